@@ -8,10 +8,6 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var (
-	databaseURL string
-)
-
 func main() {
 	rootCmd := &cobra.Command{
 		Use:   "beacon",
@@ -19,18 +15,43 @@ func main() {
 		Long:  "CLI for managing services, endpoints, and monitoring data in Beacon",
 	}
 
-	rootCmd.PersistentFlags().StringVar(&databaseURL, "db", os.Getenv("DATABASE_URL"), "Database connection string")
+	// Commands read cfg when they run, after the flags have been parsed
+	cfg := &cli.Config{}
+	rootCmd.PersistentFlags().StringVar(&cfg.DatabaseURL, "db", os.Getenv("DATABASE_URL"), "Database connection string")
+	rootCmd.PersistentFlags().StringVar(&cfg.APIURL, "api", os.Getenv("BEACON_API_URL"), "Beacon API URL; resource commands use it instead of the database")
+	rootCmd.PersistentFlags().StringVar(&cfg.Token, "token", os.Getenv("BEACON_TOKEN"), "API token for --api")
+	rootCmd.PersistentFlags().StringVar(&cfg.Project, "project", os.Getenv("BEACON_PROJECT"), "Work in an organization or project, as <organization>[/<project>]")
+	rootCmd.PersistentFlags().StringVarP(&cfg.Output, "output", "o", cli.OutputTable, "Output format: table, json, yaml, csv or jsonpath=<expr>")
+	rootCmd.PersistentFlags().BoolVar(&cfg.NoHeaders, "no-headers", false, "Leave the header row out of table and CSV output")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return cfg.CheckOutput()
+	}
 
-	rootCmd.AddCommand(cli.ServicesCmd(databaseURL))
-	rootCmd.AddCommand(cli.EndpointsCmd(databaseURL))
-	rootCmd.AddCommand(cli.PingsCmd(databaseURL))
-	rootCmd.AddCommand(cli.PingWindowsCmd(databaseURL))
-	rootCmd.AddCommand(cli.IncidentsCmd(databaseURL))
-	rootCmd.AddCommand(cli.WebhooksCmd(databaseURL))
-	rootCmd.AddCommand(cli.MonitorCmd(databaseURL))
+	rootCmd.AddCommand(cli.OrganizationsCmd(cfg))
+	rootCmd.AddCommand(cli.ProjectsCmd(cfg))
+	rootCmd.AddCommand(cli.ServicesCmd(cfg))
+	rootCmd.AddCommand(cli.EndpointsCmd(cfg))
+	rootCmd.AddCommand(cli.PingsCmd(cfg))
+	rootCmd.AddCommand(cli.PingWindowsCmd(cfg))
+	rootCmd.AddCommand(cli.IncidentsCmd(cfg))
+	rootCmd.AddCommand(cli.WebhooksCmd(cfg))
+	rootCmd.AddCommand(cli.MaintenanceCmd(cfg))
+	rootCmd.AddCommand(cli.PlanCmd(cfg))
+	rootCmd.AddCommand(cli.ApplyCmd(cfg))
+	rootCmd.AddCommand(cli.ExportCmd(cfg))
+	rootCmd.AddCommand(cli.ImportCmd(cfg))
+	rootCmd.AddCommand(cli.MonitorCmd(cfg))
+	rootCmd.AddCommand(cli.SecretsCmd(cfg))
+	rootCmd.AddCommand(cli.CertsCmd(cfg))
+	rootCmd.AddCommand(cli.HeartbeatsCmd(cfg))
+	rootCmd.AddCommand(cli.ServeCmd(cfg))
+	rootCmd.AddCommand(cli.UsersCmd(cfg))
+	rootCmd.AddCommand(cli.TokensCmd(cfg))
+	rootCmd.AddCommand(cli.AuditCmd(cfg))
+	rootCmd.AddCommand(cli.TopCmd(cfg))
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/receiver"
+	"github.com/beacon/internal/temporal"
+	"go.temporal.io/sdk/client"
+)
+
+func main() {
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is required")
+	}
+
+	temporalHost := os.Getenv("TEMPORAL_HOST")
+	if temporalHost == "" {
+		temporalHost = "localhost:7233"
+	}
+
+	addr := os.Getenv("BEACON_RECEIVER_ADDR")
+	if addr == "" {
+		addr = ":8090"
+	}
+
+	region := os.Getenv("BEACON_REGION")
+	if region == "" {
+		region = temporal.DefaultRegion
+	}
+
+	database, err := db.NewDB(databaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	c, err := client.Dial(client.Options{
+		HostPort: temporalHost,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create Temporal client: %v", err)
+	}
+	defer c.Close()
+
+	server := &http.Server{
+		Addr: addr,
+		Handler: (&receiver.Server{
+			DB:       database,
+			Temporal: c,
+			Region:   region,
+		}).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start receiver: %v", err)
+		}
+	}()
+
+	fmt.Printf("Beacon heartbeat receiver listening on %s\n", addr)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+
+	fmt.Println("Shutting down receiver...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server.Shutdown(ctx)
+}
@@ -8,7 +8,6 @@ import (
 
 	"github.com/beacon/internal/db"
 	"github.com/beacon/internal/temporal"
-	"github.com/google/uuid"
 	"go.temporal.io/sdk/client"
 )
 
@@ -47,12 +46,12 @@ func main() {
 
 	// Start monitoring workflow for each endpoint
 	for _, endpoint := range endpoints {
-		workflowID := fmt.Sprintf("monitor-endpoint-%s", endpoint.ID)
-		
-		we, err := c.ExecuteWorkflow(context.Background(), client.StartWorkflowOptions{
-			ID:        workflowID,
-			TaskQueue: "beacon-monitoring",
-		}, temporal.MonitorEndpointWorkflow, endpoint.ID, endpoint.IntervalSec)
+		orgID, err := database.EndpointOrganization(endpoint.ID)
+		if err != nil {
+			log.Printf("Failed to start workflow for endpoint %s: %v", endpoint.Name, err)
+			continue
+		}
+		we, err := temporal.StartMonitor(context.Background(), c, orgID, &endpoint)
 		
 		if err != nil {
 			log.Printf("Failed to start workflow for endpoint %s: %v", endpoint.Name, err)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"syscall"
 
 	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/secrets"
 	"github.com/beacon/internal/temporal"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
@@ -24,6 +26,9 @@ func main() {
 		temporalHost = "localhost:7233"
 	}
 
+	// Workers in a region also serve that region's PingEndpoint activities
+	region := os.Getenv("BEACON_REGION")
+
 	database, err := db.NewDB(databaseURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
@@ -38,10 +43,19 @@ func main() {
 	}
 	defer c.Close()
 
-	w := worker.New(c, "beacon-monitoring", worker.Options{})
+	w := worker.New(c, temporal.TaskQueue, worker.Options{})
+
+	keyring, err := secrets.LoadKeyring()
+	if errors.Is(err, secrets.ErrNoKey) {
+		log.Printf("Warning: %v; endpoints that reference secrets will fail", err)
+	} else if err != nil {
+		log.Fatalf("Failed to load secrets key: %v", err)
+	}
 
 	activities := &temporal.Activities{
-		DB: database,
+		DB:      database,
+		Region:  region,
+		Secrets: keyring,
 	}
 
 	w.RegisterActivity(activities.PingEndpoint)
@@ -50,8 +64,11 @@ func main() {
 	w.RegisterActivity(activities.AggregateMetrics)
 	w.RegisterActivity(activities.CleanupOldData)
 	w.RegisterActivity(activities.GetEnabledEndpoints)
+	w.RegisterActivity(activities.GetMonitorConfig)
+	w.RegisterActivity(activities.CheckHeartbeat)
 
 	w.RegisterWorkflow(temporal.MonitorEndpointWorkflow)
+	w.RegisterWorkflow(temporal.HeartbeatWorkflow)
 	w.RegisterWorkflow(temporal.AggregateMetricsWorkflow)
 	w.RegisterWorkflow(temporal.CleanupWorkflow)
 
@@ -60,6 +77,16 @@ func main() {
 		log.Fatalf("Failed to start worker: %v", err)
 	}
 
+	var rw worker.Worker
+	if region != "" {
+		rw = worker.New(c, temporal.RegionTaskQueue(region), worker.Options{})
+		rw.RegisterActivity(activities.PingEndpoint)
+		if err := rw.Start(); err != nil {
+			log.Fatalf("Failed to start region worker: %v", err)
+		}
+		fmt.Printf("Beacon worker serving region %s\n", region)
+	}
+
 	fmt.Println("Beacon worker started successfully")
 
 	sigChan := make(chan os.Signal, 1)
@@ -67,6 +94,9 @@ func main() {
 	<-sigChan
 
 	fmt.Println("Shutting down worker...")
+	if rw != nil {
+		rw.Stop()
+	}
 	w.Stop()
 }
 
@@ -0,0 +1,282 @@
+// Package api serves the control plane over HTTP: CRUD for organizations,
+// projects, services, endpoints, webhooks and incidents, read access to
+// pings and ping windows, starting and stopping monitors, and managing users
+// and API tokens. Every request sees only its caller's organization.
+// Routes are declared once in a table that both dispatches requests and
+// generates the OpenAPI document, so the two can't drift apart.
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/beacon/internal/db"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 1000
+	maxBodyBytes = 4 << 20
+)
+
+// Server handles API requests.
+type Server struct {
+	DB *db.DB
+	// Temporal starts and stops monitors. May be nil, in which case the
+	// monitor routes respond 503.
+	Temporal client.Client
+}
+
+// Handler returns the HTTP handler for the API.
+func (s *Server) Handler() http.Handler {
+	routes := s.routes()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimSuffix(r.URL.Path, "/")
+		var allowed []string
+		for _, rt := range routes {
+			params, ok := rt.match(path)
+			if !ok {
+				continue
+			}
+			if rt.method != r.Method {
+				allowed = append(allowed, rt.method)
+				continue
+			}
+			if !rt.access.public {
+				var err error
+				if r, err = s.authenticate(r, rt.access); err != nil {
+					if apiErr, ok := err.(*Error); ok && apiErr.Status == http.StatusUnauthorized {
+						w.Header().Set("WWW-Authenticate", `Bearer realm="beacon"`)
+					}
+					writeError(w, r, err)
+					return
+				}
+			}
+			if err := rt.handler(w, r, params); err != nil {
+				writeError(w, r, err)
+			}
+			return
+		}
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, r, errorf(http.StatusMethodNotAllowed, "method_not_allowed", "%s is not allowed on %s", r.Method, r.URL.Path))
+			return
+		}
+		writeError(w, r, errorf(http.StatusNotFound, "not_found", "no route for %s", r.URL.Path))
+	})
+}
+
+// handlerFunc handles a matched route. Returned errors are written as JSON
+// error responses.
+type handlerFunc func(w http.ResponseWriter, r *http.Request, params map[string]string) error
+
+// route is one API operation. pattern segments in braces, like {id}, are
+// path parameters. Every route but the public ones needs an API token.
+type route struct {
+	method  string
+	pattern string
+	access  access
+	handler handlerFunc
+	doc     operation
+}
+
+func (rt route) match(path string) (map[string]string, bool) {
+	want := strings.Split(strings.Trim(rt.pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, segment := range want {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if got[i] == "" {
+				return nil, false
+			}
+			params[segment[1:len(segment)-1]] = got[i]
+		} else if segment != got[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// Error is the body of every error response, as {"error": {...}}.
+type Error struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func errorf(status int, code, format string, args ...interface{}) *Error {
+	return &Error{Status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func badRequest(format string, args ...interface{}) *Error {
+	return errorf(http.StatusBadRequest, "bad_request", format, args...)
+}
+
+func invalid(err error) *Error {
+	return errorf(http.StatusUnprocessableEntity, "validation_failed", "%v", err)
+}
+
+func notFound(resource string) *Error {
+	return errorf(http.StatusNotFound, "not_found", "%s not found", resource)
+}
+
+// lookupError turns a failed lookup into a 404 if the row doesn't exist.
+func lookupError(err error, resource string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(resource)
+	}
+	return err
+}
+
+// writeError writes err as a JSON error. Quota errors from the database
+// become 422, and not-empty and taken-slug errors 409; other errors that
+// aren't *Error are logged and reported as a generic 500 so database
+// details don't leak.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *Error
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.Is(err, db.ErrQuotaExceeded):
+		apiErr = errorf(http.StatusUnprocessableEntity, "quota_exceeded", "%v", err)
+	case errors.Is(err, db.ErrNotEmpty), errors.Is(err, db.ErrSlugTaken):
+		apiErr = errorf(http.StatusConflict, "conflict", "%v", err)
+	default:
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		apiErr = errorf(http.StatusInternalServerError, "internal", "internal server error")
+	}
+	writeJSON(w, apiErr.Status, map[string]*Error{"error": apiErr})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(body)
+}
+
+// decodeJSON decodes a request body into v, rejecting unknown fields so
+// typos don't pass silently.
+func decodeJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return badRequest("invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// applyPatch applies a JSON merge patch (RFC 7386) from the request body to
+// v: fields present replace the current value, null removes it, and objects
+// are merged recursively.
+func applyPatch(r *http.Request, v interface{}) error {
+	var patch interface{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes)).Decode(&patch); err != nil {
+		return badRequest("invalid JSON body: %v", err)
+	}
+	if _, ok := patch.(map[string]interface{}); !ok {
+		return badRequest("patch must be a JSON object")
+	}
+
+	current, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(current, &doc); err != nil {
+		return err
+	}
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return err
+	}
+
+	// Decode into a new value rather than over v: decoding over it would
+	// keep map entries the patch removed, and change the maps and pointers
+	// v shares with the caller's copy of the current state
+	patched := reflect.New(reflect.TypeOf(v).Elem())
+	dec := json.NewDecoder(strings.NewReader(string(merged)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(patched.Interface()); err != nil {
+		return badRequest("invalid patch: %v", err)
+	}
+	reflect.ValueOf(v).Elem().Set(patched.Elem())
+	return nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatch(targetObj[key], value)
+		}
+	}
+	return targetObj
+}
+
+// Page is the body of list responses. NextOffset is set when there may be
+// more results.
+type Page struct {
+	Data       interface{} `json:"data"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	NextOffset *int        `json:"next_offset,omitempty"`
+}
+
+// pagination reads the limit and offset query parameters.
+func pagination(r *http.Request) (limit, offset int, err error) {
+	limit, offset = defaultLimit, 0
+	query := r.URL.Query()
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, badRequest("limit must be between 1 and %d", maxLimit)
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, badRequest("offset must be a non-negative integer")
+		}
+	}
+	return limit, offset, nil
+}
+
+// writePage writes the requested page of items, which must be a slice
+// holding everything from the first result onwards.
+func writePage[T any](w http.ResponseWriter, items []T, limit, offset int) {
+	page := Page{Data: []T{}, Limit: limit, Offset: offset}
+	if offset < len(items) {
+		end := offset + limit
+		if end < len(items) {
+			page.NextOffset = &end
+		} else {
+			end = len(items)
+		}
+		page.Data = items[offset:end]
+	}
+	writeJSON(w, http.StatusOK, page)
+}
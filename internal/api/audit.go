@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/beacon/internal/db"
+)
+
+// listAudit lists the audit log, newest first. Organization admins see
+// their organization's entries, and operators every entry.
+func (s *Server) listAudit(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	filter := db.AuditFilter{
+		EntityType: query.Get("entity_type"),
+		Actor:      query.Get("actor"),
+		// One extra row tells whether there is a next page
+		Limit: offset + limit + 1,
+	}
+	if filter.EntityID, err = queryID(r, "entity_id"); err != nil {
+		return err
+	}
+	if v := query.Get("since"); v != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return badRequest("invalid since %q: use RFC 3339, e.g. 2024-01-02T15:04:05Z", v)
+		}
+	}
+	entries, err := s.dbFor(r).ListAuditEntries(filter)
+	if err != nil {
+		return err
+	}
+	writePage(w, entries, limit, offset)
+	return nil
+}
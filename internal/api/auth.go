@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// access is what a route requires of the caller. Routes name a resource
+// and level, which the token's scopes must allow and which map to a role;
+// handlers then check the role against the service they touch.
+type access struct {
+	resource string
+	level    string
+	// public routes don't need a token.
+	public bool
+}
+
+var (
+	public = access{public: true}
+	// signedIn routes only need a valid token.
+	signedIn = access{}
+)
+
+func read(resource string) access  { return access{resource: resource, level: models.ScopeRead} }
+func write(resource string) access { return access{resource: resource, level: models.ScopeWrite} }
+
+// role returns the role the access needs. Everyone can manage their own
+// tokens; managing users, organizations and projects, and reading the
+// audit log, takes an admin.
+func (a access) role() string {
+	switch {
+	case a.resource == "" || a.resource == "tokens":
+		return ""
+	case a.resource == "users" || a.resource == "audit",
+		(a.resource == "organizations" || a.resource == "projects") && a.level == models.ScopeWrite:
+		return models.RoleAdmin
+	case a.level == models.ScopeWrite:
+		return models.RoleEditor
+	default:
+		return models.RoleViewer
+	}
+}
+
+// caller is the authenticated user and token behind a request, the access
+// the matched route requires, and the view of the database they get.
+type caller struct {
+	user   *models.User
+	token  *models.APIToken
+	access access
+	db     *db.DB
+}
+
+type contextKey struct{}
+
+func callerFrom(r *http.Request) *caller {
+	c, _ := r.Context().Value(contextKey{}).(*caller)
+	return c
+}
+
+// dbFor returns the database as the request's caller sees it: limited to
+// their organization, and to the project they asked for if any.
+func (s *Server) dbFor(r *http.Request) *db.DB {
+	return callerFrom(r).db
+}
+
+// isOperator reports whether the caller is outside every organization and
+// so can manage all of them.
+func isOperator(r *http.Request) bool {
+	return callerFrom(r).user.OrganizationID == nil
+}
+
+// touchInterval limits how often a token's last use is written.
+const touchInterval = time.Minute
+
+// ProjectHeader narrows a request to one project, or organization for
+// operators, named as org/project or org.
+const ProjectHeader = "Beacon-Project"
+
+// authenticate checks the request's bearer token and that it and its user
+// allow the route at all. Service-level checks are left to the handlers.
+func (s *Server) authenticate(r *http.Request, a access) (*http.Request, error) {
+	header := r.Header.Get("Authorization")
+	scheme, secret, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "Bearer") || secret == "" {
+		return nil, errorf(http.StatusUnauthorized, "unauthorized", "an API token is required (Authorization: Bearer <token>)")
+	}
+
+	token, err := s.DB.GetAPITokenByHash(db.HashAPIToken(strings.TrimSpace(secret)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errorf(http.StatusUnauthorized, "unauthorized", "invalid API token")
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !token.Active(now) {
+		return nil, errorf(http.StatusUnauthorized, "unauthorized", "API token has expired or been revoked")
+	}
+	user, err := s.DB.GetUser(token.UserID)
+	if err != nil {
+		return nil, lookupError(err, "user")
+	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > touchInterval {
+		if err := s.DB.TouchAPIToken(token.ID, now); err != nil {
+			log.Printf("Failed to record use of token %s: %v", token.ID, err)
+		}
+	}
+
+	if a.resource != "" && !models.ScopesAllow(token.Scopes, a.resource, a.level) {
+		return nil, forbidden("this token lacks the %s:%s scope", a.resource, a.level)
+	}
+	if role := a.role(); models.RoleRank(user.MaxRole()) < models.RoleRank(role) {
+		return nil, forbidden("this requires the %s role", role)
+	}
+
+	database := s.DB
+	if user.OrganizationID != nil {
+		database = s.DB.ForTenant(db.Tenant{OrganizationID: *user.OrganizationID})
+	}
+	if ref := r.Header.Get(ProjectHeader); ref != "" {
+		// Looked up in the caller's view, so other organizations' projects
+		// aren't found
+		tenant, err := database.ResolveTenant(ref)
+		if err != nil {
+			return nil, lookupError(err, "project "+ref)
+		}
+		database = s.DB.ForTenant(tenant)
+	}
+
+	// Changes are recorded in the audit log as made by the user
+	database = database.AsActor(user.Email)
+
+	c := &caller{user: user, token: token, access: a, db: database}
+	return r.WithContext(context.WithValue(r.Context(), contextKey{}, c)), nil
+}
+
+func forbidden(format string, args ...interface{}) *Error {
+	return errorf(http.StatusForbidden, "forbidden", format, args...)
+}
+
+// authorize checks that the caller has the route's role on a service.
+func authorize(r *http.Request, serviceID uuid.UUID) error {
+	c := callerFrom(r)
+	role := c.access.role()
+	if models.RoleRank(c.user.RoleFor(serviceID)) < models.RoleRank(role) {
+		return forbidden("this requires the %s role on service %s", role, serviceID)
+	}
+	return nil
+}
+
+// authorizeGlobal checks that the caller's own role, rather than a grant on
+// some service, meets the route's role. It guards actions that aren't tied
+// to an existing service, like creating one.
+func authorizeGlobal(r *http.Request) error {
+	c := callerFrom(r)
+	role := c.access.role()
+	if models.RoleRank(c.user.Role) < models.RoleRank(role) {
+		return forbidden("this requires the %s role", role)
+	}
+	return nil
+}
+
+// visible reports whether the caller can read a service's resources.
+func visible(r *http.Request, serviceID uuid.UUID) bool {
+	return models.RoleRank(callerFrom(r).user.RoleFor(serviceID)) >= models.RoleRank(models.RoleViewer)
+}
+
+// authorizeEndpoint checks that the caller has the route's role on the
+// service of an endpoint.
+func (s *Server) authorizeEndpoint(r *http.Request, endpointID uuid.UUID) error {
+	serviceID, err := s.endpointService(r, endpointID)
+	if err != nil {
+		return err
+	}
+	return authorize(r, serviceID)
+}
+
+// endpointService returns the service of an endpoint, for authorizing
+// access to its incidents and pings. Deleted endpoints have no service, so
+// only the caller's own role counts for them; their records are still
+// limited to the caller's organization by the database view.
+func (s *Server) endpointService(r *http.Request, endpointID uuid.UUID) (uuid.UUID, error) {
+	endpoint, err := s.dbFor(r).GetEndpoint(endpointID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get endpoint: %w", err)
+	}
+	return endpoint.ServiceID, nil
+}
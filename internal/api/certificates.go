@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+func (s *Server) listCertificates(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	certs, err := s.dbFor(r).ListCertificates()
+	if err != nil {
+		return err
+	}
+
+	// Certificates are visible to those who can see their endpoint's service
+	endpoints, err := s.dbFor(r).ListEndpoints(nil)
+	if err != nil {
+		return err
+	}
+	services := make(map[uuid.UUID]uuid.UUID, len(endpoints))
+	for _, endpoint := range endpoints {
+		services[endpoint.ID] = endpoint.ServiceID
+	}
+	filtered := []models.Certificate{}
+	for _, cert := range certs {
+		if visible(r, services[cert.EndpointID]) {
+			filtered = append(filtered, cert)
+		}
+	}
+	writePage(w, filtered, limit, offset)
+	return nil
+}
@@ -0,0 +1,553 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// Client calls the API with a token. Its methods mirror those of *db.DB
+// that the CLI uses, so the CLI can work through either.
+type Client struct {
+	BaseURL string
+	Token   string
+	// Project, if set, is sent as the Beacon-Project header to narrow
+	// requests to an organization or project.
+	Project string
+	HTTP    *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Close is a no-op, for symmetry with *db.DB.
+func (c *Client) Close() error {
+	return nil
+}
+
+// do sends a request and decodes the response into out, if it isn't nil.
+// Error responses are returned as *Error.
+func (c *Client) do(method, path string, query url.Values, contentType string, body, out interface{}) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.Project != "" {
+		req.Header.Set(ProjectHeader, c.Project)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var body struct {
+			Error *Error `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) != nil || body.Error == nil {
+			return &Error{Status: resp.StatusCode, Code: "http_error", Message: resp.Status}
+		}
+		body.Error.Status = resp.StatusCode
+		return body.Error
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) get(path string, out interface{}) error {
+	return c.do(http.MethodGet, path, nil, "", nil, out)
+}
+
+func (c *Client) post(path string, body, out interface{}) error {
+	return c.do(http.MethodPost, path, nil, "application/json", body, out)
+}
+
+func (c *Client) delete(path string) error {
+	return c.do(http.MethodDelete, path, nil, "", nil, nil)
+}
+
+// update sends the changes from the resource at path to v as a merge patch,
+// and decodes the result into v. Sending only the changes keeps credentials
+// the API redacted from being overwritten with their masks.
+func (c *Client) update(path string, v interface{}) error {
+	current := reflect.New(reflect.TypeOf(v).Elem()).Interface()
+	if err := c.get(path, current); err != nil {
+		return err
+	}
+	patch, err := diffPatch(current, v)
+	if err != nil {
+		return err
+	}
+	return c.do(http.MethodPatch, path, nil, "application/merge-patch+json", patch, v)
+}
+
+// diffPatch returns the merge patch that turns from into to.
+func diffPatch(from, to interface{}) (map[string]interface{}, error) {
+	var a, b map[string]interface{}
+	for _, pair := range []struct {
+		v   interface{}
+		out *map[string]interface{}
+	}{{from, &a}, {to, &b}} {
+		data, err := json.Marshal(pair.v)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, pair.out); err != nil {
+			return nil, err
+		}
+	}
+	return diffObjects(a, b), nil
+}
+
+func diffObjects(a, b map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for key, value := range b {
+		old, ok := a[key]
+		if ok && reflect.DeepEqual(old, value) {
+			continue
+		}
+		oldObj, oldIsObj := old.(map[string]interface{})
+		newObj, newIsObj := value.(map[string]interface{})
+		if oldIsObj && newIsObj {
+			patch[key] = diffObjects(oldObj, newObj)
+		} else {
+			patch[key] = value
+		}
+	}
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
+
+// list fetches up to want results from a list route (all if want is 0),
+// following its pages.
+func list[T any](c *Client, path string, query url.Values, want int) ([]T, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	items := []T{}
+	offset := 0
+	for {
+		limit := maxLimit
+		if want > 0 && want-len(items) < limit {
+			limit = want - len(items)
+		}
+		query.Set("limit", strconv.Itoa(limit))
+		query.Set("offset", strconv.Itoa(offset))
+
+		var page struct {
+			Data       []T  `json:"data"`
+			NextOffset *int `json:"next_offset"`
+		}
+		if err := c.do(http.MethodGet, path, query, "", nil, &page); err != nil {
+			return nil, err
+		}
+		items = append(items, page.Data...)
+		if page.NextOffset == nil || (want > 0 && len(items) >= want) {
+			return items, nil
+		}
+		offset = *page.NextOffset
+	}
+}
+
+func idQuery(name string, id *uuid.UUID) url.Values {
+	query := url.Values{}
+	if id != nil {
+		query.Set(name, id.String())
+	}
+	return query
+}
+
+func (c *Client) CreateOrganization(org *models.Organization) error {
+	return c.post("/v1/organizations", org, org)
+}
+
+func (c *Client) GetOrganization(id uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	if err := c.get("/v1/organizations/"+id.String(), &org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (c *Client) GetOrganizationBySlug(slug string) (*models.Organization, error) {
+	orgs, err := list[models.Organization](c, "/v1/organizations", url.Values{"slug": {slug}}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) == 0 {
+		return nil, notFound("organization " + slug)
+	}
+	return &orgs[0], nil
+}
+
+func (c *Client) ListOrganizations() ([]models.Organization, error) {
+	return list[models.Organization](c, "/v1/organizations", nil, 0)
+}
+
+func (c *Client) UpdateOrganization(org *models.Organization) error {
+	return c.update("/v1/organizations/"+org.ID.String(), org)
+}
+
+func (c *Client) DeleteOrganization(id uuid.UUID) error {
+	return c.delete("/v1/organizations/" + id.String())
+}
+
+func (c *Client) CreateProject(project *models.Project) error {
+	return c.post("/v1/projects", project, project)
+}
+
+func (c *Client) GetProject(id uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	if err := c.get("/v1/projects/"+id.String(), &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (c *Client) ListProjects(organizationID *uuid.UUID) ([]models.Project, error) {
+	return list[models.Project](c, "/v1/projects", idQuery("organization_id", organizationID), 0)
+}
+
+func (c *Client) UpdateProject(project *models.Project) error {
+	return c.update("/v1/projects/"+project.ID.String(), project)
+}
+
+func (c *Client) DeleteProject(id uuid.UUID) error {
+	return c.delete("/v1/projects/" + id.String())
+}
+
+func (c *Client) CreateMaintenanceWindow(window *models.MaintenanceWindow) error {
+	return c.post("/v1/maintenance-windows", window, window)
+}
+
+func (c *Client) GetMaintenanceWindow(id uuid.UUID) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	if err := c.get("/v1/maintenance-windows/"+id.String(), &window); err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (c *Client) ListMaintenanceWindows(serviceID *uuid.UUID) ([]models.MaintenanceWindow, error) {
+	return list[models.MaintenanceWindow](c, "/v1/maintenance-windows", idQuery("service_id", serviceID), 0)
+}
+
+func (c *Client) UpdateMaintenanceWindow(window *models.MaintenanceWindow) error {
+	return c.update("/v1/maintenance-windows/"+window.ID.String(), window)
+}
+
+func (c *Client) DeleteMaintenanceWindow(id uuid.UUID) error {
+	return c.delete("/v1/maintenance-windows/" + id.String())
+}
+
+// StartMonitor asks the API to start monitoring an endpoint.
+func (c *Client) StartMonitor(endpointID uuid.UUID) error {
+	return c.post("/v1/endpoints/"+endpointID.String()+"/monitor", nil, nil)
+}
+
+// StopMonitor asks the API to stop monitoring an endpoint.
+func (c *Client) StopMonitor(endpointID uuid.UUID) error {
+	return c.delete("/v1/endpoints/" + endpointID.String() + "/monitor")
+}
+
+// ListAuditEntries lists the audit log, up to filter.Limit entries if set.
+func (c *Client) ListAuditEntries(filter db.AuditFilter) ([]models.AuditEntry, error) {
+	query := idQuery("entity_id", filter.EntityID)
+	if filter.EntityType != "" {
+		query.Set("entity_type", filter.EntityType)
+	}
+	if filter.Actor != "" {
+		query.Set("actor", filter.Actor)
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	return list[models.AuditEntry](c, "/v1/audit", query, filter.Limit)
+}
+
+func (c *Client) CreateService(service *models.Service) error {
+	return c.post("/v1/services", service, service)
+}
+
+func (c *Client) GetService(id uuid.UUID) (*models.Service, error) {
+	var service models.Service
+	if err := c.get("/v1/services/"+id.String(), &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+func (c *Client) ListServices() ([]models.Service, error) {
+	return list[models.Service](c, "/v1/services", nil, 0)
+}
+
+// ResolveService looks a service up by ID, slug or name, like
+// db.ResolveService.
+func (c *Client) ResolveService(ref string) (*models.Service, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return c.GetService(id)
+	}
+	services, err := list[models.Service](c, "/v1/services", url.Values{"ref": {ref}}, 0)
+	if err != nil {
+		return nil, err
+	}
+	return db.Unique("service", ref, services, func(s models.Service) string {
+		return fmt.Sprintf("%s (%s)", s.Slug, s.ID)
+	})
+}
+
+func (c *Client) UpdateService(service *models.Service) error {
+	return c.update("/v1/services/"+service.ID.String(), service)
+}
+
+func (c *Client) DeleteService(id uuid.UUID) error {
+	return c.delete("/v1/services/" + id.String())
+}
+
+func (c *Client) CreateEndpoint(endpoint *models.ServiceEndpoint) error {
+	return c.post("/v1/endpoints", endpoint, endpoint)
+}
+
+func (c *Client) GetEndpoint(id uuid.UUID) (*models.ServiceEndpoint, error) {
+	var endpoint models.ServiceEndpoint
+	if err := c.get("/v1/endpoints/"+id.String(), &endpoint); err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (c *Client) ListEndpoints(serviceID *uuid.UUID) ([]models.ServiceEndpoint, error) {
+	return list[models.ServiceEndpoint](c, "/v1/endpoints", idQuery("service_id", serviceID), 0)
+}
+
+// ResolveEndpoint looks an endpoint up by ID or by reference, like
+// db.ResolveEndpoint.
+func (c *Client) ResolveEndpoint(ref string) (*models.ServiceEndpoint, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return c.GetEndpoint(id)
+	}
+	endpoints, err := list[models.ServiceEndpoint](c, "/v1/endpoints", url.Values{"ref": {ref}}, 0)
+	if err != nil {
+		return nil, err
+	}
+	return db.Unique("endpoint", ref, endpoints, func(e models.ServiceEndpoint) string {
+		service := e.ServiceID.String()
+		if s, err := c.GetService(e.ServiceID); err == nil {
+			service = s.Slug
+		}
+		return fmt.Sprintf("%s/%s (%s)", service, e.Slug, e.ID)
+	})
+}
+
+func (c *Client) UpdateEndpoint(endpoint *models.ServiceEndpoint) error {
+	return c.update("/v1/endpoints/"+endpoint.ID.String(), endpoint)
+}
+
+func (c *Client) DeleteEndpoint(id uuid.UUID) error {
+	return c.delete("/v1/endpoints/" + id.String())
+}
+
+func (c *Client) CreateWebhook(webhook *models.Webhook) error {
+	return c.post("/v1/webhooks", webhook, webhook)
+}
+
+func (c *Client) GetWebhook(id uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := c.get("/v1/webhooks/"+id.String(), &webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (c *Client) ListWebhooks(serviceID *uuid.UUID) ([]models.Webhook, error) {
+	return list[models.Webhook](c, "/v1/webhooks", idQuery("service_id", serviceID), 0)
+}
+
+func (c *Client) UpdateWebhook(webhook *models.Webhook) error {
+	return c.update("/v1/webhooks/"+webhook.ID.String(), webhook)
+}
+
+func (c *Client) DeleteWebhook(id uuid.UUID) error {
+	return c.delete("/v1/webhooks/" + id.String())
+}
+
+func (c *Client) GetIncident(id uuid.UUID) (*models.Incident, error) {
+	var incident models.Incident
+	if err := c.get("/v1/incidents/"+id.String(), &incident); err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// ListCertificates lists the certificates tls checks have seen, soonest to
+// expire first.
+func (c *Client) ListCertificates() ([]models.Certificate, error) {
+	return list[models.Certificate](c, "/v1/certificates", nil, 0)
+}
+
+func (c *Client) ListIncidents(endpointID *uuid.UUID, status string) ([]models.Incident, error) {
+	query := idQuery("endpoint_id", endpointID)
+	if status != "" {
+		query.Set("status", status)
+	}
+	return list[models.Incident](c, "/v1/incidents", query, 0)
+}
+
+func (c *Client) ResolveIncident(id uuid.UUID) error {
+	return c.post("/v1/incidents/"+id.String()+"/resolve", nil, nil)
+}
+
+func (c *Client) GetPing(id uuid.UUID) (*models.Ping, error) {
+	var ping models.Ping
+	if err := c.get("/v1/pings/"+id.String(), &ping); err != nil {
+		return nil, err
+	}
+	return &ping, nil
+}
+
+func regionQuery(region string) url.Values {
+	query := url.Values{}
+	if region != "" {
+		query.Set("region", region)
+	}
+	return query
+}
+
+func rangeQuery(region string, start, end time.Time) url.Values {
+	query := regionQuery(region)
+	query.Set("start", start.Format(time.RFC3339))
+	query.Set("end", end.Format(time.RFC3339))
+	return query
+}
+
+func (c *Client) ListPings(endpointID uuid.UUID, region string, limit int) ([]models.Ping, error) {
+	return list[models.Ping](c, "/v1/endpoints/"+endpointID.String()+"/pings", regionQuery(region), limit)
+}
+
+func (c *Client) ListPingsByTimeRange(endpointID uuid.UUID, region string, start, end time.Time) ([]models.Ping, error) {
+	return list[models.Ping](c, "/v1/endpoints/"+endpointID.String()+"/pings", rangeQuery(region, start, end), 0)
+}
+
+func (c *Client) GetPingWindow(id uuid.UUID) (*models.PingWindow, error) {
+	var window models.PingWindow
+	if err := c.get("/v1/ping-windows/"+id.String(), &window); err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (c *Client) ListPingWindows(endpointID uuid.UUID, region string, limit int) ([]models.PingWindow, error) {
+	return list[models.PingWindow](c, "/v1/endpoints/"+endpointID.String()+"/ping-windows", regionQuery(region), limit)
+}
+
+func (c *Client) ListPingWindowsByTimeRange(endpointID uuid.UUID, region string, start, end time.Time) ([]models.PingWindow, error) {
+	return list[models.PingWindow](c, "/v1/endpoints/"+endpointID.String()+"/ping-windows", rangeQuery(region, start, end), 0)
+}
+
+func (c *Client) CreateUser(user *models.User) error {
+	return c.post("/v1/users", user, user)
+}
+
+func (c *Client) GetUser(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := c.get("/v1/users/"+id.String(), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) GetUserByEmail(email string) (*models.User, error) {
+	users, err := list[models.User](c, "/v1/users", url.Values{"email": {email}}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, notFound("user " + email)
+	}
+	return &users[0], nil
+}
+
+func (c *Client) ListUsers() ([]models.User, error) {
+	return list[models.User](c, "/v1/users", nil, 0)
+}
+
+func (c *Client) UpdateUser(user *models.User) error {
+	return c.update("/v1/users/"+user.ID.String(), user)
+}
+
+func (c *Client) DeleteUser(id uuid.UUID) error {
+	return c.delete("/v1/users/" + id.String())
+}
+
+func (c *Client) SetServiceGrant(grant *models.ServiceGrant) error {
+	path := fmt.Sprintf("/v1/users/%s/grants/%s", grant.UserID, grant.ServiceID)
+	return c.do(http.MethodPut, path, nil, "application/json", GrantRequest{Role: grant.Role}, nil)
+}
+
+func (c *Client) DeleteServiceGrant(userID, serviceID uuid.UUID) error {
+	return c.delete(fmt.Sprintf("/v1/users/%s/grants/%s", userID, serviceID))
+}
+
+// IssueAPIToken asks the API for a token. A nil UserID issues one for the
+// caller.
+func (c *Client) IssueAPIToken(token *models.APIToken) (string, error) {
+	var issued IssuedToken
+	if err := c.post("/v1/tokens", token, &issued); err != nil {
+		return "", err
+	}
+	*token = issued.APIToken
+	return issued.Token, nil
+}
+
+// ListAPITokens lists the caller's tokens, or another user's.
+func (c *Client) ListAPITokens(userID *uuid.UUID) ([]models.APIToken, error) {
+	return list[models.APIToken](c, "/v1/tokens", idQuery("user_id", userID), 0)
+}
+
+func (c *Client) RevokeAPIToken(id uuid.UUID) error {
+	return c.delete("/v1/tokens/" + id.String())
+}
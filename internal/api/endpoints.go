@@ -0,0 +1,166 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/beacon/internal/models"
+)
+
+func (s *Server) listEndpoints(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	serviceID, err := queryID(r, "service_id")
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	checkType := query.Get("check_type")
+	tag := query.Get("tag")
+	var enabled *bool
+	if v := query.Get("enabled"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return badRequest("invalid enabled %q", v)
+		}
+		enabled = &b
+	}
+
+	var endpoints []models.ServiceEndpoint
+	if ref := query.Get("ref"); ref != "" {
+		endpoints, err = s.dbFor(r).FindEndpoints(ref)
+	} else {
+		endpoints, err = s.dbFor(r).ListEndpoints(serviceID)
+	}
+	if err != nil {
+		return err
+	}
+	filtered := []models.ServiceEndpoint{}
+	for _, endpoint := range endpoints {
+		if serviceID != nil && endpoint.ServiceID != *serviceID {
+			continue
+		}
+		if checkType != "" && endpoint.CheckType != checkType {
+			continue
+		}
+		if enabled != nil && endpoint.Enabled != *enabled {
+			continue
+		}
+		if tag != "" && !endpoint.HasTag(tag) {
+			continue
+		}
+		if !visible(r, endpoint.ServiceID) {
+			continue
+		}
+		filtered = append(filtered, endpoint.Redacted())
+	}
+	writePage(w, filtered, limit, offset)
+	return nil
+}
+
+func (s *Server) createEndpoint(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	endpoint := models.NewEndpoint()
+	if err := decodeJSON(r, &endpoint); err != nil {
+		return err
+	}
+
+	// Heartbeats are given a check-in URL instead of probing one
+	isHeartbeat := endpoint.CheckType == models.CheckHeartbeat
+	if isHeartbeat && endpoint.URL != "" {
+		return invalid(fmt.Errorf("heartbeat endpoints are given a check-in URL; don't set URL"))
+	}
+	if err := endpoint.Validate(); err != nil {
+		return invalid(err)
+	}
+	if err := s.requireService(r, endpoint.ServiceID); err != nil {
+		return err
+	}
+	if err := authorize(r, endpoint.ServiceID); err != nil {
+		return err
+	}
+
+	if err := s.dbFor(r).CreateEndpoint(&endpoint); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, endpoint.Redacted())
+	return nil
+}
+
+func (s *Server) getEndpoint(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	endpoint, err := s.dbFor(r).GetEndpoint(id)
+	if err != nil {
+		return lookupError(err, "endpoint")
+	}
+	if err := authorize(r, endpoint.ServiceID); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, endpoint.Redacted())
+	return nil
+}
+
+// updateEndpoint applies a merge patch to the stored endpoint. The patch is
+// applied to the unredacted endpoint, so credentials that aren't in the
+// patch are kept, and credentials sent back masked as GET returned them
+// keep their stored values.
+func (s *Server) updateEndpoint(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	endpoint, err := s.dbFor(r).GetEndpoint(id)
+	if err != nil {
+		return lookupError(err, "endpoint")
+	}
+	if err := authorize(r, endpoint.ServiceID); err != nil {
+		return err
+	}
+	current := *endpoint
+	if err := applyPatch(r, endpoint); err != nil {
+		return err
+	}
+	// Endpoints can't move between services
+	endpoint.ID, endpoint.ServiceID = current.ID, current.ServiceID
+	endpoint.CreatedAt, endpoint.DeletedAt = current.CreatedAt, current.DeletedAt
+	endpoint.KeepRedacted(current)
+	if err := endpoint.Validate(); err != nil {
+		return invalid(err)
+	}
+
+	if endpoint.CheckType == models.CheckHeartbeat && endpoint.URL != current.URL {
+		return invalid(fmt.Errorf("heartbeat endpoints are given a check-in URL; don't set URL"))
+	}
+	if current.CheckType == models.CheckHeartbeat && endpoint.CheckType != models.CheckHeartbeat && endpoint.URL == current.URL {
+		return invalid(fmt.Errorf("the check-in URL only works for heartbeat endpoints; set the URL to check"))
+	}
+	if err := s.dbFor(r).UpdateEndpoint(endpoint); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, endpoint.Redacted())
+	return nil
+}
+
+func (s *Server) deleteEndpoint(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	endpoint, err := s.dbFor(r).GetEndpoint(id)
+	if err != nil {
+		return lookupError(err, "endpoint")
+	}
+	if err := authorize(r, endpoint.ServiceID); err != nil {
+		return err
+	}
+	if err := s.dbFor(r).DeleteEndpoint(id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+func (s *Server) listIncidents(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	endpointID, err := queryID(r, "endpoint_id")
+	if err != nil {
+		return err
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "open" && status != "resolved" {
+		return badRequest("invalid status %q (use open or resolved)", status)
+	}
+	incidents, err := s.dbFor(r).ListIncidents(endpointID, status)
+	if err != nil {
+		return err
+	}
+
+	// Incidents are visible to those who can see their endpoint's service
+	endpoints, err := s.dbFor(r).ListEndpoints(nil)
+	if err != nil {
+		return err
+	}
+	services := make(map[uuid.UUID]uuid.UUID, len(endpoints))
+	for _, endpoint := range endpoints {
+		services[endpoint.ID] = endpoint.ServiceID
+	}
+	filtered := []models.Incident{}
+	for _, incident := range incidents {
+		if visible(r, services[incident.EndpointID]) {
+			filtered = append(filtered, incident)
+		}
+	}
+	writePage(w, filtered, limit, offset)
+	return nil
+}
+
+func (s *Server) getIncident(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	incident, err := s.dbFor(r).GetIncident(id)
+	if err != nil {
+		return lookupError(err, "incident")
+	}
+	if err := s.authorizeEndpoint(r, incident.EndpointID); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, incident)
+	return nil
+}
+
+func (s *Server) resolveIncident(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	incident, err := s.dbFor(r).GetIncident(id)
+	if err != nil {
+		return lookupError(err, "incident")
+	}
+	if err := s.authorizeEndpoint(r, incident.EndpointID); err != nil {
+		return err
+	}
+	if incident.Status != "resolved" {
+		if err := s.dbFor(r).ResolveIncident(id); err != nil {
+			return err
+		}
+		if incident, err = s.dbFor(r).GetIncident(id); err != nil {
+			return err
+		}
+	}
+	writeJSON(w, http.StatusOK, incident)
+	return nil
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/beacon/internal/models"
+)
+
+func (s *Server) listMaintenanceWindows(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	serviceID, err := queryID(r, "service_id")
+	if err != nil {
+		return err
+	}
+	windows, err := s.dbFor(r).ListMaintenanceWindows(serviceID)
+	if err != nil {
+		return err
+	}
+	filtered := []models.MaintenanceWindow{}
+	for _, window := range windows {
+		if visible(r, window.ServiceID) {
+			filtered = append(filtered, window)
+		}
+	}
+	writePage(w, filtered, limit, offset)
+	return nil
+}
+
+func (s *Server) createMaintenanceWindow(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	var window models.MaintenanceWindow
+	if err := decodeJSON(r, &window); err != nil {
+		return err
+	}
+	if err := window.Validate(); err != nil {
+		return invalid(err)
+	}
+	if err := s.requireService(r, window.ServiceID); err != nil {
+		return err
+	}
+	if err := authorize(r, window.ServiceID); err != nil {
+		return err
+	}
+	if err := s.dbFor(r).CreateMaintenanceWindow(&window); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, window)
+	return nil
+}
+
+func (s *Server) getMaintenanceWindow(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	window, err := s.dbFor(r).GetMaintenanceWindow(id)
+	if err != nil {
+		return lookupError(err, "maintenance window")
+	}
+	if err := authorize(r, window.ServiceID); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, window)
+	return nil
+}
+
+func (s *Server) updateMaintenanceWindow(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	window, err := s.dbFor(r).GetMaintenanceWindow(id)
+	if err != nil {
+		return lookupError(err, "maintenance window")
+	}
+	if err := authorize(r, window.ServiceID); err != nil {
+		return err
+	}
+	current := *window
+	if err := applyPatch(r, window); err != nil {
+		return err
+	}
+	// The service a window belongs to can't change
+	window.ID, window.ServiceID = current.ID, current.ServiceID
+	window.CreatedAt, window.DeletedAt = current.CreatedAt, current.DeletedAt
+	if err := window.Validate(); err != nil {
+		return invalid(err)
+	}
+	if err := s.dbFor(r).UpdateMaintenanceWindow(window); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, window)
+	return nil
+}
+
+func (s *Server) deleteMaintenanceWindow(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	window, err := s.dbFor(r).GetMaintenanceWindow(id)
+	if err != nil {
+		return lookupError(err, "maintenance window")
+	}
+	if err := authorize(r, window.ServiceID); err != nil {
+		return err
+	}
+	if err := s.dbFor(r).DeleteMaintenanceWindow(id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/beacon/internal/temporal"
+	"go.temporal.io/api/serviceerror"
+)
+
+// MonitorRun identifies the workflow monitoring an endpoint.
+type MonitorRun struct {
+	WorkflowID string `json:"workflow_id"`
+	RunID      string `json:"run_id"`
+}
+
+func (s *Server) requireTemporal() error {
+	if s.Temporal == nil {
+		return errorf(http.StatusServiceUnavailable, "unavailable", "monitors can't be managed: no Temporal connection")
+	}
+	return nil
+}
+
+func (s *Server) startMonitor(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := s.requireTemporal(); err != nil {
+		return err
+	}
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	endpoint, err := s.dbFor(r).GetEndpoint(id)
+	if err != nil {
+		return lookupError(err, "endpoint")
+	}
+	if err := authorize(r, endpoint.ServiceID); err != nil {
+		return err
+	}
+	orgID, err := s.DB.EndpointOrganization(id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	run, err := temporal.StartMonitor(ctx, s.Temporal, orgID, endpoint)
+	var started *serviceerror.WorkflowExecutionAlreadyStarted
+	if errors.As(err, &started) {
+		return errorf(http.StatusConflict, "conflict", "endpoint %s is already being monitored", id)
+	}
+	if err != nil {
+		return err
+	}
+	// The monitor is running either way, so a failure to record it
+	// shouldn't fail the request
+	if err := s.dbFor(r).RecordMonitorChange(id, models.AuditStart); err != nil {
+		log.Printf("Failed to record monitor start for endpoint %s: %v", id, err)
+	}
+	writeJSON(w, http.StatusAccepted, MonitorRun{WorkflowID: run.GetID(), RunID: run.GetRunID()})
+	return nil
+}
+
+func (s *Server) stopMonitor(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := s.requireTemporal(); err != nil {
+		return err
+	}
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	serviceID, err := s.endpointService(r, id)
+	if err != nil {
+		return err
+	}
+	if err := authorize(r, serviceID); err != nil {
+		return err
+	}
+	// Deleted endpoints can still be stopped, so check the organization
+	// rather than looking the endpoint up
+	orgID, err := s.DB.EndpointOrganization(id)
+	if err != nil {
+		return lookupError(err, "endpoint")
+	}
+	if t := s.dbFor(r).Tenant(); t != nil && t.OrganizationID != orgID {
+		return notFound("endpoint")
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	err = temporal.StopMonitor(ctx, s.Temporal, orgID, id)
+	var notRunning *serviceerror.NotFound
+	if errors.As(err, &notRunning) {
+		return errorf(http.StatusNotFound, "not_found", "endpoint %s is not being monitored", id)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.dbFor(r).RecordMonitorChange(id, models.AuditStop); err != nil {
+		log.Printf("Failed to record monitor stop for endpoint %s: %v", id, err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
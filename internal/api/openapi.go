@@ -0,0 +1,292 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// operation documents a route for the OpenAPI document.
+type operation struct {
+	summary string
+	tag     string
+	query   []queryParam
+	// request and response are zero values of the body types, used to
+	// derive their schemas. A nil response means no body.
+	request  interface{}
+	response interface{}
+	// list wraps the response in a Page; status defaults to 200.
+	list   bool
+	status int
+	// patch marks request bodies that are JSON merge patches.
+	patch bool
+}
+
+type queryParam struct {
+	name        string
+	kind        string // string, integer, boolean
+	format      string
+	description string
+}
+
+var pageParams = []queryParam{
+	{name: "limit", kind: "integer", description: "Maximum number of results (default 50, at most 1000)"},
+	{name: "offset", kind: "integer", description: "Number of results to skip"},
+}
+
+// OpenAPI returns the OpenAPI 3 document describing the API.
+func (s *Server) OpenAPI() map[string]interface{} {
+	schemas := map[string]interface{}{
+		"Error": map[string]interface{}{
+			"type":     "object",
+			"required": []string{"error"},
+			"properties": map[string]interface{}{
+				"error": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"code", "message"},
+					"properties": map[string]interface{}{
+						"code":    map[string]interface{}{"type": "string"},
+						"message": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+	gen := &schemaGenerator{schemas: schemas}
+	errorResponse := map[string]interface{}{
+		"description": "Error",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"},
+			},
+		},
+	}
+
+	paths := map[string]interface{}{}
+	for _, rt := range s.routes() {
+		doc := rt.doc
+		op := map[string]interface{}{
+			"summary":     doc.summary,
+			"operationId": operationID(rt.method, rt.pattern),
+			"responses": map[string]interface{}{
+				"default": errorResponse,
+			},
+		}
+		if doc.tag != "" {
+			op["tags"] = []string{doc.tag}
+		}
+
+		var params []interface{}
+		for _, segment := range strings.Split(rt.pattern, "/") {
+			if strings.HasPrefix(segment, "{") {
+				params = append(params, map[string]interface{}{
+					"name":     strings.Trim(segment, "{}"),
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]interface{}{"type": "string", "format": "uuid"},
+				})
+			}
+		}
+		query := doc.query
+		if doc.list {
+			query = append(append([]queryParam{}, query...), pageParams...)
+		}
+		for _, q := range query {
+			schema := map[string]interface{}{"type": q.kind}
+			if q.format != "" {
+				schema["format"] = q.format
+			}
+			params = append(params, map[string]interface{}{
+				"name":        q.name,
+				"in":          "query",
+				"description": q.description,
+				"schema":      schema,
+			})
+		}
+		if !rt.access.public {
+			params = append(params, map[string]interface{}{
+				"name":        ProjectHeader,
+				"in":          "header",
+				"description": "Narrow the request to a project: <organization>[/<project>] slugs",
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if doc.request != nil {
+			contentType := "application/json"
+			if doc.patch {
+				contentType = "application/merge-patch+json"
+			}
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					contentType: map[string]interface{}{"schema": gen.requestSchema(reflect.TypeOf(doc.request))},
+				},
+			}
+		}
+
+		status := doc.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := map[string]interface{}{"description": http.StatusText(status)}
+		if doc.response != nil {
+			schema := gen.schema(reflect.TypeOf(doc.response))
+			if doc.list {
+				schema = map[string]interface{}{
+					"type":     "object",
+					"required": []string{"data", "limit", "offset"},
+					"properties": map[string]interface{}{
+						"data":        map[string]interface{}{"type": "array", "items": schema},
+						"limit":       map[string]interface{}{"type": "integer"},
+						"offset":      map[string]interface{}{"type": "integer"},
+						"next_offset": map[string]interface{}{"type": "integer"},
+					},
+				}
+			}
+			response["content"] = map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schema},
+			}
+		}
+		op["responses"].(map[string]interface{})[strconv.Itoa(status)] = response
+
+		item, _ := paths[rt.pattern].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[rt.pattern] = item
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Beacon API",
+			"version": "1",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+// operationID derives a stable ID from the route, e.g. GET /v1/services/{id}
+// becomes get_services_id.
+func operationID(method, pattern string) string {
+	parts := []string{strings.ToLower(method)}
+	for _, segment := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if segment == "v1" {
+			continue
+		}
+		segment = strings.Trim(segment, "{}")
+		parts = append(parts, strings.ReplaceAll(segment, "-", "_"))
+	}
+	return strings.Join(parts, "_")
+}
+
+// schemaGenerator builds JSON schemas from Go types, following the same
+// rules as encoding/json. Named structs are added to schemas and referenced.
+type schemaGenerator struct {
+	schemas map[string]interface{}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		inner := g.schema(t.Elem())
+		if _, isRef := inner["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{inner}, "nullable": true}
+		}
+		inner["nullable"] = true
+		return inner
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, done := g.schemas[t.Name()]; !done {
+			g.schemas[t.Name()] = map[string]interface{}{} // placeholder for recursive types
+			g.schemas[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		// interface{} holds any JSON value
+		return map[string]interface{}{}
+	}
+}
+
+// requestSchema is the schema of a request body of type t. Fields that
+// are always present in responses may be left out of requests, taking
+// their defaults or, in patches, their current values.
+func (g *schemaGenerator) requestSchema(t reflect.Type) map[string]interface{} {
+	schema := g.object(t)
+	delete(schema, "required")
+	return schema
+}
+
+func (g *schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	g.addFields(t, properties, &required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			// Embedded struct fields are promoted
+			g.addFields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/beacon/internal/models"
+)
+
+// requireOperator guards changes to organizations themselves, which only
+// operators make.
+func requireOperator(r *http.Request) error {
+	if !isOperator(r) {
+		return forbidden("only operators can manage organizations")
+	}
+	return authorizeGlobal(r)
+}
+
+func (s *Server) listOrganizations(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	if slug := r.URL.Query().Get("slug"); slug != "" {
+		orgs := []models.Organization{}
+		org, err := s.dbFor(r).GetOrganizationBySlug(slug)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return err
+		default:
+			orgs = append(orgs, *org)
+		}
+		writePage(w, orgs, limit, offset)
+		return nil
+	}
+	orgs, err := s.dbFor(r).ListOrganizations()
+	if err != nil {
+		return err
+	}
+	writePage(w, orgs, limit, offset)
+	return nil
+}
+
+func (s *Server) createOrganization(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := requireOperator(r); err != nil {
+		return err
+	}
+	var org models.Organization
+	if err := decodeJSON(r, &org); err != nil {
+		return err
+	}
+	if err := org.Validate(); err != nil {
+		return invalid(err)
+	}
+	if _, err := s.DB.GetOrganizationBySlug(org.Slug); err == nil {
+		return errorf(http.StatusConflict, "conflict", "an organization with slug %s already exists", org.Slug)
+	}
+	if err := s.DB.CreateOrganization(&org); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, org)
+	return nil
+}
+
+func (s *Server) getOrganization(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	org, err := s.dbFor(r).GetOrganization(id)
+	if err != nil {
+		return lookupError(err, "organization")
+	}
+	writeJSON(w, http.StatusOK, org)
+	return nil
+}
+
+func (s *Server) updateOrganization(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := requireOperator(r); err != nil {
+		return err
+	}
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	org, err := s.DB.GetOrganization(id)
+	if err != nil {
+		return lookupError(err, "organization")
+	}
+	current := *org
+	if err := applyPatch(r, org); err != nil {
+		return err
+	}
+	org.ID, org.CreatedAt, org.DeletedAt = current.ID, current.CreatedAt, current.DeletedAt
+	if err := org.Validate(); err != nil {
+		return invalid(err)
+	}
+	if existing, err := s.DB.GetOrganizationBySlug(org.Slug); err == nil && existing.ID != org.ID {
+		return errorf(http.StatusConflict, "conflict", "an organization with slug %s already exists", org.Slug)
+	}
+	if err := s.DB.UpdateOrganization(org); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, org)
+	return nil
+}
+
+func (s *Server) deleteOrganization(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := requireOperator(r); err != nil {
+		return err
+	}
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	if _, err := s.DB.GetOrganization(id); err != nil {
+		return lookupError(err, "organization")
+	}
+	if err := s.DB.DeleteOrganization(id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
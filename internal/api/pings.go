@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// pingQuery is the filter shared by the ping and ping window listings.
+type pingQuery struct {
+	endpointID    uuid.UUID
+	region        string
+	start, end    time.Time
+	limit, offset int
+}
+
+// timeRange reports whether the listing is bounded by start or end, in
+// which case the time range queries are used instead of a limit.
+func (q pingQuery) timeRange() bool {
+	return !q.start.IsZero() || !q.end.IsZero()
+}
+
+func (s *Server) parsePingQuery(r *http.Request, params map[string]string) (pingQuery, error) {
+	var q pingQuery
+	var err error
+	if q.endpointID, err = pathID(params); err != nil {
+		return q, err
+	}
+	endpoint, err := s.dbFor(r).GetEndpoint(q.endpointID)
+	if err != nil {
+		return q, lookupError(err, "endpoint")
+	}
+	if err := authorize(r, endpoint.ServiceID); err != nil {
+		return q, err
+	}
+	if q.limit, q.offset, err = pagination(r); err != nil {
+		return q, err
+	}
+
+	query := r.URL.Query()
+	q.region = query.Get("region")
+	for name, t := range map[string]*time.Time{"start": &q.start, "end": &q.end} {
+		if v := query.Get(name); v != "" {
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				return q, badRequest("invalid %s %q: use RFC 3339, e.g. 2024-01-02T15:04:05Z", name, v)
+			}
+		}
+	}
+	if q.timeRange() && q.end.IsZero() {
+		q.end = time.Now()
+	}
+	if q.end.Before(q.start) {
+		return q, badRequest("end is before start")
+	}
+	return q, nil
+}
+
+func (s *Server) listPings(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	q, err := s.parsePingQuery(r, params)
+	if err != nil {
+		return err
+	}
+	if q.timeRange() {
+		pings, err := s.dbFor(r).ListPingsByTimeRange(q.endpointID, q.region, q.start, q.end)
+		if err != nil {
+			return err
+		}
+		writePage(w, pings, q.limit, q.offset)
+		return nil
+	}
+	// One extra row tells whether there is a next page
+	pings, err := s.dbFor(r).ListPings(q.endpointID, q.region, q.offset+q.limit+1)
+	if err != nil {
+		return err
+	}
+	writePage(w, pings, q.limit, q.offset)
+	return nil
+}
+
+func (s *Server) listPingWindows(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	q, err := s.parsePingQuery(r, params)
+	if err != nil {
+		return err
+	}
+	if q.timeRange() {
+		windows, err := s.dbFor(r).ListPingWindowsByTimeRange(q.endpointID, q.region, q.start, q.end)
+		if err != nil {
+			return err
+		}
+		writePage(w, windows, q.limit, q.offset)
+		return nil
+	}
+	windows, err := s.dbFor(r).ListPingWindows(q.endpointID, q.region, q.offset+q.limit+1)
+	if err != nil {
+		return err
+	}
+	writePage(w, windows, q.limit, q.offset)
+	return nil
+}
+
+func (s *Server) getPing(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	ping, err := s.dbFor(r).GetPing(id)
+	if err != nil {
+		return lookupError(err, "ping")
+	}
+	if err := s.authorizeEndpoint(r, ping.EndpointID); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, ping)
+	return nil
+}
+
+func (s *Server) getPingWindow(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	window, err := s.dbFor(r).GetPingWindow(id)
+	if err != nil {
+		return lookupError(err, "ping window")
+	}
+	if err := s.authorizeEndpoint(r, window.EndpointID); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, window)
+	return nil
+}
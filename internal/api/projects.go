@@ -0,0 +1,142 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	orgID, err := queryID(r, "organization_id")
+	if err != nil {
+		return err
+	}
+	projects, err := s.dbFor(r).ListProjects(orgID)
+	if err != nil {
+		return err
+	}
+	writePage(w, projects, limit, offset)
+	return nil
+}
+
+// createProject creates a project in the caller's organization. Operators
+// name the organization in the body.
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	var project models.Project
+	if err := decodeJSON(r, &project); err != nil {
+		return err
+	}
+	if err := project.Validate(); err != nil {
+		return invalid(err)
+	}
+	if t := s.dbFor(r).Tenant(); t != nil && project.OrganizationID == uuid.Nil {
+		project.OrganizationID = t.OrganizationID
+	}
+	if project.OrganizationID == uuid.Nil {
+		return invalid(fmt.Errorf("organization is required"))
+	}
+	_, err := s.dbFor(r).GetOrganization(project.OrganizationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return invalid(fmt.Errorf("organization %s not found", project.OrganizationID))
+	}
+	if err != nil {
+		return err
+	}
+	if s.projectSlugTaken(&project) {
+		return errorf(http.StatusConflict, "conflict", "a project with slug %s already exists", project.Slug)
+	}
+	if err := s.dbFor(r).CreateProject(&project); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, project)
+	return nil
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	project, err := s.dbFor(r).GetProject(id)
+	if err != nil {
+		return lookupError(err, "project")
+	}
+	writeJSON(w, http.StatusOK, project)
+	return nil
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	project, err := s.dbFor(r).GetProject(id)
+	if err != nil {
+		return lookupError(err, "project")
+	}
+	current := *project
+	if err := applyPatch(r, project); err != nil {
+		return err
+	}
+	// Projects can't move between organizations
+	project.ID, project.OrganizationID = current.ID, current.OrganizationID
+	project.CreatedAt, project.DeletedAt = current.CreatedAt, current.DeletedAt
+	if err := project.Validate(); err != nil {
+		return invalid(err)
+	}
+	if s.projectSlugTaken(project) {
+		return errorf(http.StatusConflict, "conflict", "a project with slug %s already exists", project.Slug)
+	}
+	if err := s.dbFor(r).UpdateProject(project); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, project)
+	return nil
+}
+
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	if _, err := s.dbFor(r).GetProject(id); err != nil {
+		return lookupError(err, "project")
+	}
+	if err := s.dbFor(r).DeleteProject(id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// projectSlugTaken reports whether another project in the same
+// organization has the project's slug.
+func (s *Server) projectSlugTaken(project *models.Project) bool {
+	projects, err := s.DB.ListProjects(&project.OrganizationID)
+	if err != nil {
+		return false
+	}
+	for _, p := range projects {
+		if p.Slug == project.Slug && p.ID != project.ID {
+			return true
+		}
+	}
+	return false
+}
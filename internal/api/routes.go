@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// routes lists every API operation. Handler dispatches from it and OpenAPI
+// documents it.
+func (s *Server) routes() []route {
+	endpointFilters := []queryParam{
+		{name: "service_id", kind: "string", format: "uuid", description: "Only endpoints of this service"},
+		{name: "ref", kind: "string", description: "Only endpoints this reference names: <service>/<endpoint> or <endpoint>, by slug or name"},
+		{name: "check_type", kind: "string", description: "Only endpoints with this check type"},
+		{name: "enabled", kind: "boolean", description: "Only enabled or only disabled endpoints"},
+		{name: "tag", kind: "string", description: "Only endpoints with this tag"},
+	}
+	pingFilters := []queryParam{
+		{name: "region", kind: "string", description: "Only results from this region"},
+		{name: "start", kind: "string", format: "date-time", description: "Only results at or after this time (RFC 3339)"},
+		{name: "end", kind: "string", format: "date-time", description: "Only results before this time (RFC 3339, default now)"},
+	}
+
+	return []route{
+		{http.MethodGet, "/healthz", public, s.health, operation{summary: "Check the API is up", response: HealthStatus{}}},
+		{http.MethodGet, "/openapi.json", public, s.spec, operation{summary: "Get this OpenAPI document"}},
+
+		{http.MethodGet, "/v1/organizations", read("organizations"), s.listOrganizations, operation{summary: "List organizations (just yours unless you're an operator)", tag: "organizations", query: []queryParam{
+			{name: "slug", kind: "string", description: "Only the organization with this slug"},
+		}, response: models.Organization{}, list: true}},
+		{http.MethodPost, "/v1/organizations", write("organizations"), s.createOrganization, operation{summary: "Create an organization with a default project (operators only)", tag: "organizations", request: models.Organization{}, response: models.Organization{}, status: http.StatusCreated}},
+		{http.MethodGet, "/v1/organizations/{id}", read("organizations"), s.getOrganization, operation{summary: "Get an organization", tag: "organizations", response: models.Organization{}}},
+		{http.MethodPatch, "/v1/organizations/{id}", write("organizations"), s.updateOrganization, operation{summary: "Update an organization and its quotas (operators only)", tag: "organizations", request: models.Organization{}, response: models.Organization{}, patch: true}},
+		{http.MethodDelete, "/v1/organizations/{id}", write("organizations"), s.deleteOrganization, operation{summary: "Delete an empty organization with its users (operators only)", tag: "organizations", status: http.StatusNoContent}},
+
+		{http.MethodGet, "/v1/projects", read("projects"), s.listProjects, operation{summary: "List projects", tag: "projects", query: []queryParam{
+			{name: "organization_id", kind: "string", format: "uuid", description: "Only projects of this organization"},
+		}, response: models.Project{}, list: true}},
+		{http.MethodPost, "/v1/projects", write("projects"), s.createProject, operation{summary: "Create a project", tag: "projects", request: models.Project{}, response: models.Project{}, status: http.StatusCreated}},
+		{http.MethodGet, "/v1/projects/{id}", read("projects"), s.getProject, operation{summary: "Get a project", tag: "projects", response: models.Project{}}},
+		{http.MethodPatch, "/v1/projects/{id}", write("projects"), s.updateProject, operation{summary: "Update a project", tag: "projects", request: models.Project{}, response: models.Project{}, patch: true}},
+		{http.MethodDelete, "/v1/projects/{id}", write("projects"), s.deleteProject, operation{summary: "Delete a project without services", tag: "projects", status: http.StatusNoContent}},
+
+		{http.MethodGet, "/v1/services", read("services"), s.listServices, operation{summary: "List services", tag: "services", query: []queryParam{
+			{name: "ref", kind: "string", description: "Only services with this slug, or failing that this name"},
+		}, response: models.Service{}, list: true}},
+		{http.MethodPost, "/v1/services", write("services"), s.createService, operation{summary: "Create a service", tag: "services", request: models.Service{}, response: models.Service{}, status: http.StatusCreated}},
+		{http.MethodGet, "/v1/services/{id}", read("services"), s.getService, operation{summary: "Get a service", tag: "services", response: models.Service{}}},
+		{http.MethodPatch, "/v1/services/{id}", write("services"), s.updateService, operation{summary: "Update a service", tag: "services", request: models.Service{}, response: models.Service{}, patch: true}},
+		{http.MethodDelete, "/v1/services/{id}", write("services"), s.deleteService, operation{summary: "Delete a service", tag: "services", status: http.StatusNoContent}},
+
+		{http.MethodGet, "/v1/endpoints", read("endpoints"), s.listEndpoints, operation{summary: "List endpoints", tag: "endpoints", query: endpointFilters, response: models.ServiceEndpoint{}, list: true}},
+		{http.MethodPost, "/v1/endpoints", write("endpoints"), s.createEndpoint, operation{summary: "Create an endpoint", tag: "endpoints", request: models.ServiceEndpoint{}, response: models.ServiceEndpoint{}, status: http.StatusCreated}},
+		{http.MethodGet, "/v1/endpoints/{id}", read("endpoints"), s.getEndpoint, operation{summary: "Get an endpoint", tag: "endpoints", response: models.ServiceEndpoint{}}},
+		{http.MethodPatch, "/v1/endpoints/{id}", write("endpoints"), s.updateEndpoint, operation{summary: "Update an endpoint", tag: "endpoints", request: models.ServiceEndpoint{}, response: models.ServiceEndpoint{}, patch: true}},
+		{http.MethodDelete, "/v1/endpoints/{id}", write("endpoints"), s.deleteEndpoint, operation{summary: "Delete an endpoint", tag: "endpoints", status: http.StatusNoContent}},
+		{http.MethodGet, "/v1/endpoints/{id}/pings", read("pings"), s.listPings, operation{summary: "List an endpoint's pings, newest first", tag: "pings", query: pingFilters, response: models.Ping{}, list: true}},
+		{http.MethodGet, "/v1/endpoints/{id}/ping-windows", read("pings"), s.listPingWindows, operation{summary: "List an endpoint's ping windows, newest first", tag: "pings", query: pingFilters, response: models.PingWindow{}, list: true}},
+		{http.MethodPost, "/v1/endpoints/{id}/monitor", write("monitors"), s.startMonitor, operation{summary: "Start monitoring an endpoint", tag: "monitors", response: MonitorRun{}, status: http.StatusAccepted}},
+		{http.MethodDelete, "/v1/endpoints/{id}/monitor", write("monitors"), s.stopMonitor, operation{summary: "Stop monitoring an endpoint", tag: "monitors", status: http.StatusNoContent}},
+
+		{http.MethodGet, "/v1/pings/{id}", read("pings"), s.getPing, operation{summary: "Get a ping", tag: "pings", response: models.Ping{}}},
+		{http.MethodGet, "/v1/ping-windows/{id}", read("pings"), s.getPingWindow, operation{summary: "Get a ping window", tag: "pings", response: models.PingWindow{}}},
+
+		{http.MethodGet, "/v1/certificates", read("pings"), s.listCertificates, operation{summary: "List the certificates tls checks have seen, soonest to expire first", tag: "certificates", response: models.Certificate{}, list: true}},
+
+		{http.MethodGet, "/v1/incidents", read("incidents"), s.listIncidents, operation{summary: "List incidents, newest first", tag: "incidents", query: []queryParam{
+			{name: "endpoint_id", kind: "string", format: "uuid", description: "Only incidents of this endpoint"},
+			{name: "status", kind: "string", description: "Only open or only resolved incidents"},
+		}, response: models.Incident{}, list: true}},
+		{http.MethodGet, "/v1/incidents/{id}", read("incidents"), s.getIncident, operation{summary: "Get an incident", tag: "incidents", response: models.Incident{}}},
+		{http.MethodPost, "/v1/incidents/{id}/resolve", write("incidents"), s.resolveIncident, operation{summary: "Resolve an incident", tag: "incidents", response: models.Incident{}}},
+
+		{http.MethodGet, "/v1/maintenance-windows", read("maintenance"), s.listMaintenanceWindows, operation{summary: "List maintenance windows by start time", tag: "maintenance", query: []queryParam{
+			{name: "service_id", kind: "string", format: "uuid", description: "Only windows of this service"},
+		}, response: models.MaintenanceWindow{}, list: true}},
+		{http.MethodPost, "/v1/maintenance-windows", write("maintenance"), s.createMaintenanceWindow, operation{summary: "Schedule a maintenance window", tag: "maintenance", request: models.MaintenanceWindow{}, response: models.MaintenanceWindow{}, status: http.StatusCreated}},
+		{http.MethodGet, "/v1/maintenance-windows/{id}", read("maintenance"), s.getMaintenanceWindow, operation{summary: "Get a maintenance window", tag: "maintenance", response: models.MaintenanceWindow{}}},
+		{http.MethodPatch, "/v1/maintenance-windows/{id}", write("maintenance"), s.updateMaintenanceWindow, operation{summary: "Update a maintenance window", tag: "maintenance", request: models.MaintenanceWindow{}, response: models.MaintenanceWindow{}, patch: true}},
+		{http.MethodDelete, "/v1/maintenance-windows/{id}", write("maintenance"), s.deleteMaintenanceWindow, operation{summary: "Delete a maintenance window", tag: "maintenance", status: http.StatusNoContent}},
+
+		{http.MethodGet, "/v1/audit", read("audit"), s.listAudit, operation{summary: "List configuration changes, newest first (admins only)", tag: "audit", query: []queryParam{
+			{name: "entity_type", kind: "string", description: "Only changes to this kind of entity, e.g. endpoint"},
+			{name: "entity_id", kind: "string", format: "uuid", description: "Only changes to this entity"},
+			{name: "actor", kind: "string", description: "Only changes by this actor"},
+			{name: "since", kind: "string", format: "date-time", description: "Only changes at or after this time (RFC 3339)"},
+		}, response: models.AuditEntry{}, list: true}},
+
+		{http.MethodGet, "/v1/webhooks", read("webhooks"), s.listWebhooks, operation{summary: "List webhooks", tag: "webhooks", query: []queryParam{
+			{name: "service_id", kind: "string", format: "uuid", description: "Only webhooks of this service"},
+		}, response: models.Webhook{}, list: true}},
+		{http.MethodPost, "/v1/webhooks", write("webhooks"), s.createWebhook, operation{summary: "Create a webhook", tag: "webhooks", request: models.Webhook{}, response: models.Webhook{}, status: http.StatusCreated}},
+		{http.MethodGet, "/v1/webhooks/{id}", read("webhooks"), s.getWebhook, operation{summary: "Get a webhook", tag: "webhooks", response: models.Webhook{}}},
+		{http.MethodPatch, "/v1/webhooks/{id}", write("webhooks"), s.updateWebhook, operation{summary: "Update a webhook", tag: "webhooks", request: models.Webhook{}, response: models.Webhook{}, patch: true}},
+		{http.MethodDelete, "/v1/webhooks/{id}", write("webhooks"), s.deleteWebhook, operation{summary: "Delete a webhook", tag: "webhooks", status: http.StatusNoContent}},
+
+		{http.MethodGet, "/v1/me", signedIn, s.getMe, operation{summary: "Get the user the token belongs to", tag: "users", response: models.User{}}},
+		{http.MethodGet, "/v1/users", read("users"), s.listUsers, operation{summary: "List users", tag: "users", query: []queryParam{
+			{name: "email", kind: "string", description: "Only the user with this email"},
+		}, response: models.User{}, list: true}},
+		{http.MethodPost, "/v1/users", write("users"), s.createUser, operation{summary: "Create a user", tag: "users", request: models.User{}, response: models.User{}, status: http.StatusCreated}},
+		{http.MethodGet, "/v1/users/{id}", read("users"), s.getUser, operation{summary: "Get a user", tag: "users", response: models.User{}}},
+		{http.MethodPatch, "/v1/users/{id}", write("users"), s.updateUser, operation{summary: "Update a user", tag: "users", request: models.User{}, response: models.User{}, patch: true}},
+		{http.MethodDelete, "/v1/users/{id}", write("users"), s.deleteUser, operation{summary: "Delete a user and revoke their tokens", tag: "users", status: http.StatusNoContent}},
+		{http.MethodPut, "/v1/users/{id}/grants/{service_id}", write("users"), s.setGrant, operation{summary: "Give a user a role on a service", tag: "users", request: GrantRequest{}, response: models.User{}}},
+		{http.MethodDelete, "/v1/users/{id}/grants/{service_id}", write("users"), s.deleteGrant, operation{summary: "Remove a user's role on a service", tag: "users", response: models.User{}}},
+
+		{http.MethodGet, "/v1/tokens", read("tokens"), s.listTokens, operation{summary: "List your API tokens, or any user's as an admin", tag: "tokens", query: []queryParam{
+			{name: "user_id", kind: "string", format: "uuid", description: "Another user's tokens (admins only)"},
+		}, response: models.APIToken{}, list: true}},
+		{http.MethodPost, "/v1/tokens", write("tokens"), s.createToken, operation{summary: "Issue an API token", tag: "tokens", request: models.APIToken{}, response: IssuedToken{}, status: http.StatusCreated}},
+		{http.MethodDelete, "/v1/tokens/{id}", write("tokens"), s.revokeToken, operation{summary: "Revoke an API token", tag: "tokens", status: http.StatusNoContent}},
+	}
+}
+
+// HealthStatus is the body of /healthz.
+type HealthStatus struct {
+	Status string `json:"status"`
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := s.DB.Ping(); err != nil {
+		return errorf(http.StatusServiceUnavailable, "unavailable", "database unreachable")
+	}
+	writeJSON(w, http.StatusOK, HealthStatus{Status: "ok"})
+	return nil
+}
+
+func (s *Server) spec(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	writeJSON(w, http.StatusOK, s.OpenAPI())
+	return nil
+}
+
+// pathID parses the {id} path parameter.
+func pathID(params map[string]string) (uuid.UUID, error) {
+	return pathUUID(params, "id")
+}
+
+func pathUUID(params map[string]string, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(params[name])
+	if err != nil {
+		return uuid.Nil, badRequest("invalid %s %q", strings.ReplaceAll(name, "_", " "), params[name])
+	}
+	return id, nil
+}
+
+// queryID parses an optional UUID query parameter.
+func queryID(r *http.Request, name string) (*uuid.UUID, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return nil, badRequest("invalid %s %q", name, v)
+	}
+	return &id, nil
+}
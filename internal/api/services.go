@@ -0,0 +1,141 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+func (s *Server) listServices(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	var services []models.Service
+	if ref := r.URL.Query().Get("ref"); ref != "" {
+		services, err = s.dbFor(r).FindServices(ref)
+	} else {
+		services, err = s.dbFor(r).ListServices()
+	}
+	if err != nil {
+		return err
+	}
+	filtered := []models.Service{}
+	for _, service := range services {
+		if visible(r, service.ID) {
+			filtered = append(filtered, service)
+		}
+	}
+	writePage(w, filtered, limit, offset)
+	return nil
+}
+
+func (s *Server) createService(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	var service models.Service
+	if err := decodeJSON(r, &service); err != nil {
+		return err
+	}
+	if err := service.Validate(); err != nil {
+		return invalid(err)
+	}
+	if service.ProjectID != uuid.Nil {
+		if err := s.requireProject(r, service.ProjectID); err != nil {
+			return err
+		}
+	}
+	if err := s.dbFor(r).CreateService(&service); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, service)
+	return nil
+}
+
+func (s *Server) getService(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	if err := authorize(r, id); err != nil {
+		return err
+	}
+	service, err := s.dbFor(r).GetService(id)
+	if err != nil {
+		return lookupError(err, "service")
+	}
+	writeJSON(w, http.StatusOK, service)
+	return nil
+}
+
+func (s *Server) updateService(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	if err := authorize(r, id); err != nil {
+		return err
+	}
+	service, err := s.dbFor(r).GetService(id)
+	if err != nil {
+		return lookupError(err, "service")
+	}
+	current := *service
+	if err := applyPatch(r, service); err != nil {
+		return err
+	}
+	// Services can't move between projects
+	service.ID, service.ProjectID = current.ID, current.ProjectID
+	service.CreatedAt, service.DeletedAt = current.CreatedAt, current.DeletedAt
+	if err := service.Validate(); err != nil {
+		return invalid(err)
+	}
+	if err := s.dbFor(r).UpdateService(service); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, service)
+	return nil
+}
+
+func (s *Server) deleteService(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	if err := authorize(r, id); err != nil {
+		return err
+	}
+	if _, err := s.dbFor(r).GetService(id); err != nil {
+		return lookupError(err, "service")
+	}
+	if err := s.dbFor(r).DeleteService(id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// requireService checks that a resource being created refers to a service
+// that exists.
+func (s *Server) requireService(r *http.Request, id uuid.UUID) error {
+	_, err := s.dbFor(r).GetService(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return invalid(fmt.Errorf("service %s not found", id))
+	}
+	return err
+}
+
+// requireProject checks that a resource being created refers to a project
+// the caller can see.
+func (s *Server) requireProject(r *http.Request, id uuid.UUID) error {
+	_, err := s.dbFor(r).GetProject(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return invalid(fmt.Errorf("project %s not found", id))
+	}
+	return err
+}
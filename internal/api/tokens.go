@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// IssuedToken is a newly issued API token. Token is only ever returned
+// here.
+type IssuedToken struct {
+	models.APIToken
+	Token string `json:"token"`
+}
+
+// isAdmin reports whether the caller can manage other users' tokens.
+func isAdmin(r *http.Request) bool {
+	return callerFrom(r).user.Role == models.RoleAdmin
+}
+
+func (s *Server) listTokens(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	userID, err := queryID(r, "user_id")
+	if err != nil {
+		return err
+	}
+	if userID == nil {
+		userID = &callerFrom(r).user.ID
+	} else if *userID != callerFrom(r).user.ID && !isAdmin(r) {
+		return forbidden("only admins can list other users' tokens")
+	}
+	tokens, err := s.dbFor(r).ListAPITokens(userID)
+	if err != nil {
+		return err
+	}
+	writePage(w, tokens, limit, offset)
+	return nil
+}
+
+// createToken issues a token for the caller, or for another user if the
+// caller is an admin. A token can't issue one with scopes it lacks itself.
+func (s *Server) createToken(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	c := callerFrom(r)
+	var token models.APIToken
+	if err := decodeJSON(r, &token); err != nil {
+		return err
+	}
+	token.LastUsedAt, token.RevokedAt = nil, nil
+	if token.UserID == uuid.Nil {
+		token.UserID = c.user.ID
+	}
+	if token.UserID != c.user.ID {
+		if !isAdmin(r) {
+			return forbidden("only admins can issue tokens for other users")
+		}
+		if _, err := s.dbFor(r).GetUser(token.UserID); err != nil {
+			return lookupError(err, "user")
+		}
+	}
+	if err := token.Validate(); err != nil {
+		return invalid(err)
+	}
+	if len(c.token.Scopes) > 0 {
+		if len(token.Scopes) == 0 {
+			return forbidden("a scoped token can only issue tokens with narrower scopes")
+		}
+		for _, scope := range token.Scopes {
+			resource, level, _ := strings.Cut(scope, ":")
+			if !models.ScopesAllow(c.token.Scopes, resource, level) {
+				return forbidden("this token can't issue the %s scope", scope)
+			}
+		}
+	}
+
+	secret, err := s.dbFor(r).IssueAPIToken(&token)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, IssuedToken{APIToken: token, Token: secret})
+	return nil
+}
+
+func (s *Server) revokeToken(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	token, err := s.dbFor(r).GetAPIToken(id)
+	if err != nil {
+		return lookupError(err, "token")
+	}
+	if token.UserID != callerFrom(r).user.ID && !isAdmin(r) {
+		return notFound("token")
+	}
+	if err := s.dbFor(r).RevokeAPIToken(id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/beacon/internal/models"
+)
+
+// GrantRequest is the body of a grant: the role to give on the service.
+type GrantRequest struct {
+	Role string `json:"role"`
+}
+
+func (s *Server) getMe(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	writeJSON(w, http.StatusOK, callerFrom(r).user)
+	return nil
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	if email := r.URL.Query().Get("email"); email != "" {
+		users := []models.User{}
+		user, err := s.dbFor(r).GetUserByEmail(email)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return err
+		default:
+			users = append(users, *user)
+		}
+		writePage(w, users, limit, offset)
+		return nil
+	}
+	users, err := s.dbFor(r).ListUsers()
+	if err != nil {
+		return err
+	}
+	writePage(w, users, limit, offset)
+	return nil
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	var user models.User
+	if err := decodeJSON(r, &user); err != nil {
+		return err
+	}
+	if err := user.Validate(); err != nil {
+		return invalid(err)
+	}
+	if err := s.checkUserOrganization(r, &user); err != nil {
+		return err
+	}
+	// Emails are unique across organizations
+	if _, err := s.DB.GetUserByEmail(user.Email); err == nil {
+		return errorf(http.StatusConflict, "conflict", "a user with email %s already exists", user.Email)
+	}
+	// Grants are set separately
+	user.Grants = nil
+	if err := s.dbFor(r).CreateUser(&user); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, user)
+	return nil
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	user, err := s.dbFor(r).GetUser(id)
+	if err != nil {
+		return lookupError(err, "user")
+	}
+	writeJSON(w, http.StatusOK, user)
+	return nil
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	user, err := s.dbFor(r).GetUser(id)
+	if err != nil {
+		return lookupError(err, "user")
+	}
+	current := *user
+	if err := applyPatch(r, user); err != nil {
+		return err
+	}
+	user.ID, user.Grants = current.ID, current.Grants
+	user.CreatedAt, user.DeletedAt = current.CreatedAt, current.DeletedAt
+	if err := user.Validate(); err != nil {
+		return invalid(err)
+	}
+	if err := s.checkUserOrganization(r, user); err != nil {
+		return err
+	}
+	if existing, err := s.DB.GetUserByEmail(user.Email); err == nil && existing.ID != user.ID {
+		return errorf(http.StatusConflict, "conflict", "a user with email %s already exists", user.Email)
+	}
+	if err := s.dbFor(r).UpdateUser(user); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, user)
+	return nil
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	if _, err := s.dbFor(r).GetUser(id); err != nil {
+		return lookupError(err, "user")
+	}
+	if err := s.dbFor(r).DeleteUser(id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) setGrant(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	grant, err := s.grantTarget(r, params)
+	if err != nil {
+		return err
+	}
+	var body GrantRequest
+	if err := decodeJSON(r, &body); err != nil {
+		return err
+	}
+	grant.Role = body.Role
+	if err := grant.Validate(); err != nil {
+		return invalid(err)
+	}
+	if err := s.dbFor(r).SetServiceGrant(grant); err != nil {
+		return err
+	}
+	return s.writeGrantee(w, r, grant)
+}
+
+func (s *Server) deleteGrant(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	if err := authorizeGlobal(r); err != nil {
+		return err
+	}
+	grant, err := s.grantTarget(r, params)
+	if err != nil {
+		return err
+	}
+	if err := s.dbFor(r).DeleteServiceGrant(grant.UserID, grant.ServiceID); err != nil {
+		return err
+	}
+	return s.writeGrantee(w, r, grant)
+}
+
+// checkUserOrganization keeps users created or changed by organization
+// members in the member's organization, and checks that operators put them
+// in one that exists.
+func (s *Server) checkUserOrganization(r *http.Request, user *models.User) error {
+	if !isOperator(r) {
+		user.OrganizationID = callerFrom(r).user.OrganizationID
+		return nil
+	}
+	if user.OrganizationID == nil {
+		return nil
+	}
+	_, err := s.DB.GetOrganization(*user.OrganizationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return invalid(fmt.Errorf("organization %s not found", *user.OrganizationID))
+	}
+	return err
+}
+
+// grantTarget checks the user and service a grant route names.
+func (s *Server) grantTarget(r *http.Request, params map[string]string) (*models.ServiceGrant, error) {
+	userID, err := pathID(params)
+	if err != nil {
+		return nil, err
+	}
+	serviceID, err := pathUUID(params, "service_id")
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.dbFor(r).GetUser(userID)
+	if err != nil {
+		return nil, lookupError(err, "user")
+	}
+	if _, err := s.dbFor(r).GetService(serviceID); err != nil {
+		return nil, lookupError(err, "service")
+	}
+	if user.OrganizationID != nil {
+		orgID, err := s.DB.ServiceOrganization(serviceID)
+		if err != nil {
+			return nil, err
+		}
+		if orgID != *user.OrganizationID {
+			return nil, invalid(fmt.Errorf("service %s is in another organization than the user", serviceID))
+		}
+	}
+	return &models.ServiceGrant{UserID: userID, ServiceID: serviceID}, nil
+}
+
+// writeGrantee responds with the user a grant changed.
+func (s *Server) writeGrantee(w http.ResponseWriter, r *http.Request, grant *models.ServiceGrant) error {
+	user, err := s.dbFor(r).GetUser(grant.UserID)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, user)
+	return nil
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/beacon/internal/models"
+)
+
+func (s *Server) listWebhooks(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	serviceID, err := queryID(r, "service_id")
+	if err != nil {
+		return err
+	}
+	webhooks, err := s.dbFor(r).ListWebhooks(serviceID)
+	if err != nil {
+		return err
+	}
+	filtered := []models.Webhook{}
+	for _, webhook := range webhooks {
+		if visible(r, webhook.ServiceID) {
+			filtered = append(filtered, webhook.Redacted())
+		}
+	}
+	writePage(w, filtered, limit, offset)
+	return nil
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	webhook := models.NewWebhook()
+	if err := decodeJSON(r, &webhook); err != nil {
+		return err
+	}
+	if err := webhook.Validate(); err != nil {
+		return invalid(err)
+	}
+	if err := s.requireService(r, webhook.ServiceID); err != nil {
+		return err
+	}
+	if err := authorize(r, webhook.ServiceID); err != nil {
+		return err
+	}
+	if err := s.dbFor(r).CreateWebhook(&webhook); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, webhook.Redacted())
+	return nil
+}
+
+func (s *Server) getWebhook(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	webhook, err := s.dbFor(r).GetWebhook(id)
+	if err != nil {
+		return lookupError(err, "webhook")
+	}
+	if err := authorize(r, webhook.ServiceID); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, webhook.Redacted())
+	return nil
+}
+
+func (s *Server) updateWebhook(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	webhook, err := s.dbFor(r).GetWebhook(id)
+	if err != nil {
+		return lookupError(err, "webhook")
+	}
+	if err := authorize(r, webhook.ServiceID); err != nil {
+		return err
+	}
+	current := *webhook
+	if err := applyPatch(r, webhook); err != nil {
+		return err
+	}
+	// The service a webhook belongs to can't change
+	webhook.ID, webhook.ServiceID = current.ID, current.ServiceID
+	webhook.CreatedAt, webhook.DeletedAt = current.CreatedAt, current.DeletedAt
+	webhook.KeepRedacted(current)
+	if err := webhook.Validate(); err != nil {
+		return invalid(err)
+	}
+	if err := s.dbFor(r).UpdateWebhook(webhook); err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, webhook.Redacted())
+	return nil
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request, params map[string]string) error {
+	id, err := pathID(params)
+	if err != nil {
+		return err
+	}
+	webhook, err := s.dbFor(r).GetWebhook(id)
+	if err != nil {
+		return lookupError(err, "webhook")
+	}
+	if err := authorize(r, webhook.ServiceID); err != nil {
+		return err
+	}
+	if err := s.dbFor(r).DeleteWebhook(id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
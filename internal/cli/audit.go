@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+func AuditCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show who changed what",
+		Long: `Show the audit log: every create, update and delete of services, endpoints,
+webhooks, users, tokens, secrets, organizations and projects, endpoints and
+webhooks being enabled or disabled, incidents resolved by hand, and monitors
+started or stopped, with who did it and the fields that changed.`,
+	}
+
+	cmd.AddCommand(listAuditCmd(cfg))
+
+	return cmd
+}
+
+// parseSince turns an age like 24h or 7d, or an RFC 3339 time, into the
+// time to list changes from.
+func parseSince(since string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+	d, err := parseDays(since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q (use e.g. 24h, 7d or 2024-01-02T15:04:05Z)", since)
+	}
+	return time.Now().Add(-d), nil
+}
+
+func listAuditCmd(cfg *Config) *cobra.Command {
+	var (
+		entity, actor, since string
+		limit                int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List changes, newest first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := db.AuditFilter{Actor: actor, Limit: limit}
+			if id, err := uuid.Parse(entity); err == nil {
+				filter.EntityID = &id
+			} else {
+				filter.EntityType = entity
+			}
+			if since != "" {
+				var err error
+				if filter.Since, err = parseSince(since); err != nil {
+					return err
+				}
+			}
+
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			entries, err := database.ListAuditEntries(filter)
+			if err != nil {
+				return fmt.Errorf("failed to list audit entries: %w", err)
+			}
+
+			return printList(cfg, entries, auditColumns)
+		},
+	}
+
+	cmd.Flags().StringVar(&entity, "entity", "", "Only changes to this entity (an ID) or kind of entity (e.g. endpoint, webhook, monitor)")
+	cmd.Flags().StringVar(&actor, "actor", "", "Only changes by this actor: a user's email, or cli:<username> for direct database access")
+	cmd.Flags().StringVar(&since, "since", "", "Only changes in this period (e.g. 24h, 7d) or after this time (RFC 3339)")
+	cmd.Flags().IntVar(&limit, "limit", 100, "Maximum number of entries to return")
+
+	return cmd
+}
+
+var auditColumns = []column[models.AuditEntry]{
+	{"TIME", func(a models.AuditEntry) interface{} { return a.CreatedAt }},
+	{"ACTOR", func(a models.AuditEntry) interface{} { return a.Actor }},
+	{"ACTION", func(a models.AuditEntry) interface{} { return a.Action }},
+	{"ENTITY", func(a models.AuditEntry) interface{} { return a.EntityType }},
+	{"ENTITY ID", func(a models.AuditEntry) interface{} { return a.EntityID }},
+	{"FIELDS", func(a models.AuditEntry) interface{} {
+		// Creates and deletes hold the whole entity, so only list fields
+		// for changes
+		if a.Before == nil || a.After == nil {
+			return ""
+		}
+		fields := make([]string, 0, len(a.After))
+		for field := range a.After {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		return fields
+	}},
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/spf13/cobra"
+)
+
+func CertsCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "Inspect TLS certificates seen by tls checks",
+	}
+
+	cmd.AddCommand(listCertsCmd(cfg))
+
+	return cmd
+}
+
+func listCertsCmd(cfg *Config) *cobra.Command {
+	var withinDays int
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List certificates, soonest to expire first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			certs, err := database.ListCertificates()
+			if err != nil {
+				return fmt.Errorf("failed to list certificates: %w", err)
+			}
+
+			if cmd.Flags().Changed("within") {
+				cutoff := time.Now().AddDate(0, 0, withinDays)
+				filtered := []models.Certificate{}
+				for _, cert := range certs {
+					if cert.NotAfter.Before(cutoff) {
+						filtered = append(filtered, cert)
+					}
+				}
+				certs = filtered
+			}
+
+			return printList(cfg, certs, certificateColumns)
+		},
+	}
+
+	cmd.Flags().IntVar(&withinDays, "within", 0, "Only show certificates expiring within this many days")
+
+	return cmd
+}
+
+var certificateColumns = []column[models.Certificate]{
+	{"ENDPOINT ID", func(c models.Certificate) interface{} { return c.EndpointID }},
+	{"HOST", func(c models.Certificate) interface{} { return c.Host }},
+	{"ISSUER", func(c models.Certificate) interface{} { return c.Issuer }},
+	{"EXPIRES", func(c models.Certificate) interface{} { return c.NotAfter }},
+	{"CHAIN", func(c models.Certificate) interface{} {
+		if c.ChainValid {
+			return status("valid")
+		}
+		return status("invalid")
+	}},
+	{"OCSP", func(c models.Certificate) interface{} { return status(c.OCSPStatus) }},
+	{"CHECKED", func(c models.Certificate) interface{} { return c.CheckedAt }},
+}
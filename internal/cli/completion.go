@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// completionFunc suggests values for an argument or flag.
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeServices suggests the slugs of the services in view for a
+// command's first argument.
+func completeServices(cfg *Config) completionFunc {
+	return firstArg(func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return serviceRefs(cfg)
+	})
+}
+
+// completeEndpoints suggests <service>/<endpoint> references for the
+// endpoints in view for a command's first argument.
+func completeEndpoints(cfg *Config) completionFunc {
+	return firstArg(func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return endpointRefs(cfg)
+	})
+}
+
+// completeServiceFlag and completeEndpointFlag make a flag suggest service
+// or endpoint references.
+func completeServiceFlag(cfg *Config, cmd *cobra.Command, name string) {
+	cmd.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return serviceRefs(cfg)
+	})
+}
+
+func completeEndpointFlag(cfg *Config, cmd *cobra.Command, name string) {
+	cmd.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return endpointRefs(cfg)
+	})
+}
+
+// firstArg stops complete suggesting anything once the first argument has
+// been given.
+func firstArg(complete completionFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
+
+// serviceRefs lists service slugs, described by their names.
+func serviceRefs(cfg *Config) ([]string, cobra.ShellCompDirective) {
+	database, err := cfg.openStore()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer database.Close()
+
+	services, err := database.ListServices()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	refs := make([]string, len(services))
+	for i, service := range services {
+		refs[i] = service.Slug + "\t" + service.Name
+	}
+	return refs, cobra.ShellCompDirectiveNoFileComp
+}
+
+// endpointRefs lists endpoints as <service>/<endpoint> slugs, described by
+// their names.
+func endpointRefs(cfg *Config) ([]string, cobra.ShellCompDirective) {
+	database, err := cfg.openStore()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer database.Close()
+
+	services, err := database.ListServices()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	endpoints, err := database.ListEndpoints(nil)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	slugs := make(map[uuid.UUID]string, len(services))
+	for _, service := range services {
+		slugs[service.ID] = service.Slug
+	}
+	refs := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		service, ok := slugs[endpoint.ServiceID]
+		if !ok {
+			service = endpoint.ServiceID.String()
+		}
+		refs = append(refs, service+"/"+endpoint.Slug+"\t"+endpoint.Name)
+	}
+	return refs, cobra.ShellCompDirectiveNoFileComp
+}
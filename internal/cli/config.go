@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/beacon/internal/api"
+	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// Config holds the global flags. Commands read it when they run, after the
+// flags have been parsed.
+type Config struct {
+	DatabaseURL string
+	// APIURL, if set, sends the resource commands through the API with
+	// Token instead of connecting to the database.
+	APIURL string
+	Token  string
+	// Project narrows commands to an organization or project, as
+	// <organization>[/<project>] slugs.
+	Project string
+	// Output is how get and list commands print results (see CheckOutput),
+	// and NoHeaders leaves the header row out of tables and CSV.
+	Output    string
+	NoHeaders bool
+}
+
+// store is what the resource commands need. *api.Client implements it over
+// the API, and *db.DB against the database.
+type store interface {
+	CreateOrganization(org *models.Organization) error
+	GetOrganization(id uuid.UUID) (*models.Organization, error)
+	GetOrganizationBySlug(slug string) (*models.Organization, error)
+	ListOrganizations() ([]models.Organization, error)
+	UpdateOrganization(org *models.Organization) error
+	DeleteOrganization(id uuid.UUID) error
+
+	CreateProject(project *models.Project) error
+	GetProject(id uuid.UUID) (*models.Project, error)
+	ListProjects(organizationID *uuid.UUID) ([]models.Project, error)
+	UpdateProject(project *models.Project) error
+	DeleteProject(id uuid.UUID) error
+
+	CreateService(service *models.Service) error
+	GetService(id uuid.UUID) (*models.Service, error)
+	ListServices() ([]models.Service, error)
+	ResolveService(ref string) (*models.Service, error)
+	UpdateService(service *models.Service) error
+	DeleteService(id uuid.UUID) error
+
+	CreateEndpoint(endpoint *models.ServiceEndpoint) error
+	GetEndpoint(id uuid.UUID) (*models.ServiceEndpoint, error)
+	ListEndpoints(serviceID *uuid.UUID) ([]models.ServiceEndpoint, error)
+	ResolveEndpoint(ref string) (*models.ServiceEndpoint, error)
+	UpdateEndpoint(endpoint *models.ServiceEndpoint) error
+	DeleteEndpoint(id uuid.UUID) error
+
+	CreateWebhook(webhook *models.Webhook) error
+	GetWebhook(id uuid.UUID) (*models.Webhook, error)
+	ListWebhooks(serviceID *uuid.UUID) ([]models.Webhook, error)
+	UpdateWebhook(webhook *models.Webhook) error
+	DeleteWebhook(id uuid.UUID) error
+
+	CreateMaintenanceWindow(window *models.MaintenanceWindow) error
+	GetMaintenanceWindow(id uuid.UUID) (*models.MaintenanceWindow, error)
+	ListMaintenanceWindows(serviceID *uuid.UUID) ([]models.MaintenanceWindow, error)
+	UpdateMaintenanceWindow(window *models.MaintenanceWindow) error
+	DeleteMaintenanceWindow(id uuid.UUID) error
+
+	ListCertificates() ([]models.Certificate, error)
+
+	GetIncident(id uuid.UUID) (*models.Incident, error)
+	ListIncidents(endpointID *uuid.UUID, status string) ([]models.Incident, error)
+	ResolveIncident(id uuid.UUID) error
+
+	GetPing(id uuid.UUID) (*models.Ping, error)
+	ListPings(endpointID uuid.UUID, region string, limit int) ([]models.Ping, error)
+	ListPingsByTimeRange(endpointID uuid.UUID, region string, start, end time.Time) ([]models.Ping, error)
+	GetPingWindow(id uuid.UUID) (*models.PingWindow, error)
+	ListPingWindows(endpointID uuid.UUID, region string, limit int) ([]models.PingWindow, error)
+	ListPingWindowsByTimeRange(endpointID uuid.UUID, region string, start, end time.Time) ([]models.PingWindow, error)
+
+	CreateUser(user *models.User) error
+	GetUser(id uuid.UUID) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
+	ListUsers() ([]models.User, error)
+	UpdateUser(user *models.User) error
+	DeleteUser(id uuid.UUID) error
+	SetServiceGrant(grant *models.ServiceGrant) error
+	DeleteServiceGrant(userID, serviceID uuid.UUID) error
+
+	IssueAPIToken(token *models.APIToken) (string, error)
+	ListAPITokens(userID *uuid.UUID) ([]models.APIToken, error)
+	RevokeAPIToken(id uuid.UUID) error
+
+	ListAuditEntries(filter db.AuditFilter) ([]models.AuditEntry, error)
+
+	Close() error
+}
+
+// usingAPI reports whether the resource commands go through the API.
+func (c *Config) usingAPI() bool {
+	return c.APIURL != ""
+}
+
+// openStore returns the API client if --api is set, and otherwise connects
+// to the database.
+func (c *Config) openStore() (store, error) {
+	if c.usingAPI() {
+		if c.Token == "" {
+			return nil, fmt.Errorf("--api needs a token: set --token or BEACON_TOKEN")
+		}
+		client := api.NewClient(c.APIURL, c.Token)
+		client.Project = c.Project
+		return client, nil
+	}
+	return c.openDB()
+}
+
+// openDB connects to the database, for commands that only work with direct
+// access. With --project set, the connection only sees that project.
+// Changes are recorded in the audit log as made by the local user.
+func (c *Config) openDB() (*db.DB, error) {
+	database, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	database = database.AsActor(localActor())
+	if c.Project == "" {
+		return database, nil
+	}
+	tenant, err := database.ResolveTenant(c.Project)
+	if err != nil {
+		database.Close()
+		return nil, fmt.Errorf("project %s: %w", c.Project, err)
+	}
+	return database.ForTenant(tenant), nil
+}
+
+// localActor names the user running the CLI, as cli:<username>.
+func localActor() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return "cli:" + name
+}
+
+// connect connects to the database without narrowing it to a project.
+func (c *Config) connect() (*db.DB, error) {
+	if c.DatabaseURL == "" && c.usingAPI() {
+		return nil, fmt.Errorf("this command isn't available through the API and needs database access: set --db or DATABASE_URL")
+	}
+	if c.DatabaseURL == "" {
+		return nil, fmt.Errorf("this command needs database access: set --db or DATABASE_URL")
+	}
+	return db.NewDB(c.DatabaseURL)
+}
@@ -0,0 +1,486 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/beacon/internal/models"
+	"github.com/robfig/cron"
+	"github.com/spf13/cobra"
+	"go.starlark.net/syntax"
+)
+
+// requestFlags holds the flags shared by create and update that shape the
+// HTTP request sent on each ping.
+type requestFlags struct {
+	headers         []string
+	body            string
+	bodyFile        string
+	contentType     string
+	query           map[string]string
+	followRedirects bool
+	maxRedirects    int
+	userAgent       string
+	httpVersion     string
+
+	create bool
+}
+
+func (f *requestFlags) register(cmd *cobra.Command, create bool) {
+	f.create = create
+	cmd.Flags().StringArrayVar(&f.headers, "header", nil, `Request header as "Name: value" (repeatable, empty value removes)`)
+	cmd.Flags().StringVar(&f.body, "body", "", "Request body")
+	cmd.Flags().StringVar(&f.bodyFile, "body-file", "", "Read the request body from a file")
+	cmd.Flags().StringVar(&f.contentType, "content-type", "", "Request Content-Type header")
+	cmd.Flags().StringToStringVar(&f.query, "query", nil, "Query parameters (key=value,...)")
+	cmd.Flags().BoolVar(&f.followRedirects, "follow-redirects", true, "Follow redirects")
+	cmd.Flags().IntVar(&f.maxRedirects, "max-redirects", 10, "Maximum number of redirects to follow (at least 1; use --follow-redirects=false not to follow any)")
+	cmd.Flags().StringVar(&f.userAgent, "user-agent", "", "Custom User-Agent header")
+	cmd.Flags().StringVar(&f.httpVersion, "http-version", "", "HTTP version to require: 1.1, or 2 (h2c for http:// URLs); by default HTTP/2 is used if the server offers it")
+	cmd.MarkFlagsMutuallyExclusive("body", "body-file")
+}
+
+// apply copies the flags onto the endpoint. On create every flag applies,
+// taking its default if unset; on update only the flags given are changed.
+func (f *requestFlags) apply(cmd *cobra.Command, endpoint *models.ServiceEndpoint) error {
+	set := func(name string) bool {
+		return f.create || cmd.Flags().Changed(name)
+	}
+
+	if cmd.Flags().Changed("header") {
+		headers, err := applyHeaders(endpoint.Headers, f.headers)
+		if err != nil {
+			return err
+		}
+		endpoint.Headers = headers
+	}
+	if set("body") {
+		endpoint.Body = f.body
+	}
+	if cmd.Flags().Changed("body-file") {
+		data, err := os.ReadFile(f.bodyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read body file: %w", err)
+		}
+		endpoint.Body = string(data)
+	}
+	if set("content-type") {
+		endpoint.ContentType = f.contentType
+	}
+	if set("query") {
+		endpoint.QueryParams = make(models.JSONB)
+		for key, value := range f.query {
+			endpoint.QueryParams[key] = value
+		}
+	}
+	if set("follow-redirects") {
+		endpoint.FollowRedirects = f.followRedirects
+	}
+	if set("max-redirects") {
+		if f.maxRedirects < 0 {
+			return fmt.Errorf("max redirects must not be negative")
+		}
+		endpoint.MaxRedirects = f.maxRedirects
+	}
+	if set("user-agent") {
+		endpoint.UserAgent = f.userAgent
+	}
+	if set("http-version") {
+		switch f.httpVersion {
+		case "", models.HTTPVersion11, models.HTTPVersion2:
+			endpoint.HTTPVersion = f.httpVersion
+		default:
+			return fmt.Errorf("invalid HTTP version %q (use 1.1 or 2)", f.httpVersion)
+		}
+	}
+	return nil
+}
+
+// authFlags holds the flags shared by create and update that configure how
+// requests to the endpoint are authenticated.
+type authFlags struct {
+	authType        string
+	username        string
+	password        string
+	token           string
+	keyName         string
+	keyValue        string
+	keyIn           string
+	tokenURL        string
+	clientID        string
+	clientSecret    string
+	scopes          []string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	awsRegion       string
+	awsService      string
+}
+
+func (f *authFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.authType, "auth-type", "", "Authentication type (basic, bearer, api_key, oauth2, aws_sigv4, none)")
+	cmd.Flags().StringVar(&f.username, "auth-username", "", "Basic auth username")
+	cmd.Flags().StringVar(&f.password, "auth-password", "", "Basic auth password")
+	cmd.Flags().StringVar(&f.token, "auth-token", "", "Bearer token")
+	cmd.Flags().StringVar(&f.keyName, "api-key-name", "", "API key header or query parameter name")
+	cmd.Flags().StringVar(&f.keyValue, "api-key-value", "", "API key value")
+	cmd.Flags().StringVar(&f.keyIn, "api-key-in", "header", "Where to send the API key (header or query)")
+	cmd.Flags().StringVar(&f.tokenURL, "oauth2-token-url", "", "OAuth2 token endpoint")
+	cmd.Flags().StringVar(&f.clientID, "oauth2-client-id", "", "OAuth2 client ID")
+	cmd.Flags().StringVar(&f.clientSecret, "oauth2-client-secret", "", "OAuth2 client secret")
+	cmd.Flags().StringSliceVar(&f.scopes, "oauth2-scopes", nil, "OAuth2 scopes")
+	cmd.Flags().StringVar(&f.accessKeyID, "aws-access-key-id", "", "AWS access key ID for SigV4 signing")
+	cmd.Flags().StringVar(&f.secretAccessKey, "aws-secret-access-key", "", "AWS secret access key for SigV4 signing")
+	cmd.Flags().StringVar(&f.sessionToken, "aws-session-token", "", "AWS session token for SigV4 signing")
+	cmd.Flags().StringVar(&f.awsRegion, "aws-region", "", "AWS region for SigV4 signing")
+	cmd.Flags().StringVar(&f.awsService, "aws-service", "", "AWS service name for SigV4 signing (e.g. execute-api)")
+}
+
+// apply updates the endpoint's auth config from the flags that were given.
+// Changing --auth-type starts from a fresh config; otherwise the existing
+// config is edited in place. --auth-type none removes authentication.
+func (f *authFlags) apply(cmd *cobra.Command, endpoint *models.ServiceEndpoint) error {
+	changed := cmd.Flags().Changed
+
+	auth := endpoint.Auth
+	if changed("auth-type") {
+		if f.authType == "none" {
+			endpoint.Auth = nil
+			return nil
+		}
+		auth = &models.AuthConfig{Type: f.authType}
+		if auth.Type == models.AuthAPIKey {
+			auth.KeyIn = f.keyIn
+		}
+	} else if auth == nil {
+		for _, name := range []string{"auth-username", "auth-password", "auth-token", "api-key-name", "api-key-value",
+			"oauth2-token-url", "oauth2-client-id", "oauth2-client-secret", "aws-access-key-id", "aws-secret-access-key"} {
+			if changed(name) {
+				return fmt.Errorf("--%s requires --auth-type", name)
+			}
+		}
+		return nil
+	} else {
+		copied := *auth
+		auth = &copied
+	}
+
+	fields := []struct {
+		flag  string
+		value string
+		dest  *string
+	}{
+		{"auth-username", f.username, &auth.Username},
+		{"auth-password", f.password, &auth.Password},
+		{"auth-token", f.token, &auth.Token},
+		{"api-key-name", f.keyName, &auth.KeyName},
+		{"api-key-value", f.keyValue, &auth.KeyValue},
+		{"api-key-in", f.keyIn, &auth.KeyIn},
+		{"oauth2-token-url", f.tokenURL, &auth.TokenURL},
+		{"oauth2-client-id", f.clientID, &auth.ClientID},
+		{"oauth2-client-secret", f.clientSecret, &auth.ClientSecret},
+		{"aws-access-key-id", f.accessKeyID, &auth.AccessKeyID},
+		{"aws-secret-access-key", f.secretAccessKey, &auth.SecretAccessKey},
+		{"aws-session-token", f.sessionToken, &auth.SessionToken},
+		{"aws-region", f.awsRegion, &auth.AWSRegion},
+		{"aws-service", f.awsService, &auth.AWSService},
+	}
+	for _, field := range fields {
+		if changed(field.flag) {
+			*field.dest = field.value
+		}
+	}
+	if changed("oauth2-scopes") {
+		auth.Scopes = f.scopes
+	}
+
+	if err := auth.Validate(); err != nil {
+		return err
+	}
+	endpoint.Auth = auth
+	return nil
+}
+
+// tlsFlags holds the flags shared by create and update that customize the
+// TLS connection to the endpoint.
+type tlsFlags struct {
+	clientCertSecret   string
+	clientKeySecret    string
+	caSecret           string
+	serverName         string
+	minVersion         string
+	insecureSkipVerify bool
+}
+
+func (f *tlsFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.clientCertSecret, "client-cert-secret", "", "Secret holding the PEM client certificate for mutual TLS")
+	cmd.Flags().StringVar(&f.clientKeySecret, "client-key-secret", "", "Secret holding the PEM client private key for mutual TLS")
+	cmd.Flags().StringVar(&f.caSecret, "ca-secret", "", "Secret holding a PEM CA bundle to verify the server with")
+	cmd.Flags().StringVar(&f.serverName, "tls-server-name", "", "Override the server name used for SNI and verification")
+	cmd.Flags().StringVar(&f.minVersion, "tls-min-version", "", "Minimum TLS version (1.0, 1.1, 1.2, 1.3)")
+	cmd.Flags().BoolVar(&f.insecureSkipVerify, "insecure-skip-verify", false, "DANGEROUS: don't verify the server certificate")
+}
+
+// apply updates the endpoint's TLS config from the flags that were given.
+func (f *tlsFlags) apply(cmd *cobra.Command, endpoint *models.ServiceEndpoint) error {
+	changed := cmd.Flags().Changed
+
+	config := models.TLSConfig{}
+	if endpoint.TLS != nil {
+		config = *endpoint.TLS
+	}
+
+	if changed("client-cert-secret") {
+		config.ClientCertSecret = f.clientCertSecret
+	}
+	if changed("client-key-secret") {
+		config.ClientKeySecret = f.clientKeySecret
+	}
+	if changed("ca-secret") {
+		config.CASecret = f.caSecret
+	}
+	if changed("tls-server-name") {
+		config.ServerName = f.serverName
+	}
+	if changed("tls-min-version") {
+		valid := f.minVersion == ""
+		for _, version := range models.TLSVersions {
+			valid = valid || f.minVersion == version
+		}
+		if !valid {
+			return fmt.Errorf("invalid TLS version %q (use %s)", f.minVersion, strings.Join(models.TLSVersions, ", "))
+		}
+		config.MinVersion = f.minVersion
+	}
+	if changed("insecure-skip-verify") {
+		config.InsecureSkipVerify = f.insecureSkipVerify
+	}
+
+	if (config.ClientCertSecret == "") != (config.ClientKeySecret == "") {
+		return fmt.Errorf("--client-cert-secret and --client-key-secret must be set together")
+	}
+
+	if config == (models.TLSConfig{}) {
+		endpoint.TLS = nil
+	} else {
+		endpoint.TLS = &config
+	}
+	return nil
+}
+
+// checkFlags holds the flags shared by create and update that pick the
+// check type and its type-specific settings.
+type checkFlags struct {
+	checkType      string
+	certExpiryDays []int
+	send           string
+	expect         string
+	expectRegex    string
+	recordType     string
+	nameserver     string
+	expectedValues []string
+	dnsMatch       string
+	resetBaseline  bool
+	grpcService    string
+	grpcPlaintext  bool
+	query          string
+	maxReplLag     int
+	stepsFile      string
+	scriptFile     string
+	schedule       string
+	graceSec       int
+	sseEvent       string
+}
+
+func (f *checkFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.checkType, "check-type", "", "Check type ("+strings.Join(models.CheckTypes, ", ")+"; default http)")
+	cmd.Flags().IntSliceVar(&f.certExpiryDays, "cert-expiry-days", nil, "Days before certificate expiry to send cert_expiring webhooks (default 30,14,7,1)")
+	cmd.Flags().StringVar(&f.send, "send", "", `Payload to send on tcp/udp/websocket checks (Go escapes such as \r\n and \x00 are decoded)`)
+	cmd.Flags().StringVar(&f.expect, "expect", "", "Substring the tcp/udp response, websocket message or sse event data must contain (escapes decoded)")
+	cmd.Flags().StringVar(&f.expectRegex, "expect-regex", "", "Regular expression the tcp/udp response, websocket message or sse event data must match")
+	cmd.Flags().StringVar(&f.recordType, "record-type", "", "DNS record type ("+strings.Join(models.DNSRecordTypes, ", ")+"; default A)")
+	cmd.Flags().StringVar(&f.nameserver, "nameserver", "", "Nameserver to query as host[:port] (default system resolver)")
+	cmd.Flags().StringArrayVar(&f.expectedValues, "expected-value", nil, "Expected DNS answer (repeatable)")
+	cmd.Flags().StringVar(&f.dnsMatch, "dns-match", "", "How DNS answers are compared (exact, contains, baseline; default exact)")
+	cmd.Flags().BoolVar(&f.resetBaseline, "reset-baseline", false, "Forget the DNS baseline so the next lookup captures a new one")
+	cmd.Flags().StringVar(&f.grpcService, "grpc-service", "", "Service name for the gRPC health check (default: the whole server)")
+	cmd.Flags().BoolVar(&f.grpcPlaintext, "grpc-plaintext", false, "Connect to the gRPC server without TLS")
+	cmd.Flags().StringVar(&f.query, "query-command", "", "Query or command for postgres/mysql/redis checks (default SELECT 1 or PING)")
+	cmd.Flags().IntVar(&f.maxReplLag, "max-replication-lag", 0, "Fail postgres/mysql/redis checks when replication lag exceeds this many seconds (0 disables)")
+	cmd.Flags().StringVar(&f.stepsFile, "steps-file", "", "JSON file with the steps of a transaction check")
+	cmd.Flags().StringVar(&f.scriptFile, "script-file", "", "Starlark file defining check() for a script check")
+	cmd.Flags().StringVar(&f.schedule, "schedule", "", `Cron expression (UTC) for when a heartbeat's job runs, e.g. "0 3 * * *" (default: every --interval)`)
+	cmd.Flags().IntVar(&f.graceSec, "grace", 0, "Seconds a heartbeat check-in may be late before an incident is opened")
+	cmd.Flags().StringVar(&f.sseEvent, "sse-event", "", "Event type an sse check waits for (default: any)")
+}
+
+// apply updates the endpoint's check type and config from the flags that
+// were given.
+func (f *checkFlags) apply(cmd *cobra.Command, endpoint *models.ServiceEndpoint) error {
+	changed := cmd.Flags().Changed
+
+	if changed("check-type") {
+		valid := false
+		for _, checkType := range models.CheckTypes {
+			valid = valid || f.checkType == checkType
+		}
+		if !valid {
+			return fmt.Errorf("invalid check type %q (use %s)", f.checkType, strings.Join(models.CheckTypes, ", "))
+		}
+		endpoint.CheckType = f.checkType
+	}
+	if changed("cert-expiry-days") {
+		for _, days := range f.certExpiryDays {
+			if days < 0 {
+				return fmt.Errorf("certificate expiry thresholds must not be negative")
+			}
+		}
+		endpoint.Check.CertExpiryDays = f.certExpiryDays
+	}
+	if changed("send") {
+		send, err := unescape(f.send)
+		if err != nil {
+			return fmt.Errorf("invalid --send: %w", err)
+		}
+		endpoint.Check.Send = send
+	}
+	if changed("expect") {
+		expect, err := unescape(f.expect)
+		if err != nil {
+			return fmt.Errorf("invalid --expect: %w", err)
+		}
+		endpoint.Check.Expect = expect
+	}
+	if changed("expect-regex") {
+		if _, err := regexp.Compile(f.expectRegex); err != nil {
+			return fmt.Errorf("invalid --expect-regex: %w", err)
+		}
+		endpoint.Check.ExpectRegex = f.expectRegex
+	}
+	if changed("record-type") {
+		recordType := strings.ToUpper(f.recordType)
+		valid := false
+		for _, t := range models.DNSRecordTypes {
+			valid = valid || recordType == t
+		}
+		if !valid {
+			return fmt.Errorf("invalid record type %q (use %s)", f.recordType, strings.Join(models.DNSRecordTypes, ", "))
+		}
+		endpoint.Check.RecordType = recordType
+	}
+	if changed("nameserver") {
+		endpoint.Check.Nameserver = f.nameserver
+	}
+	if changed("expected-value") {
+		endpoint.Check.ExpectedValues = f.expectedValues
+	}
+	if changed("dns-match") {
+		switch f.dnsMatch {
+		case models.DNSMatchExact, models.DNSMatchContains, models.DNSMatchBaseline:
+			endpoint.Check.DNSMatch = f.dnsMatch
+		default:
+			return fmt.Errorf("invalid DNS match %q (use exact, contains or baseline)", f.dnsMatch)
+		}
+	}
+	if changed("grpc-service") {
+		endpoint.Check.GRPCService = f.grpcService
+	}
+	if changed("grpc-plaintext") {
+		endpoint.Check.GRPCPlaintext = f.grpcPlaintext
+	}
+	if changed("query-command") {
+		endpoint.Check.Query = f.query
+	}
+	if changed("max-replication-lag") {
+		if f.maxReplLag < 0 {
+			return fmt.Errorf("--max-replication-lag must not be negative")
+		}
+		endpoint.Check.MaxReplicationLagSec = f.maxReplLag
+	}
+	if changed("steps-file") {
+		data, err := os.ReadFile(f.stepsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read steps file: %w", err)
+		}
+		var steps []models.TransactionStep
+		if err := json.Unmarshal(data, &steps); err != nil {
+			return fmt.Errorf("invalid steps file: %w", err)
+		}
+		if err := models.ValidateSteps(steps); err != nil {
+			return err
+		}
+		endpoint.Check.Steps = steps
+	}
+	if changed("script-file") {
+		data, err := os.ReadFile(f.scriptFile)
+		if err != nil {
+			return fmt.Errorf("failed to read script file: %w", err)
+		}
+		if _, err := syntax.Parse(f.scriptFile, data, 0); err != nil {
+			return fmt.Errorf("invalid script: %w", err)
+		}
+		endpoint.Check.Script = string(data)
+	}
+	if changed("schedule") {
+		if f.schedule != "" {
+			if _, err := cron.ParseStandard(f.schedule); err != nil {
+				return fmt.Errorf("invalid --schedule: %w", err)
+			}
+		}
+		endpoint.Check.Schedule = f.schedule
+	}
+	if changed("grace") {
+		if f.graceSec < 0 {
+			return fmt.Errorf("--grace must not be negative")
+		}
+		endpoint.Check.GraceSec = f.graceSec
+	}
+	if changed("sse-event") {
+		endpoint.Check.SSEEvent = f.sseEvent
+	}
+	if changed("record-type") || changed("nameserver") || changed("dns-match") || f.resetBaseline {
+		endpoint.Check.DNSBaseline = nil
+	}
+	return nil
+}
+
+// unescape decodes Go string escapes so binary and line-based protocols
+// can be written on the command line. Double quotes may be written with or
+// without a backslash.
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for s != "" {
+		if s[0] == '"' {
+			b.WriteByte('"')
+			s = s[1:]
+			continue
+		}
+		r, multibyte, tail, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", s)
+		}
+		if r < utf8.RuneSelf || !multibyte {
+			b.WriteByte(byte(r))
+		} else {
+			b.WriteRune(r)
+		}
+		s = tail
+	}
+	return b.String(), nil
+}
+
+// warnInsecure prints a warning to stderr for endpoints that skip TLS
+// certificate verification.
+func warnInsecure(endpoints ...models.ServiceEndpoint) {
+	for _, endpoint := range endpoints {
+		if endpoint.TLS != nil && endpoint.TLS.InsecureSkipVerify {
+			fmt.Fprintf(os.Stderr, "⚠️  WARNING: TLS certificate verification is DISABLED for endpoint %s (%s).\n", endpoint.Name, endpoint.ID)
+			fmt.Fprintf(os.Stderr, "⚠️  Checks will pass even if the certificate is forged, expired or issued to another host.\n")
+		}
+	}
+}
@@ -1,33 +1,43 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
+	"os"
 
-	"github.com/beacon/internal/db"
 	"github.com/beacon/internal/models"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
-func EndpointsCmd(dbURL string) *cobra.Command {
+func EndpointsCmd(cfg *Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "endpoints",
 		Short: "Manage service endpoints",
 	}
 
-	cmd.AddCommand(createEndpointCmd(dbURL))
-	cmd.AddCommand(getEndpointCmd(dbURL))
-	cmd.AddCommand(listEndpointsCmd(dbURL))
-	cmd.AddCommand(updateEndpointCmd(dbURL))
-	cmd.AddCommand(deleteEndpointCmd(dbURL))
+	cmd.AddCommand(createEndpointCmd(cfg))
+	cmd.AddCommand(getEndpointCmd(cfg))
+	cmd.AddCommand(listEndpointsCmd(cfg))
+	cmd.AddCommand(updateEndpointCmd(cfg))
+	cmd.AddCommand(deleteEndpointCmd(cfg))
 
 	return cmd
 }
 
-func createEndpointCmd(dbURL string) *cobra.Command {
+// findEndpoint looks an endpoint up by ID, or by reference as
+// <service>/<endpoint> or <endpoint>, using slugs or names.
+func findEndpoint(database store, ref string) (*models.ServiceEndpoint, error) {
+	endpoint, err := database.ResolveEndpoint(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+func createEndpointCmd(cfg *Config) *cobra.Command {
 	var (
 		serviceID    string
+		slug         string
 		name         string
 		url          string
 		method       string
@@ -35,25 +45,33 @@ func createEndpointCmd(dbURL string) *cobra.Command {
 		timeoutMs    int
 		intervalSec  int
 		enabled      bool
+		regions      []string
+		quorum       int
+		tags         []string
+		request      requestFlags
+		auth         authFlags
+		tls          tlsFlags
+		check        checkFlags
 	)
 
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a new endpoint",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			svcID, err := uuid.Parse(serviceID)
+			service, err := findService(database, serviceID)
 			if err != nil {
-				return fmt.Errorf("invalid service UUID: %w", err)
+				return err
 			}
 
 			endpoint := &models.ServiceEndpoint{
-				ServiceID:    svcID,
+				ServiceID:    service.ID,
+				Slug:         slug,
 				Name:         name,
 				URL:          url,
 				Method:       method,
@@ -62,71 +80,110 @@ func createEndpointCmd(dbURL string) *cobra.Command {
 				IntervalSec:  intervalSec,
 				Enabled:      enabled,
 				Headers:      make(models.JSONB),
+				Regions:      regions,
+				Quorum:       quorum,
+				Tags:         tags,
+			}
+
+			if err := request.apply(cmd, endpoint); err != nil {
+				return err
+			}
+			if err := auth.apply(cmd, endpoint); err != nil {
+				return err
+			}
+			if err := tls.apply(cmd, endpoint); err != nil {
+				return err
+			}
+			if err := check.apply(cmd, endpoint); err != nil {
+				return err
+			}
+			if err := endpoint.Validate(); err != nil {
+				return err
+			}
+
+			// Heartbeats are given a check-in URL instead of probing one
+			isHeartbeat := endpoint.CheckType == models.CheckHeartbeat
+			if isHeartbeat && url != "" {
+				return fmt.Errorf("heartbeat endpoints are given a check-in URL; don't pass --url")
+			}
+			if !isHeartbeat && url == "" {
+				return fmt.Errorf(`required flag(s) "url" not set`)
 			}
 
 			if err := database.CreateEndpoint(endpoint); err != nil {
 				return fmt.Errorf("failed to create endpoint: %w", err)
 			}
 
-			data, _ := json.MarshalIndent(endpoint, "", "  ")
-			fmt.Println(string(data))
+			if err := printOne(cfg, endpoint.Redacted(), endpointColumns); err != nil {
+				return err
+			}
+			warnInsecure(*endpoint)
+			if isHeartbeat {
+				fmt.Fprintf(os.Stderr, "Check in at %s (append /start or /fail to report a job starting or failing)\n", endpoint.URL)
+			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&serviceID, "service-id", "", "Service ID (required)")
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Service ID or slug (required)")
 	cmd.Flags().StringVar(&name, "name", "", "Endpoint name (required)")
-	cmd.Flags().StringVar(&url, "url", "", "Endpoint URL (required)")
+	cmd.Flags().StringVar(&slug, "slug", "", "Short name for referring to the endpoint within its service (default derived from --name)")
+	cmd.Flags().StringVar(&url, "url", "", "Endpoint URL (required, except for heartbeats)")
 	cmd.Flags().StringVar(&method, "method", "GET", "HTTP method")
 	cmd.Flags().IntVar(&expectedCode, "expected-code", 200, "Expected HTTP status code")
 	cmd.Flags().IntVar(&timeoutMs, "timeout", 30000, "Timeout in milliseconds")
 	cmd.Flags().IntVar(&intervalSec, "interval", 60, "Check interval in seconds")
 	cmd.Flags().BoolVar(&enabled, "enabled", true, "Enable endpoint monitoring")
+	cmd.Flags().StringSliceVar(&regions, "regions", nil, "Comma-separated list of regions to probe from")
+	cmd.Flags().IntVar(&quorum, "quorum", 1, "Number of failing regions needed to consider the endpoint down")
+	cmd.Flags().StringSliceVar(&tags, "tags", nil, "Comma-separated list of tags, e.g. team-payments,tier-1")
+	request.register(cmd, true)
+	auth.register(cmd)
+	tls.register(cmd)
+	check.register(cmd)
 	
 	cmd.MarkFlagRequired("service-id")
 	cmd.MarkFlagRequired("name")
-	cmd.MarkFlagRequired("url")
+	completeServiceFlag(cfg, cmd, "service-id")
 
 	return cmd
 }
 
-func getEndpointCmd(dbURL string) *cobra.Command {
+func getEndpointCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "get [id]",
-		Short: "Get an endpoint by ID",
-		Args:  cobra.ExactArgs(1),
+		Use:               "get [id or service/endpoint]",
+		Short:             "Get an endpoint by ID, or by service and endpoint slug or name",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeEndpoints(cfg),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			id, err := uuid.Parse(args[0])
+			endpoint, err := findEndpoint(database, args[0])
 			if err != nil {
-				return fmt.Errorf("invalid UUID: %w", err)
+				return err
 			}
 
-			endpoint, err := database.GetEndpoint(id)
-			if err != nil {
-				return fmt.Errorf("failed to get endpoint: %w", err)
+			if err := printOne(cfg, endpoint.Redacted(), endpointColumns); err != nil {
+				return err
 			}
-
-			data, _ := json.MarshalIndent(endpoint, "", "  ")
-			fmt.Println(string(data))
+			warnInsecure(*endpoint)
 			return nil
 		},
 	}
 }
 
-func listEndpointsCmd(dbURL string) *cobra.Command {
-	var serviceID string
+func listEndpointsCmd(cfg *Config) *cobra.Command {
+	var serviceID, tag string
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List endpoints",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -134,31 +191,43 @@ func listEndpointsCmd(dbURL string) *cobra.Command {
 
 			var svcID *uuid.UUID
 			if serviceID != "" {
-				id, err := uuid.Parse(serviceID)
+				service, err := findService(database, serviceID)
 				if err != nil {
-					return fmt.Errorf("invalid service UUID: %w", err)
+					return err
 				}
-				svcID = &id
+				svcID = &service.ID
 			}
 
 			endpoints, err := database.ListEndpoints(svcID)
 			if err != nil {
 				return fmt.Errorf("failed to list endpoints: %w", err)
 			}
+			var tagged []models.ServiceEndpoint
+			for _, endpoint := range endpoints {
+				if tag == "" || endpoint.HasTag(tag) {
+					tagged = append(tagged, endpoint.Redacted())
+				}
+			}
+			endpoints = tagged
 
-			data, _ := json.MarshalIndent(endpoints, "", "  ")
-			fmt.Println(string(data))
+			if err := printList(cfg, endpoints, endpointColumns); err != nil {
+				return err
+			}
+			warnInsecure(endpoints...)
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&serviceID, "service-id", "", "Filter by service ID")
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Filter by service ID or slug")
+	cmd.Flags().StringVar(&tag, "tag", "", "Filter by tag")
+	completeServiceFlag(cfg, cmd, "service-id")
 
 	return cmd
 }
 
-func updateEndpointCmd(dbURL string) *cobra.Command {
+func updateEndpointCmd(cfg *Config) *cobra.Command {
 	var (
+		slug         string
 		name         string
 		url          string
 		method       string
@@ -166,29 +235,35 @@ func updateEndpointCmd(dbURL string) *cobra.Command {
 		timeoutMs    int
 		intervalSec  int
 		enabled      *bool
+		regions      []string
+		quorum       int
+		tags         []string
+		request      requestFlags
+		auth         authFlags
+		tls          tlsFlags
+		check        checkFlags
 	)
 
 	cmd := &cobra.Command{
-		Use:   "update [id]",
-		Short: "Update an endpoint",
-		Args:  cobra.ExactArgs(1),
+		Use:               "update [id or service/endpoint]",
+		Short:             "Update an endpoint",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeEndpoints(cfg),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			id, err := uuid.Parse(args[0])
+			endpoint, err := findEndpoint(database, args[0])
 			if err != nil {
-				return fmt.Errorf("invalid UUID: %w", err)
+				return err
 			}
 
-			endpoint, err := database.GetEndpoint(id)
-			if err != nil {
-				return fmt.Errorf("failed to get endpoint: %w", err)
+			if slug != "" {
+				endpoint.Slug = slug
 			}
-
 			if name != "" {
 				endpoint.Name = name
 			}
@@ -210,22 +285,65 @@ func updateEndpointCmd(dbURL string) *cobra.Command {
 			if enabled != nil {
 				endpoint.Enabled = *enabled
 			}
+			if cmd.Flags().Changed("regions") {
+				endpoint.Regions = regions
+			}
+			if cmd.Flags().Changed("quorum") {
+				endpoint.Quorum = quorum
+			}
+			if cmd.Flags().Changed("tags") {
+				endpoint.Tags = tags
+			}
 
+			if err := request.apply(cmd, endpoint); err != nil {
+				return err
+			}
+			if err := auth.apply(cmd, endpoint); err != nil {
+				return err
+			}
+			if err := tls.apply(cmd, endpoint); err != nil {
+				return err
+			}
+			if err := check.apply(cmd, endpoint); err != nil {
+				return err
+			}
+			if err := endpoint.Validate(); err != nil {
+				return err
+			}
+
+			if endpoint.CheckType == models.CheckHeartbeat && url != "" {
+				return fmt.Errorf("heartbeat endpoints are given a check-in URL; don't pass --url")
+			}
+
+			// Endpoints that become heartbeats are given a check-in URL
+			previousURL := endpoint.URL
 			if err := database.UpdateEndpoint(endpoint); err != nil {
 				return fmt.Errorf("failed to update endpoint: %w", err)
 			}
+			if endpoint.CheckType == models.CheckHeartbeat && endpoint.URL != previousURL {
+				fmt.Printf("Check in at %s\n", endpoint.URL)
+			}
 
-			fmt.Printf("Endpoint %s updated successfully\n", id)
+			fmt.Printf("Endpoint %s updated successfully\n", endpoint.ID)
+			warnInsecure(*endpoint)
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&slug, "slug", "", "Endpoint slug")
 	cmd.Flags().StringVar(&name, "name", "", "Endpoint name")
 	cmd.Flags().StringVar(&url, "url", "", "Endpoint URL")
 	cmd.Flags().StringVar(&method, "method", "", "HTTP method")
 	cmd.Flags().IntVar(&expectedCode, "expected-code", 0, "Expected HTTP status code")
 	cmd.Flags().IntVar(&timeoutMs, "timeout", 0, "Timeout in milliseconds")
 	cmd.Flags().IntVar(&intervalSec, "interval", 0, "Check interval in seconds")
+	cmd.Flags().StringSliceVar(&regions, "regions", nil, "Comma-separated list of regions to probe from (empty for any)")
+	cmd.Flags().IntVar(&quorum, "quorum", 0, "Number of failing regions needed to consider the endpoint down")
+	cmd.Flags().StringSliceVar(&tags, "tags", nil, "Comma-separated list of tags (replaces the current ones; empty for none)")
+	request.register(cmd, false)
+	auth.register(cmd)
+	tls.register(cmd)
+	check.register(cmd)
 	
 	enabledFlag := false
 	cmd.Flags().BoolVar(&enabledFlag, "enabled", false, "Enable/disable endpoint")
@@ -239,29 +357,48 @@ func updateEndpointCmd(dbURL string) *cobra.Command {
 	return cmd
 }
 
-func deleteEndpointCmd(dbURL string) *cobra.Command {
+func deleteEndpointCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "delete [id]",
-		Short: "Delete an endpoint",
-		Args:  cobra.ExactArgs(1),
+		Use:               "delete [id or service/endpoint]",
+		Short:             "Delete an endpoint",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeEndpoints(cfg),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			id, err := uuid.Parse(args[0])
+			endpoint, err := findEndpoint(database, args[0])
 			if err != nil {
-				return fmt.Errorf("invalid UUID: %w", err)
+				return err
 			}
 
-			if err := database.DeleteEndpoint(id); err != nil {
+			if err := database.DeleteEndpoint(endpoint.ID); err != nil {
 				return fmt.Errorf("failed to delete endpoint: %w", err)
 			}
 
-			fmt.Printf("Endpoint %s deleted successfully\n", id)
+			fmt.Printf("Endpoint %s deleted successfully\n", endpoint.ID)
 			return nil
 		},
 	}
-}
\ No newline at end of file
+}
+
+var endpointColumns = []column[models.ServiceEndpoint]{
+	{"ID", func(e models.ServiceEndpoint) interface{} { return e.ID }},
+	{"SLUG", func(e models.ServiceEndpoint) interface{} { return e.Slug }},
+	{"NAME", func(e models.ServiceEndpoint) interface{} { return e.Name }},
+	{"TYPE", func(e models.ServiceEndpoint) interface{} {
+		if e.CheckType == "" {
+			return models.CheckHTTP
+		}
+		return e.CheckType
+	}},
+	{"URL", func(e models.ServiceEndpoint) interface{} { return e.URL }},
+	{"INTERVAL", func(e models.ServiceEndpoint) interface{} { return seconds(e.IntervalSec) }},
+	{"REGIONS", func(e models.ServiceEndpoint) interface{} { return e.Regions }},
+	{"TAGS", func(e models.ServiceEndpoint) interface{} { return e.Tags }},
+	{"STATUS", func(e models.ServiceEndpoint) interface{} { return enabledStatus(e.Enabled) }},
+	{"CREATED", func(e models.ServiceEndpoint) interface{} { return e.CreatedAt }},
+}
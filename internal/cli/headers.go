@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/beacon/internal/models"
+)
+
+// applyHeaders merges "Name: value" header flags into headers. A header
+// with an empty value is removed. Values may reference secrets with
+// {{secret "name"}}.
+func applyHeaders(headers models.JSONB, values []string) (models.JSONB, error) {
+	if headers == nil {
+		headers = make(models.JSONB)
+	}
+	for _, header := range values {
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid header %q (expected \"Name: value\")", header)
+		}
+		if value = strings.TrimSpace(value); value == "" {
+			delete(headers, name)
+		} else {
+			headers[name] = value
+		}
+	}
+	return headers, nil
+}
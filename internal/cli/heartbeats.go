@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
+	"github.com/spf13/cobra"
+)
+
+func HeartbeatsCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "heartbeats",
+		Short: "Inspect heartbeat check-ins",
+	}
+
+	cmd.AddCommand(listHeartbeatsCmd(cfg))
+	cmd.AddCommand(rotateHeartbeatCmd(cfg))
+
+	return cmd
+}
+
+func listHeartbeatsCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List heartbeats and their last check-ins",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openDB()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			heartbeats, err := database.ListHeartbeats()
+			if err != nil {
+				return err
+			}
+
+			return printList(cfg, heartbeats, heartbeatColumns)
+		},
+	}
+}
+
+func rotateHeartbeatCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:               "rotate [endpoint]",
+		Short:             "Issue a new check-in URL, invalidating the old one",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeEndpoints(cfg),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openDB()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			endpoint, err := database.ResolveEndpoint(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get endpoint: %w", err)
+			}
+			id := endpoint.ID
+
+			token, err := db.NewHeartbeatToken()
+			if err != nil {
+				return fmt.Errorf("failed to generate heartbeat token: %w", err)
+			}
+			if err := database.RotateHeartbeatToken(id, token); err != nil {
+				return fmt.Errorf("failed to rotate heartbeat token: %w", err)
+			}
+			endpoint.URL = db.CheckinURL(token)
+			if err := database.UpdateEndpoint(endpoint); err != nil {
+				return fmt.Errorf("failed to update endpoint: %w", err)
+			}
+
+			fmt.Printf("Check in at %s\n", endpoint.URL)
+			return nil
+		},
+	}
+}
+
+var heartbeatColumns = []column[models.Heartbeat]{
+	{"ENDPOINT ID", func(h models.Heartbeat) interface{} { return h.EndpointID }},
+	{"STATUS", func(h models.Heartbeat) interface{} { return status(h.LastStatus) }},
+	{"LAST CHECK-IN", func(h models.Heartbeat) interface{} { return h.LastCheckinAt }},
+	{"LAST SUCCESS", func(h models.Heartbeat) interface{} { return h.LastSuccessAt }},
+	{"LAST START", func(h models.Heartbeat) interface{} { return h.LastStartAt }},
+}
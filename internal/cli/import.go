@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/beacon/internal/importer"
+	"github.com/beacon/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+func ImportCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Create endpoints from other tools' API descriptions",
+		Long: `Create endpoints for a service from the GET operations in an OpenAPI spec
+or the GET requests in a Postman collection. Endpoints with the same method
+and URL as one the service already has are skipped, so importing again only
+adds what's new. New endpoints aren't monitored until started with
+beacon monitor.
+
+uptime-kuma and blackbox migrate another monitoring tool's setup instead,
+writing a manifest for beacon apply and reporting what couldn't be mapped.`,
+	}
+
+	cmd.AddCommand(importSourceCmd(cfg, "openapi [file]", "Import the GET operations of an OpenAPI 3 or Swagger 2 spec (YAML or JSON)", importer.OpenAPI))
+	cmd.AddCommand(importSourceCmd(cfg, "postman [file]", "Import the GET requests of a Postman v2.1 collection", importer.Postman))
+	cmd.AddCommand(importUptimeKumaCmd(cfg))
+	cmd.AddCommand(importBlackboxCmd(cfg))
+
+	return cmd
+}
+
+// importSourceCmd builds the command for one kind of file, which read turns
+// into endpoints.
+func importSourceCmd(cfg *Config, use, short string, read func([]byte, importer.Options) (*importer.Result, error)) *cobra.Command {
+	var (
+		serviceID   string
+		opts        importer.Options
+		intervalSec int
+		enabled     bool
+		dryRun      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			result, err := read(data, opts)
+			if err != nil {
+				return err
+			}
+
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			service, err := findService(database, serviceID)
+			if err != nil {
+				return err
+			}
+			svcID := service.ID
+			current, err := database.ListEndpoints(&svcID)
+			if err != nil {
+				return fmt.Errorf("failed to list endpoints: %w", err)
+			}
+			exists := map[string]bool{}
+			for _, endpoint := range current {
+				exists[importer.Key(endpoint)] = true
+			}
+
+			var imported, existing int
+			for _, endpoint := range result.Endpoints {
+				endpoint.ServiceID = svcID
+				endpoint.IntervalSec = intervalSec
+				endpoint.Enabled = enabled
+				key := importer.Key(endpoint)
+				if exists[key] {
+					fmt.Printf("= %s (%s) already exists\n", endpoint.Name, key)
+					existing++
+					continue
+				}
+				exists[key] = true
+
+				if err := endpoint.Validate(); err != nil {
+					result.Skipped = append(result.Skipped, importer.Skipped{Source: endpoint.Name, Reason: err.Error()})
+					continue
+				}
+				if dryRun {
+					fmt.Printf("+ %s (%s)\n", endpoint.Name, key)
+					imported++
+					continue
+				}
+				if err := database.CreateEndpoint(&endpoint); err != nil {
+					return fmt.Errorf("failed to create endpoint %s: %w", endpoint.Name, err)
+				}
+				fmt.Printf("✓ Created %s (%s) with ID %s\n", endpoint.Name, key, endpoint.ID)
+				imported++
+			}
+
+			for _, skipped := range result.Skipped {
+				fmt.Printf("! Skipped %s: %s\n", skipped.Source, skipped.Reason)
+			}
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+
+			verb := "Imported"
+			if dryRun {
+				verb = "Would import"
+			}
+			fmt.Printf("\n%s %d endpoints; %d already exist and %d couldn't be imported.\n", verb, imported, existing, len(result.Skipped))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Service to add the endpoints to, by ID or slug (required)")
+	cmd.Flags().StringVar(&opts.BaseURL, "base-url", "", "URL to send requests to instead of the file's server URL or host")
+	cmd.Flags().StringSliceVar(&opts.Tags, "tag", nil, "Only import operations with these tags, or requests in these folders")
+	cmd.Flags().StringSliceVar(&opts.Paths, "path", nil, "Only import paths starting with these prefixes")
+	cmd.Flags().IntVar(&intervalSec, "interval", 60, "Check interval in seconds")
+	cmd.Flags().BoolVar(&enabled, "enabled", true, "Enable endpoint monitoring")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be imported without creating anything")
+	cmd.MarkFlagRequired("service-id")
+	completeServiceFlag(cfg, cmd, "service-id")
+
+	return cmd
+}
+
+func importUptimeKumaCmd(cfg *Config) *cobra.Command {
+	var service string
+	var out migrationFlags
+
+	cmd := &cobra.Command{
+		Use:   "uptime-kuma [backup.json]",
+		Short: "Migrate monitors and webhook notifications from an Uptime Kuma backup",
+		Long: `Map the monitors and webhook notifications in an Uptime Kuma backup
+(Settings → Backup → Export) onto services, endpoints and webhooks. Groups
+become services; monitors outside a group go into --service.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			migration, err := importer.UptimeKuma(data, service)
+			if err != nil {
+				return err
+			}
+			return out.finish(cfg, migration)
+		},
+	}
+
+	cmd.Flags().StringVar(&service, "service", "Uptime Kuma", "Service for monitors that aren't in a group")
+	out.register(cmd)
+
+	return cmd
+}
+
+func importBlackboxCmd(cfg *Config) *cobra.Command {
+	var modules, alertmanager string
+	var out migrationFlags
+
+	cmd := &cobra.Command{
+		Use:   "blackbox [prometheus.yml]",
+		Short: "Migrate blackbox_exporter probes from a Prometheus config",
+		Long: `Map the blackbox_exporter jobs in a Prometheus config onto services and
+endpoints: each job becomes a service with an endpoint per static target,
+checked at the job's scrape interval. --modules reads what each module
+checks from the blackbox_exporter config, and --alertmanager turns webhook
+receivers into webhooks.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			prometheus, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			var modulesData, alertmanagerData []byte
+			if modules != "" {
+				if modulesData, err = os.ReadFile(modules); err != nil {
+					return err
+				}
+			}
+			if alertmanager != "" {
+				if alertmanagerData, err = os.ReadFile(alertmanager); err != nil {
+					return err
+				}
+			}
+			migration, err := importer.Blackbox(prometheus, modulesData, alertmanagerData)
+			if err != nil {
+				return err
+			}
+			return out.finish(cfg, migration)
+		},
+	}
+
+	cmd.Flags().StringVar(&modules, "modules", "", "blackbox_exporter config (blackbox.yml)")
+	cmd.Flags().StringVar(&alertmanager, "alertmanager", "", "Alertmanager config, for webhook receivers")
+	out.register(cmd)
+
+	return cmd
+}
+
+// migrationFlags say what to do with a migration: write its manifest, or
+// apply it straight away.
+type migrationFlags struct {
+	file         string
+	apply        bool
+	skipMonitors bool
+}
+
+func (f *migrationFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.file, "file", "f", "", "File to write the manifest to (default stdout)")
+	cmd.Flags().BoolVar(&f.apply, "apply", false, "Apply the manifest instead of writing it")
+	cmd.Flags().BoolVar(&f.skipMonitors, "skip-monitors", false, "With --apply, don't start monitors")
+}
+
+// finish writes or applies the migration's manifest, then prints its
+// report to stderr.
+func (f *migrationFlags) finish(cfg *Config, migration *importer.Migration) error {
+	defer func() {
+		if len(migration.Report) == 0 {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "\nNot mapped, or mapped differently:")
+		for _, line := range migration.Report {
+			fmt.Fprintf(os.Stderr, "  - %s\n", line)
+		}
+	}()
+
+	if !f.apply {
+		return writeManifest(cfg, f.file, &migration.Manifest)
+	}
+
+	s, err := cfg.openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	plan, err := manifest.Compute(s, &migration.Manifest, false)
+	if err != nil {
+		return err
+	}
+	if len(plan.Changes) == 0 {
+		plan.Write(os.Stdout)
+		return nil
+	}
+	var monitors manifest.Monitors
+	if !f.skipMonitors {
+		var done func()
+		monitors, done = monitorsFor(s)
+		defer done()
+	}
+	return manifest.Apply(s, monitors, plan, os.Stdout)
+}
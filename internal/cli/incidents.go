@@ -1,34 +1,33 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
 
-	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
-func IncidentsCmd(dbURL string) *cobra.Command {
+func IncidentsCmd(cfg *Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "incidents",
 		Short: "Manage incidents",
 	}
 
-	cmd.AddCommand(getIncidentCmd(dbURL))
-	cmd.AddCommand(listIncidentsCmd(dbURL))
-	cmd.AddCommand(resolveIncidentCmd(dbURL))
+	cmd.AddCommand(getIncidentCmd(cfg))
+	cmd.AddCommand(listIncidentsCmd(cfg))
+	cmd.AddCommand(resolveIncidentCmd(cfg))
 
 	return cmd
 }
 
-func getIncidentCmd(dbURL string) *cobra.Command {
+func getIncidentCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "get [id]",
 		Short: "Get an incident by ID",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -44,14 +43,12 @@ func getIncidentCmd(dbURL string) *cobra.Command {
 				return fmt.Errorf("failed to get incident: %w", err)
 			}
 
-			data, _ := json.MarshalIndent(incident, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printOne(cfg, *incident, incidentColumns)
 		},
 	}
 }
 
-func listIncidentsCmd(dbURL string) *cobra.Command {
+func listIncidentsCmd(cfg *Config) *cobra.Command {
 	var (
 		endpointID string
 		status     string
@@ -61,7 +58,7 @@ func listIncidentsCmd(dbURL string) *cobra.Command {
 		Use:   "list",
 		Short: "List incidents",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -69,11 +66,11 @@ func listIncidentsCmd(dbURL string) *cobra.Command {
 
 			var epID *uuid.UUID
 			if endpointID != "" {
-				id, err := uuid.Parse(endpointID)
+				endpoint, err := findEndpoint(database, endpointID)
 				if err != nil {
-					return fmt.Errorf("invalid endpoint UUID: %w", err)
+					return err
 				}
-				epID = &id
+				epID = &endpoint.ID
 			}
 
 			incidents, err := database.ListIncidents(epID, status)
@@ -81,25 +78,24 @@ func listIncidentsCmd(dbURL string) *cobra.Command {
 				return fmt.Errorf("failed to list incidents: %w", err)
 			}
 
-			data, _ := json.MarshalIndent(incidents, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printList(cfg, incidents, incidentColumns)
 		},
 	}
 
-	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Filter by endpoint ID")
+	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Filter by endpoint ID or <service>/<endpoint>")
+	completeEndpointFlag(cfg, cmd, "endpoint-id")
 	cmd.Flags().StringVar(&status, "status", "", "Filter by status (open/resolved)")
 
 	return cmd
 }
 
-func resolveIncidentCmd(dbURL string) *cobra.Command {
+func resolveIncidentCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "resolve [id]",
 		Short: "Resolve an incident",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -118,4 +114,13 @@ func resolveIncidentCmd(dbURL string) *cobra.Command {
 			return nil
 		},
 	}
-}
\ No newline at end of file
+}
+
+var incidentColumns = []column[models.Incident]{
+	{"ID", func(i models.Incident) interface{} { return i.ID }},
+	{"ENDPOINT ID", func(i models.Incident) interface{} { return i.EndpointID }},
+	{"STATUS", func(i models.Incident) interface{} { return status(i.Status) }},
+	{"STARTED", func(i models.Incident) interface{} { return i.StartedAt }},
+	{"RESOLVED", func(i models.Incident) interface{} { return i.ResolvedAt }},
+	{"MESSAGE", func(i models.Incident) interface{} { return i.Message }},
+}
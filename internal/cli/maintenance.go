@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+func MaintenanceCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Manage maintenance windows",
+		Long: `Manage maintenance windows. While a window is open, failures of the
+service's endpoints are still recorded as pings but don't open incidents, so
+no alerts go out.`,
+	}
+
+	cmd.AddCommand(createMaintenanceCmd(cfg))
+	cmd.AddCommand(listMaintenanceCmd(cfg))
+	cmd.AddCommand(deleteMaintenanceCmd(cfg))
+
+	return cmd
+}
+
+func createMaintenanceCmd(cfg *Config) *cobra.Command {
+	var (
+		serviceID string
+		name      string
+		start     string
+		end       string
+		duration  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Schedule a maintenance window",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			service, err := findService(database, serviceID)
+			if err != nil {
+				return err
+			}
+
+			window := &models.MaintenanceWindow{
+				ServiceID: service.ID,
+				Name:      name,
+				StartsAt:  time.Now(),
+			}
+			if start != "" {
+				if window.StartsAt, err = time.Parse(time.RFC3339, start); err != nil {
+					return fmt.Errorf("invalid --start: %w", err)
+				}
+			}
+			switch {
+			case end != "" && duration != "":
+				return fmt.Errorf("give --end or --duration, not both")
+			case end != "":
+				if window.EndsAt, err = time.Parse(time.RFC3339, end); err != nil {
+					return fmt.Errorf("invalid --end: %w", err)
+				}
+			case duration != "":
+				d, err := parseDays(duration)
+				if err != nil {
+					return fmt.Errorf("invalid --duration %q (use e.g. 2h or 1d)", duration)
+				}
+				window.EndsAt = window.StartsAt.Add(d)
+			default:
+				return fmt.Errorf("give --end or --duration")
+			}
+
+			if err := window.Validate(); err != nil {
+				return err
+			}
+
+			if err := database.CreateMaintenanceWindow(window); err != nil {
+				return fmt.Errorf("failed to create maintenance window: %w", err)
+			}
+
+			return printOne(cfg, *window, maintenanceWindowColumns)
+		},
+	}
+
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Service ID or slug (required)")
+	cmd.Flags().StringVar(&name, "name", "", "Window name, e.g. what the maintenance is (required)")
+	cmd.Flags().StringVar(&start, "start", "", "Start time (RFC3339 format, default now)")
+	cmd.Flags().StringVar(&end, "end", "", "End time (RFC3339 format)")
+	cmd.Flags().StringVar(&duration, "duration", "", "Length of the window instead of --end, e.g. 2h")
+	cmd.MarkFlagRequired("service-id")
+	cmd.MarkFlagRequired("name")
+	completeServiceFlag(cfg, cmd, "service-id")
+
+	return cmd
+}
+
+func listMaintenanceCmd(cfg *Config) *cobra.Command {
+	var serviceID string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List maintenance windows",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			var svcID *uuid.UUID
+			if serviceID != "" {
+				service, err := findService(database, serviceID)
+				if err != nil {
+					return err
+				}
+				svcID = &service.ID
+			}
+
+			windows, err := database.ListMaintenanceWindows(svcID)
+			if err != nil {
+				return fmt.Errorf("failed to list maintenance windows: %w", err)
+			}
+
+			return printList(cfg, windows, maintenanceWindowColumns)
+		},
+	}
+
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Filter by service ID or slug")
+	completeServiceFlag(cfg, cmd, "service-id")
+
+	return cmd
+}
+
+func deleteMaintenanceCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete a maintenance window",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			id, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid UUID: %w", err)
+			}
+
+			if err := database.DeleteMaintenanceWindow(id); err != nil {
+				return fmt.Errorf("failed to delete maintenance window: %w", err)
+			}
+
+			fmt.Printf("Maintenance window %s deleted successfully\n", id)
+			return nil
+		},
+	}
+}
+
+var maintenanceWindowColumns = []column[models.MaintenanceWindow]{
+	{"ID", func(m models.MaintenanceWindow) interface{} { return m.ID }},
+	{"SERVICE ID", func(m models.MaintenanceWindow) interface{} { return m.ServiceID }},
+	{"NAME", func(m models.MaintenanceWindow) interface{} { return m.Name }},
+	{"STATUS", func(m models.MaintenanceWindow) interface{} {
+		now := time.Now()
+		switch {
+		case m.Active(now):
+			return status("active")
+		case now.Before(m.StartsAt):
+			return status("scheduled")
+		}
+		return status("ended")
+	}},
+	{"STARTS", func(m models.MaintenanceWindow) interface{} { return m.StartsAt }},
+	{"ENDS", func(m models.MaintenanceWindow) interface{} { return m.EndsAt }},
+}
@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/beacon/internal/api"
+	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/manifest"
+	"github.com/beacon/internal/models"
+	"github.com/beacon/internal/temporal"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+func PlanCmd(cfg *Config) *cobra.Command {
+	var file string
+	var prune bool
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show what applying a manifest would change",
+		Long: `Compare a manifest with the current state and show what applying it would
+create (+), change (~) and delete (-), without changing anything. Services
+not in the manifest, and endpoints, webhooks and maintenance windows not
+declared for their service, are left alone unless --prune is given.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := readManifest(file)
+			if err != nil {
+				return err
+			}
+
+			s, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			plan, err := manifest.Compute(s, m, prune)
+			if err != nil {
+				return err
+			}
+			plan.Write(os.Stdout)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Manifest file, YAML or JSON, or - for stdin (required)")
+	cmd.Flags().BoolVar(&prune, "prune", false, "Delete what the manifest doesn't declare")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func ApplyCmd(cfg *Config) *cobra.Command {
+	var file string
+	var prune, skipMonitors bool
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Make the current state match a manifest",
+		Long: `Create, change and delete services, endpoints, webhooks and maintenance
+windows to match a manifest, matching them up by name. Monitors are started
+for new and newly enabled endpoints, and stopped for disabled and deleted
+ones. Run beacon plan first to see what will change.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := readManifest(file)
+			if err != nil {
+				return err
+			}
+
+			s, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			plan, err := manifest.Compute(s, m, prune)
+			if err != nil {
+				return err
+			}
+			if len(plan.Changes) == 0 {
+				plan.Write(os.Stdout)
+				return nil
+			}
+
+			var monitors manifest.Monitors
+			if !skipMonitors {
+				var done func()
+				monitors, done = monitorsFor(s)
+				defer done()
+			}
+			return manifest.Apply(s, monitors, plan, os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Manifest file, YAML or JSON, or - for stdin (required)")
+	cmd.Flags().BoolVar(&prune, "prune", false, "Delete what the manifest doesn't declare")
+	cmd.Flags().BoolVar(&skipMonitors, "skip-monitors", false, "Don't start or stop monitors")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func ExportCmd(cfg *Config) *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the current state as a manifest",
+		Long: `Write the services, endpoints, webhooks and maintenance windows in view as
+a manifest that beacon apply accepts, as YAML or with --output json as JSON.
+Credentials are masked; applying the manifest keeps the stored ones.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			m, err := manifest.Export(s)
+			if err != nil {
+				return err
+			}
+
+			return writeManifest(cfg, file, m)
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "File to write (default stdout)")
+
+	return cmd
+}
+
+// writeManifest writes a manifest to a file, or stdout for - or no file.
+// Manifests are YAML unless --output asks for JSON.
+func writeManifest(cfg *Config, file string, m *manifest.Manifest) error {
+	format := manifest.FormatYAML
+	switch cfg.Output {
+	case OutputJSON:
+		format = manifest.FormatJSON
+	case "", OutputTable, OutputYAML:
+	default:
+		return fmt.Errorf("manifests can only be written as yaml or json")
+	}
+
+	if file == "" || file == "-" {
+		return manifest.Write(os.Stdout, m, format)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := manifest.Write(f, m, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readManifest reads a manifest from a file, or stdin for -.
+func readManifest(file string) (*manifest.Manifest, error) {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	m, err := manifest.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return m, nil
+}
+
+// monitorsFor returns what starts and stops monitors the same way s
+// reaches the database, and a function to call when done with it.
+func monitorsFor(s store) (manifest.Monitors, func()) {
+	if client, ok := s.(*api.Client); ok {
+		return apiMonitors{client}, func() {}
+	}
+	local := &localMonitors{database: s.(*db.DB)}
+	return local, local.Close
+}
+
+// apiMonitors starts and stops monitors through the API.
+type apiMonitors struct {
+	client *api.Client
+}
+
+func (m apiMonitors) StartMonitor(endpointID uuid.UUID) error {
+	return ignoreStatus(m.client.StartMonitor(endpointID), http.StatusConflict)
+}
+
+func (m apiMonitors) StopMonitor(endpointID uuid.UUID) error {
+	return ignoreStatus(m.client.StopMonitor(endpointID), http.StatusNotFound)
+}
+
+// ignoreStatus drops API errors with the given status, for monitors that
+// are already as wanted.
+func ignoreStatus(err error, status int) error {
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) && apiErr.Status == status {
+		return nil
+	}
+	return err
+}
+
+// localMonitors starts and stops monitors with Temporal directly, like
+// beacon monitor. It connects on first use, so applying a manifest that
+// doesn't touch monitors doesn't need Temporal.
+type localMonitors struct {
+	database *db.DB
+	client   client.Client
+}
+
+func (m *localMonitors) connect() (client.Client, error) {
+	if m.client == nil {
+		c, err := dialTemporal()
+		if err != nil {
+			return nil, err
+		}
+		m.client = c
+	}
+	return m.client, nil
+}
+
+func (m *localMonitors) StartMonitor(endpointID uuid.UUID) error {
+	c, err := m.connect()
+	if err != nil {
+		return err
+	}
+	endpoint, err := m.database.GetEndpoint(endpointID)
+	if err != nil {
+		return err
+	}
+	orgID, err := m.database.EndpointOrganization(endpointID)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = temporal.StartMonitor(ctx, c, orgID, endpoint)
+	var started *serviceerror.WorkflowExecutionAlreadyStarted
+	if errors.As(err, &started) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	recordMonitorChange(m.database, endpointID, models.AuditStart)
+	return nil
+}
+
+func (m *localMonitors) StopMonitor(endpointID uuid.UUID) error {
+	c, err := m.connect()
+	if err != nil {
+		return err
+	}
+	orgID, err := m.database.EndpointOrganization(endpointID)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = temporal.StopMonitor(ctx, c, orgID, endpointID)
+	var notRunning *serviceerror.NotFound
+	if errors.As(err, &notRunning) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	recordMonitorChange(m.database, endpointID, models.AuditStop)
+	return nil
+}
+
+func (m *localMonitors) Close() {
+	if m.client != nil {
+		m.client.Close()
+	}
+}
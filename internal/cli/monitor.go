@@ -6,25 +6,26 @@ import (
 	"os"
 
 	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
 	"github.com/beacon/internal/temporal"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"go.temporal.io/sdk/client"
 )
 
-func MonitorCmd(dbURL string) *cobra.Command {
+func MonitorCmd(cfg *Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "monitor",
 		Short: "Manage monitoring workflows",
 	}
 
-	cmd.AddCommand(startMonitoringCmd(dbURL))
-	cmd.AddCommand(stopMonitoringCmd(dbURL))
+	cmd.AddCommand(startMonitoringCmd(cfg))
+	cmd.AddCommand(stopMonitoringCmd(cfg))
 
 	return cmd
 }
 
-func startMonitoringCmd(dbURL string) *cobra.Command {
+func startMonitoringCmd(cfg *Config) *cobra.Command {
 	var endpointID string
 	var all bool
 
@@ -32,22 +33,15 @@ func startMonitoringCmd(dbURL string) *cobra.Command {
 		Use:   "start",
 		Short: "Start monitoring workflows",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openDB()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			temporalHost := os.Getenv("TEMPORAL_HOST")
-			if temporalHost == "" {
-				temporalHost = "localhost:7233"
-			}
-
-			c, err := client.Dial(client.Options{
-				HostPort: temporalHost,
-			})
+			c, err := dialTemporal()
 			if err != nil {
-				return fmt.Errorf("failed to create Temporal client: %w", err)
+				return err
 			}
 			defer c.Close()
 
@@ -61,12 +55,12 @@ func startMonitoringCmd(dbURL string) *cobra.Command {
 				fmt.Printf("Starting monitoring for %d endpoints\n", len(endpoints))
 
 				for _, endpoint := range endpoints {
-					workflowID := fmt.Sprintf("monitor-endpoint-%s", endpoint.ID)
-					
-					we, err := c.ExecuteWorkflow(context.Background(), client.StartWorkflowOptions{
-						ID:        workflowID,
-						TaskQueue: "beacon-monitoring",
-					}, temporal.MonitorEndpointWorkflow, endpoint.ID, endpoint.IntervalSec)
+					orgID, err := database.EndpointOrganization(endpoint.ID)
+					if err != nil {
+						fmt.Printf("Failed to start workflow for %s: %v\n", endpoint.Name, err)
+						continue
+					}
+					we, err := temporal.StartMonitor(context.Background(), c, orgID, &endpoint)
 					
 					if err != nil {
 						fmt.Printf("Failed to start workflow for %s: %v\n", endpoint.Name, err)
@@ -74,6 +68,13 @@ func startMonitoringCmd(dbURL string) *cobra.Command {
 					}
 					
 					fmt.Printf("✓ Started monitoring for %s (ID: %s)\n", endpoint.Name, we.GetID())
+					recordMonitorChange(database, endpoint.ID, models.AuditStart)
+				}
+
+				// The aggregate and cleanup workflows cover every
+				// organization, so only operators start them
+				if database.Tenant() != nil {
+					return nil
 				}
 
 				// Also start the aggregate metrics workflow
@@ -105,28 +106,24 @@ func startMonitoringCmd(dbURL string) *cobra.Command {
 
 			} else if endpointID != "" {
 				// Start monitoring for a specific endpoint
-				epID, err := uuid.Parse(endpointID)
+				endpoint, err := database.ResolveEndpoint(endpointID)
 				if err != nil {
-					return fmt.Errorf("invalid endpoint UUID: %w", err)
+					return fmt.Errorf("failed to get endpoint: %w", err)
 				}
+				epID := endpoint.ID
 
-				endpoint, err := database.GetEndpoint(epID)
+				orgID, err := database.EndpointOrganization(epID)
 				if err != nil {
-					return fmt.Errorf("failed to get endpoint: %w", err)
+					return err
 				}
-
-				workflowID := fmt.Sprintf("monitor-endpoint-%s", endpoint.ID)
-				
-				we, err := c.ExecuteWorkflow(context.Background(), client.StartWorkflowOptions{
-					ID:        workflowID,
-					TaskQueue: "beacon-monitoring",
-				}, temporal.MonitorEndpointWorkflow, endpoint.ID, endpoint.IntervalSec)
+				we, err := temporal.StartMonitor(context.Background(), c, orgID, endpoint)
 				
 				if err != nil {
 					return fmt.Errorf("failed to start workflow: %w", err)
 				}
 				
 				fmt.Printf("✓ Started monitoring for %s (ID: %s)\n", endpoint.Name, we.GetID())
+				recordMonitorChange(database, epID, models.AuditStart)
 			} else {
 				return fmt.Errorf("specify --endpoint-id or --all")
 			}
@@ -135,13 +132,14 @@ func startMonitoringCmd(dbURL string) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Endpoint ID to monitor")
+	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Endpoint to monitor, by ID or service/endpoint")
+	completeEndpointFlag(cfg, cmd, "endpoint-id")
 	cmd.Flags().BoolVar(&all, "all", false, "Start monitoring for all enabled endpoints")
 
 	return cmd
 }
 
-func stopMonitoringCmd(dbURL string) *cobra.Command {
+func stopMonitoringCmd(cfg *Config) *cobra.Command {
 	var endpointID string
 	var all bool
 
@@ -149,59 +147,70 @@ func stopMonitoringCmd(dbURL string) *cobra.Command {
 		Use:   "stop",
 		Short: "Stop monitoring workflows",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			temporalHost := os.Getenv("TEMPORAL_HOST")
-			if temporalHost == "" {
-				temporalHost = "localhost:7233"
+			c, err := dialTemporal()
+			if err != nil {
+				return err
 			}
+			defer c.Close()
 
-			c, err := client.Dial(client.Options{
-				HostPort: temporalHost,
-			})
+			database, err := cfg.openDB()
 			if err != nil {
-				return fmt.Errorf("failed to create Temporal client: %w", err)
+				return err
 			}
-			defer c.Close()
+			defer database.Close()
 
 			if all {
-				database, err := db.NewDB(dbURL)
-				if err != nil {
-					return err
-				}
-				defer database.Close()
-
 				endpoints, err := database.ListEnabledEndpoints()
 				if err != nil {
 					return fmt.Errorf("failed to list endpoints: %w", err)
 				}
 
 				for _, endpoint := range endpoints {
-					workflowID := fmt.Sprintf("monitor-endpoint-%s", endpoint.ID)
-					err := c.CancelWorkflow(context.Background(), workflowID, "")
+					orgID, err := database.EndpointOrganization(endpoint.ID)
+					if err == nil {
+						err = temporal.StopMonitor(context.Background(), c, orgID, endpoint.ID)
+					}
 					if err != nil {
 						fmt.Printf("Failed to stop workflow for %s: %v\n", endpoint.Name, err)
 					} else {
 						fmt.Printf("✓ Stopped monitoring for %s\n", endpoint.Name)
+						recordMonitorChange(database, endpoint.ID, models.AuditStop)
 					}
 				}
 
+				if database.Tenant() != nil {
+					return nil
+				}
+
 				// Stop aggregate and cleanup workflows
 				c.CancelWorkflow(context.Background(), "aggregate-metrics", "")
 				c.CancelWorkflow(context.Background(), "cleanup-old-data", "")
 				fmt.Printf("✓ Stopped aggregate and cleanup workflows\n")
 
 			} else if endpointID != "" {
+				// Deleted endpoints can still be stopped by ID
 				epID, err := uuid.Parse(endpointID)
 				if err != nil {
-					return fmt.Errorf("invalid endpoint UUID: %w", err)
+					endpoint, err := database.ResolveEndpoint(endpointID)
+					if err != nil {
+						return fmt.Errorf("failed to get endpoint: %w", err)
+					}
+					epID = endpoint.ID
 				}
-
-				workflowID := fmt.Sprintf("monitor-endpoint-%s", epID)
-				err = c.CancelWorkflow(context.Background(), workflowID, "")
+				orgID, err := database.EndpointOrganization(epID)
+				if err != nil {
+					return err
+				}
+				if t := database.Tenant(); t != nil && t.OrganizationID != orgID {
+					return fmt.Errorf("endpoint %s is not in organization %s", epID, t.OrganizationID)
+				}
+				err = temporal.StopMonitor(context.Background(), c, orgID, epID)
 				if err != nil {
 					return fmt.Errorf("failed to stop workflow: %w", err)
 				}
 				
 				fmt.Printf("✓ Stopped monitoring for endpoint %s\n", endpointID)
+				recordMonitorChange(database, epID, models.AuditStop)
 			} else {
 				return fmt.Errorf("specify --endpoint-id or --all")
 			}
@@ -210,8 +219,32 @@ func stopMonitoringCmd(dbURL string) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Endpoint ID to stop monitoring")
+	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Endpoint to stop monitoring, by ID or service/endpoint")
+	completeEndpointFlag(cfg, cmd, "endpoint-id")
 	cmd.Flags().BoolVar(&all, "all", false, "Stop monitoring for all endpoints")
 
 	return cmd
-}
\ No newline at end of file
+}
+
+// dialTemporal connects to Temporal at TEMPORAL_HOST.
+func dialTemporal() (client.Client, error) {
+	temporalHost := os.Getenv("TEMPORAL_HOST")
+	if temporalHost == "" {
+		temporalHost = "localhost:7233"
+	}
+	c, err := client.Dial(client.Options{
+		HostPort: temporalHost,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Temporal client: %w", err)
+	}
+	return c, nil
+}
+
+// recordMonitorChange adds a monitor start or stop to the audit log. The
+// workflow has already changed by then, so failures are only reported.
+func recordMonitorChange(database *db.DB, endpointID uuid.UUID, action string) {
+	if err := database.RecordMonitorChange(endpointID, action); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record monitor %s in the audit log: %v\n", action, err)
+	}
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+func OrganizationsCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "orgs",
+		Aliases: []string{"organizations"},
+		Short:   "Manage organizations",
+		Long: `Manage organizations. Each organization has its own projects, services,
+users and secrets, and quotas on its endpoints. Only operators (users outside
+any organization) can create, change or delete organizations.`,
+	}
+
+	cmd.AddCommand(createOrganizationCmd(cfg))
+	cmd.AddCommand(listOrganizationsCmd(cfg))
+	cmd.AddCommand(updateOrganizationCmd(cfg))
+	cmd.AddCommand(deleteOrganizationCmd(cfg))
+
+	return cmd
+}
+
+// findOrganization looks an organization up by ID or slug.
+func findOrganization(database store, ref string) (*models.Organization, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return database.GetOrganization(id)
+	}
+	return database.GetOrganizationBySlug(ref)
+}
+
+func createOrganizationCmd(cfg *Config) *cobra.Command {
+	var (
+		slug, name     string
+		maxEndpoints   int
+		minIntervalSec int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an organization with a default project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			org := &models.Organization{
+				Slug:           slug,
+				Name:           name,
+				MaxEndpoints:   maxEndpoints,
+				MinIntervalSec: minIntervalSec,
+			}
+
+			if err := org.Validate(); err != nil {
+				return err
+			}
+			if err := database.CreateOrganization(org); err != nil {
+				return fmt.Errorf("failed to create organization: %w", err)
+			}
+
+			return printOne(cfg, *org, organizationColumns)
+		},
+	}
+
+	cmd.Flags().StringVar(&slug, "slug", "", "Short name used in --project (required)")
+	cmd.Flags().StringVar(&name, "name", "", "Organization name (required)")
+	cmd.Flags().IntVar(&maxEndpoints, "max-endpoints", 0, "Most endpoints the organization may have (0 for no limit)")
+	cmd.Flags().IntVar(&minIntervalSec, "min-interval", 0, "Shortest check interval in seconds (0 for no limit)")
+	cmd.MarkFlagRequired("slug")
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func listOrganizationsCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List organizations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			orgs, err := database.ListOrganizations()
+			if err != nil {
+				return fmt.Errorf("failed to list organizations: %w", err)
+			}
+
+			return printList(cfg, orgs, organizationColumns)
+		},
+	}
+}
+
+func updateOrganizationCmd(cfg *Config) *cobra.Command {
+	var (
+		slug, name     string
+		maxEndpoints   int
+		minIntervalSec int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "update [id or slug]",
+		Short: "Update an organization or its quotas",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			org, err := findOrganization(database, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get organization: %w", err)
+			}
+
+			if slug != "" {
+				org.Slug = slug
+			}
+			if name != "" {
+				org.Name = name
+			}
+			if cmd.Flags().Changed("max-endpoints") {
+				org.MaxEndpoints = maxEndpoints
+			}
+			if cmd.Flags().Changed("min-interval") {
+				org.MinIntervalSec = minIntervalSec
+			}
+
+			if err := org.Validate(); err != nil {
+				return err
+			}
+			if err := database.UpdateOrganization(org); err != nil {
+				return fmt.Errorf("failed to update organization: %w", err)
+			}
+
+			fmt.Printf("Organization %s updated successfully\n", org.Slug)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&slug, "slug", "", "Short name used in --project")
+	cmd.Flags().StringVar(&name, "name", "", "Organization name")
+	cmd.Flags().IntVar(&maxEndpoints, "max-endpoints", 0, "Most endpoints the organization may have (0 for no limit)")
+	cmd.Flags().IntVar(&minIntervalSec, "min-interval", 0, "Shortest check interval in seconds (0 for no limit)")
+
+	return cmd
+}
+
+func deleteOrganizationCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [id or slug]",
+		Short: "Delete an organization without services, with its projects and users",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			org, err := findOrganization(database, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get organization: %w", err)
+			}
+			if err := database.DeleteOrganization(org.ID); err != nil {
+				return fmt.Errorf("failed to delete organization: %w", err)
+			}
+
+			fmt.Printf("Organization %s deleted successfully\n", org.Slug)
+			return nil
+		},
+	}
+}
+
+var organizationColumns = []column[models.Organization]{
+	{"ID", func(o models.Organization) interface{} { return o.ID }},
+	{"SLUG", func(o models.Organization) interface{} { return o.Slug }},
+	{"NAME", func(o models.Organization) interface{} { return o.Name }},
+	{"MAX ENDPOINTS", func(o models.Organization) interface{} { return o.MaxEndpoints }},
+	{"MIN INTERVAL", func(o models.Organization) interface{} { return seconds(o.MinIntervalSec) }},
+	{"CREATED", func(o models.Organization) interface{} { return o.CreatedAt }},
+}
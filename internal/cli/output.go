@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/beacon/internal/jsonpath"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by --output. jsonpath=<expr> prints the values
+// the expression matches in the JSON output, one per line.
+const (
+	OutputTable    = "table"
+	OutputJSON     = "json"
+	OutputYAML     = "yaml"
+	OutputCSV      = "csv"
+	outputJSONPath = "jsonpath="
+)
+
+// maxCellWidth is where table cells are cut off. The other formats print
+// values in full.
+const maxCellWidth = 60
+
+// CheckOutput validates --output, so that a bad format fails before a
+// command changes anything.
+func (c *Config) CheckOutput() error {
+	switch c.Output {
+	case "", OutputTable, OutputJSON, OutputYAML, OutputCSV:
+		return nil
+	}
+	if expr, ok := strings.CutPrefix(c.Output, outputJSONPath); ok {
+		if _, err := jsonpath.Compile(expr); err != nil {
+			return fmt.Errorf("invalid --output: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid --output %q: must be table, json, yaml, csv or jsonpath=<expr>", c.Output)
+}
+
+// column is one column of table and CSV output. value returns the cell;
+// times, UUIDs, string lists and statuses are formatted by the printer.
+type column[T any] struct {
+	header string
+	value  func(T) interface{}
+}
+
+// status is a cell that is colored in tables by what it says.
+type status string
+
+// statusColors are the ANSI colors for status cells.
+var statusColors = map[status]string{
+	"ok": "32", "up": "32", "success": "32", "resolved": "32", "enabled": "32", "active": "32", "valid": "32", "good": "32",
+	"fail": "31", "down": "31", "open": "31", "revoked": "31", "expired": "31", "invalid": "31",
+	"start": "33", "scheduled": "33", "unknown": "33", "failing": "33", "maintenance": "36",
+	"disabled": "90", "ended": "90", "pending": "90", "paused": "90",
+}
+
+// enabledStatus describes an enabled flag as a status.
+func enabledStatus(enabled bool) status {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// printList prints items in the --output format, as one row per item in
+// tables and CSV.
+func printList[T any](cfg *Config, items []T, columns []column[T]) error {
+	if items == nil {
+		items = []T{}
+	}
+	return render(cfg, items, headers(columns), rows(items, columns))
+}
+
+// printOne prints a single item in the --output format, as a one-row table
+// or as an object rather than a list.
+func printOne[T any](cfg *Config, item T, columns []column[T]) error {
+	return render(cfg, item, headers(columns), rows([]T{item}, columns))
+}
+
+func headers[T any](columns []column[T]) []string {
+	h := make([]string, len(columns))
+	for i, c := range columns {
+		h[i] = c.header
+	}
+	return h
+}
+
+func rows[T any](items []T, columns []column[T]) [][]interface{} {
+	r := make([][]interface{}, len(items))
+	for i, item := range items {
+		r[i] = make([]interface{}, len(columns))
+		for j, c := range columns {
+			r[i][j] = c.value(item)
+		}
+	}
+	return r
+}
+
+// render writes v, or its rows, to stdout in the --output format.
+func render(cfg *Config, v interface{}, headers []string, rows [][]interface{}) error {
+	w := os.Stdout
+	switch cfg.Output {
+	case "", OutputTable:
+		return writeTable(w, headers, rows, !cfg.NoHeaders, colorEnabled(w), time.Now())
+	case OutputCSV:
+		return writeCSV(w, headers, rows, !cfg.NoHeaders)
+	case OutputJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	tree, err := jsonTree(v)
+	if err != nil {
+		return err
+	}
+	if cfg.Output == OutputYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(tree); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	expr, ok := strings.CutPrefix(cfg.Output, outputJSONPath)
+	if !ok {
+		return cfg.CheckOutput()
+	}
+	value, found, err := jsonpath.Lookup(tree, expr)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("jsonpath %s matched nothing", expr)
+	}
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	for _, value := range values {
+		fmt.Fprintln(w, jsonpath.Format(value))
+	}
+	return nil
+}
+
+// jsonTree converts v to the generic form of its JSON, so YAML and JSONPath
+// see the same field names as JSON.
+func jsonTree(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	err = json.Unmarshal(data, &tree)
+	return tree, err
+}
+
+// writeTable writes rows as aligned columns. Times are shown relative to
+// now, and status cells are colored if color is set.
+func writeTable(w io.Writer, headers []string, rows [][]interface{}, showHeaders, color bool, now time.Time) error {
+	cells := make([][]string, 0, len(rows)+1)
+	if showHeaders {
+		cells = append(cells, headers)
+	}
+	for _, row := range rows {
+		line := make([]string, len(row))
+		for i, value := range row {
+			line[i] = truncate(formatCell(value, true, now), maxCellWidth)
+		}
+		cells = append(cells, line)
+	}
+
+	widths := make([]int, len(headers))
+	for _, line := range cells {
+		for i, cell := range line {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var b strings.Builder
+	for r, line := range cells {
+		row := r
+		if showHeaders {
+			row--
+		}
+		for i, cell := range line {
+			padding := ""
+			if i < len(line)-1 {
+				padding = strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+3)
+			}
+			// Color just the text, so the escape codes don't upset the
+			// alignment
+			if s, ok := cellStatus(rows, row, i); color && ok {
+				if code, ok := statusColors[s]; ok {
+					cell = "\x1b[" + code + "m" + cell + "\x1b[0m"
+				}
+			}
+			b.WriteString(cell + padding)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cellStatus returns the cell at row and column i if it's a status. Row -1
+// is the header.
+func cellStatus(rows [][]interface{}, row, i int) (status, bool) {
+	if row < 0 {
+		return "", false
+	}
+	s, ok := rows[row][i].(status)
+	return s, ok
+}
+
+// writeCSV writes rows as CSV, with times in RFC 3339.
+func writeCSV(w io.Writer, headers []string, rows [][]interface{}, showHeaders bool) error {
+	cw := csv.NewWriter(w)
+	if showHeaders {
+		header := make([]string, len(headers))
+		for i, h := range headers {
+			header[i] = strings.ToLower(strings.ReplaceAll(h, " ", "_"))
+		}
+		cw.Write(header)
+	}
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatCell(value, false, time.Time{})
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatCell renders a cell value as text. In tables times are relative to
+// now and text is kept to one line; otherwise times are RFC 3339.
+func formatCell(value interface{}, table bool, now time.Time) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if table {
+			return strings.Join(strings.Fields(v), " ")
+		}
+		return v
+	case status:
+		return string(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		if table {
+			return relativeTime(v, now)
+		}
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return formatCell(*v, table, now)
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	case uuid.UUID:
+		if v == uuid.Nil {
+			return ""
+		}
+		return v.String()
+	case *uuid.UUID:
+		if v == nil {
+			return ""
+		}
+		return formatCell(*v, table, now)
+	case []string:
+		return strings.Join(v, ",")
+	case pq.StringArray:
+		return strings.Join(v, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// relativeTime describes t as an age ("3m ago") or a wait ("in 2h"). Times
+// more than 30 days away are shown as a date.
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	var ago string
+	switch {
+	case d < time.Second:
+		return "now"
+	case d < time.Minute:
+		ago = fmt.Sprintf("%ds", int(d/time.Second))
+	case d < time.Hour:
+		ago = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 48*time.Hour:
+		ago = fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		ago = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	default:
+		return t.Local().Format("2006-01-02")
+	}
+	if future {
+		return "in " + ago
+	}
+	return ago + " ago"
+}
+
+// seconds renders a number of seconds in the largest whole unit.
+func seconds(n int) string {
+	switch {
+	case n != 0 && n%3600 == 0:
+		return fmt.Sprintf("%dh", n/3600)
+	case n != 0 && n%60 == 0:
+		return fmt.Sprintf("%dm", n/60)
+	}
+	return fmt.Sprintf("%ds", n)
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// colorEnabled reports whether to color output to f: only on a terminal,
+// and not if NO_COLOR is set.
+func colorEnabled(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
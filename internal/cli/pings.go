@@ -1,34 +1,33 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
 	"time"
 
-	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
-func PingsCmd(dbURL string) *cobra.Command {
+func PingsCmd(cfg *Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "pings",
 		Short: "Manage pings",
 	}
 
-	cmd.AddCommand(getPingCmd(dbURL))
-	cmd.AddCommand(listPingsCmd(dbURL))
+	cmd.AddCommand(getPingCmd(cfg))
+	cmd.AddCommand(listPingsCmd(cfg))
 
 	return cmd
 }
 
-func getPingCmd(dbURL string) *cobra.Command {
+func getPingCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "get [id]",
 		Short: "Get a ping by ID",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -44,16 +43,15 @@ func getPingCmd(dbURL string) *cobra.Command {
 				return fmt.Errorf("failed to get ping: %w", err)
 			}
 
-			data, _ := json.MarshalIndent(ping, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printOne(cfg, *ping, pingColumns)
 		},
 	}
 }
 
-func listPingsCmd(dbURL string) *cobra.Command {
+func listPingsCmd(cfg *Config) *cobra.Command {
 	var (
 		endpointID string
+		region     string
 		limit      int
 		startTime  string
 		endTime    string
@@ -63,16 +61,17 @@ func listPingsCmd(dbURL string) *cobra.Command {
 		Use:   "list",
 		Short: "List pings",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			epID, err := uuid.Parse(endpointID)
+			endpoint, err := findEndpoint(database, endpointID)
 			if err != nil {
-				return fmt.Errorf("invalid endpoint UUID: %w", err)
+				return err
 			}
+			epID := endpoint.ID
 
 			if startTime != "" && endTime != "" {
 				start, err := time.Parse(time.RFC3339, startTime)
@@ -84,32 +83,61 @@ func listPingsCmd(dbURL string) *cobra.Command {
 					return fmt.Errorf("invalid end time: %w", err)
 				}
 
-				pings, err := database.ListPingsByTimeRange(epID, start, end)
+				pings, err := database.ListPingsByTimeRange(epID, region, start, end)
 				if err != nil {
 					return fmt.Errorf("failed to list pings: %w", err)
 				}
 
-				data, _ := json.MarshalIndent(pings, "", "  ")
-				fmt.Println(string(data))
+				if err := printList(cfg, pings, pingColumns); err != nil {
+					return err
+				}
 			} else {
-				pings, err := database.ListPings(epID, limit)
+				pings, err := database.ListPings(epID, region, limit)
 				if err != nil {
 					return fmt.Errorf("failed to list pings: %w", err)
 				}
 
-				data, _ := json.MarshalIndent(pings, "", "  ")
-				fmt.Println(string(data))
+				if err := printList(cfg, pings, pingColumns); err != nil {
+					return err
+				}
 			}
 
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Endpoint ID (required)")
+	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Endpoint ID or service/endpoint (required)")
+	cmd.Flags().StringVar(&region, "region", "", "Filter by probe region")
 	cmd.Flags().IntVar(&limit, "limit", 100, "Maximum number of pings to return")
 	cmd.Flags().StringVar(&startTime, "start", "", "Start time (RFC3339 format)")
 	cmd.Flags().StringVar(&endTime, "end", "", "End time (RFC3339 format)")
 	cmd.MarkFlagRequired("endpoint-id")
+	completeEndpointFlag(cfg, cmd, "endpoint-id")
 
 	return cmd
-}
\ No newline at end of file
+}
+
+var pingColumns = []column[models.Ping]{
+	{"ID", func(p models.Ping) interface{} { return p.ID }},
+	{"TIME", func(p models.Ping) interface{} { return p.CreatedAt }},
+	{"REGION", func(p models.Ping) interface{} { return p.Region }},
+	{"STATUS", func(p models.Ping) interface{} {
+		if p.Success {
+			return status("ok")
+		}
+		return status("fail")
+	}},
+	{"CODE", func(p models.Ping) interface{} {
+		if p.StatusCode == 0 {
+			return ""
+		}
+		return p.StatusCode
+	}},
+	{"RESPONSE", func(p models.Ping) interface{} { return fmt.Sprintf("%dms", p.ResponseMs) }},
+	{"ERROR", func(p models.Ping) interface{} {
+		if p.Error == nil {
+			return ""
+		}
+		return *p.Error
+	}},
+}
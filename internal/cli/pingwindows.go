@@ -1,34 +1,33 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
 	"time"
 
-	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
-func PingWindowsCmd(dbURL string) *cobra.Command {
+func PingWindowsCmd(cfg *Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "ping-windows",
 		Short: "Manage ping windows (aggregated metrics)",
 	}
 
-	cmd.AddCommand(getPingWindowCmd(dbURL))
-	cmd.AddCommand(listPingWindowsCmd(dbURL))
+	cmd.AddCommand(getPingWindowCmd(cfg))
+	cmd.AddCommand(listPingWindowsCmd(cfg))
 
 	return cmd
 }
 
-func getPingWindowCmd(dbURL string) *cobra.Command {
+func getPingWindowCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "get [id]",
 		Short: "Get a ping window by ID",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -44,16 +43,15 @@ func getPingWindowCmd(dbURL string) *cobra.Command {
 				return fmt.Errorf("failed to get ping window: %w", err)
 			}
 
-			data, _ := json.MarshalIndent(window, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printOne(cfg, *window, pingWindowColumns)
 		},
 	}
 }
 
-func listPingWindowsCmd(dbURL string) *cobra.Command {
+func listPingWindowsCmd(cfg *Config) *cobra.Command {
 	var (
 		endpointID string
+		region     string
 		limit      int
 		startTime  string
 		endTime    string
@@ -63,16 +61,17 @@ func listPingWindowsCmd(dbURL string) *cobra.Command {
 		Use:   "list",
 		Short: "List ping windows",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			epID, err := uuid.Parse(endpointID)
+			endpoint, err := findEndpoint(database, endpointID)
 			if err != nil {
-				return fmt.Errorf("invalid endpoint UUID: %w", err)
+				return err
 			}
+			epID := endpoint.ID
 
 			if startTime != "" && endTime != "" {
 				start, err := time.Parse(time.RFC3339, startTime)
@@ -84,32 +83,53 @@ func listPingWindowsCmd(dbURL string) *cobra.Command {
 					return fmt.Errorf("invalid end time: %w", err)
 				}
 
-				windows, err := database.ListPingWindowsByTimeRange(epID, start, end)
+				windows, err := database.ListPingWindowsByTimeRange(epID, region, start, end)
 				if err != nil {
 					return fmt.Errorf("failed to list ping windows: %w", err)
 				}
 
-				data, _ := json.MarshalIndent(windows, "", "  ")
-				fmt.Println(string(data))
+				if err := printList(cfg, windows, pingWindowColumns); err != nil {
+					return err
+				}
 			} else {
-				windows, err := database.ListPingWindows(epID, limit)
+				windows, err := database.ListPingWindows(epID, region, limit)
 				if err != nil {
 					return fmt.Errorf("failed to list ping windows: %w", err)
 				}
 
-				data, _ := json.MarshalIndent(windows, "", "  ")
-				fmt.Println(string(data))
+				if err := printList(cfg, windows, pingWindowColumns); err != nil {
+					return err
+				}
 			}
 
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Endpoint ID (required)")
+	cmd.Flags().StringVar(&endpointID, "endpoint-id", "", "Endpoint ID or service/endpoint (required)")
+	cmd.Flags().StringVar(&region, "region", "", "Filter by probe region")
 	cmd.Flags().IntVar(&limit, "limit", 100, "Maximum number of windows to return")
 	cmd.Flags().StringVar(&startTime, "start", "", "Start time (RFC3339 format)")
 	cmd.Flags().StringVar(&endTime, "end", "", "End time (RFC3339 format)")
 	cmd.MarkFlagRequired("endpoint-id")
+	completeEndpointFlag(cfg, cmd, "endpoint-id")
 
 	return cmd
-}
\ No newline at end of file
+}
+
+var pingWindowColumns = []column[models.PingWindow]{
+	{"ID", func(w models.PingWindow) interface{} { return w.ID }},
+	{"START", func(w models.PingWindow) interface{} { return w.WindowStart }},
+	{"END", func(w models.PingWindow) interface{} { return w.WindowEnd }},
+	{"REGION", func(w models.PingWindow) interface{} { return w.Region }},
+	{"PINGS", func(w models.PingWindow) interface{} { return w.TotalPings }},
+	{"UPTIME", func(w models.PingWindow) interface{} {
+		if w.TotalPings == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%.1f%%", 100*float64(w.SuccessPings)/float64(w.TotalPings))
+	}},
+	{"AVG", func(w models.PingWindow) interface{} { return fmt.Sprintf("%dms", w.AvgResponseMs) }},
+	{"MIN", func(w models.PingWindow) interface{} { return fmt.Sprintf("%dms", w.MinResponseMs) }},
+	{"MAX", func(w models.PingWindow) interface{} { return fmt.Sprintf("%dms", w.MaxResponseMs) }},
+}
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+func ProjectsCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "projects",
+		Short: "Manage projects",
+		Long: `Manage projects, which group services within an organization. Every
+organization starts with a "default" project. Select one for other commands
+with --project <organization>/<project>.`,
+	}
+
+	cmd.AddCommand(createProjectCmd(cfg))
+	cmd.AddCommand(listProjectsCmd(cfg))
+	cmd.AddCommand(updateProjectCmd(cfg))
+	cmd.AddCommand(deleteProjectCmd(cfg))
+
+	return cmd
+}
+
+// findProject looks a project up by ID, or by slug among the projects the
+// store sees.
+func findProject(database store, ref string) (*models.Project, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return database.GetProject(id)
+	}
+	projects, err := database.ListProjects(nil)
+	if err != nil {
+		return nil, err
+	}
+	var found *models.Project
+	for i := range projects {
+		if projects[i].Slug != ref {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("more than one organization has a project %s; use its ID or --project", ref)
+		}
+		found = &projects[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("project %s not found", ref)
+	}
+	return found, nil
+}
+
+func createProjectCmd(cfg *Config) *cobra.Command {
+	var slug, name, organization string
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a project",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			project := &models.Project{
+				Slug: slug,
+				Name: name,
+			}
+			if organization != "" {
+				org, err := findOrganization(database, organization)
+				if err != nil {
+					return fmt.Errorf("failed to get organization: %w", err)
+				}
+				project.OrganizationID = org.ID
+			}
+
+			if err := project.Validate(); err != nil {
+				return err
+			}
+			if err := database.CreateProject(project); err != nil {
+				return fmt.Errorf("failed to create project: %w", err)
+			}
+
+			return printOne(cfg, *project, projectColumns)
+		},
+	}
+
+	cmd.Flags().StringVar(&slug, "slug", "", "Short name used in --project (required)")
+	cmd.Flags().StringVar(&name, "name", "", "Project name (required)")
+	cmd.Flags().StringVar(&organization, "organization", "", "Organization ID or slug (defaults to yours or --project's)")
+	cmd.MarkFlagRequired("slug")
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func listProjectsCmd(cfg *Config) *cobra.Command {
+	var organization string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List projects",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			var orgID *uuid.UUID
+			if organization != "" {
+				org, err := findOrganization(database, organization)
+				if err != nil {
+					return fmt.Errorf("failed to get organization: %w", err)
+				}
+				orgID = &org.ID
+			}
+
+			projects, err := database.ListProjects(orgID)
+			if err != nil {
+				return fmt.Errorf("failed to list projects: %w", err)
+			}
+
+			return printList(cfg, projects, projectColumns)
+		},
+	}
+
+	cmd.Flags().StringVar(&organization, "organization", "", "Only projects of this organization (ID or slug)")
+
+	return cmd
+}
+
+func updateProjectCmd(cfg *Config) *cobra.Command {
+	var slug, name string
+
+	cmd := &cobra.Command{
+		Use:   "update [id or slug]",
+		Short: "Update a project",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			project, err := findProject(database, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get project: %w", err)
+			}
+
+			if slug != "" {
+				project.Slug = slug
+			}
+			if name != "" {
+				project.Name = name
+			}
+
+			if err := project.Validate(); err != nil {
+				return err
+			}
+			if err := database.UpdateProject(project); err != nil {
+				return fmt.Errorf("failed to update project: %w", err)
+			}
+
+			fmt.Printf("Project %s updated successfully\n", project.Slug)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&slug, "slug", "", "Short name used in --project")
+	cmd.Flags().StringVar(&name, "name", "", "Project name")
+
+	return cmd
+}
+
+func deleteProjectCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [id or slug]",
+		Short: "Delete a project without services",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			project, err := findProject(database, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get project: %w", err)
+			}
+			if err := database.DeleteProject(project.ID); err != nil {
+				return fmt.Errorf("failed to delete project: %w", err)
+			}
+
+			fmt.Printf("Project %s deleted successfully\n", project.Slug)
+			return nil
+		},
+	}
+}
+
+var projectColumns = []column[models.Project]{
+	{"ID", func(p models.Project) interface{} { return p.ID }},
+	{"ORGANIZATION ID", func(p models.Project) interface{} { return p.OrganizationID }},
+	{"SLUG", func(p models.Project) interface{} { return p.Slug }},
+	{"NAME", func(p models.Project) interface{} { return p.Name }},
+	{"CREATED", func(p models.Project) interface{} { return p.CreatedAt }},
+}
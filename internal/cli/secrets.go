@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/beacon/internal/models"
+	"github.com/beacon/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+func SecretsCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage encrypted secrets",
+		Long: `Manage encrypted secrets referenced from endpoint and webhook settings as {{secret "name"}}.
+
+Values are encrypted with a master key from ` + secrets.KeyEnv + ` (base64) or ` + secrets.KeyFileEnv + `
+and are only decrypted by workers. Secret values are never printed.`,
+	}
+
+	cmd.AddCommand(setSecretCmd(cfg))
+	cmd.AddCommand(listSecretsCmd(cfg))
+	cmd.AddCommand(rotateSecretsCmd(cfg))
+	cmd.AddCommand(deleteSecretCmd(cfg))
+
+	return cmd
+}
+
+func setSecretCmd(cfg *Config) *cobra.Command {
+	var value, fromFile string
+
+	cmd := &cobra.Command{
+		Use:   "set [name]",
+		Short: "Create or replace a secret (reads the value from stdin if no flag is given)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			keyring, err := secrets.LoadKeyring()
+			if err != nil {
+				return err
+			}
+
+			var plaintext []byte
+			switch {
+			case cmd.Flags().Changed("value"):
+				plaintext = []byte(value)
+			case fromFile != "":
+				plaintext, err = os.ReadFile(fromFile)
+				if err != nil {
+					return fmt.Errorf("failed to read secret file: %w", err)
+				}
+			default:
+				plaintext, err = io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return fmt.Errorf("failed to read secret from stdin: %w", err)
+				}
+				plaintext = []byte(strings.TrimRight(string(plaintext), "\r\n"))
+			}
+
+			sealed, err := keyring.Seal(name, plaintext)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt secret: %w", err)
+			}
+
+			database, err := cfg.openDB()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			secret := &models.Secret{
+				Name:       name,
+				KeyID:      sealed.KeyID,
+				WrappedKey: sealed.WrappedKey,
+				Ciphertext: sealed.Ciphertext,
+			}
+			if err := database.SetSecret(secret); err != nil {
+				return fmt.Errorf("failed to set secret: %w", err)
+			}
+
+			fmt.Printf("Secret %s set successfully (reference it as %s)\n", name, `{{secret "`+name+`"}}`)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&value, "value", "", "Secret value (prefer stdin or --from-file to keep it out of shell history)")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "Read the secret value from a file")
+	cmd.MarkFlagsMutuallyExclusive("value", "from-file")
+
+	return cmd
+}
+
+func listSecretsCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List secret names",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openDB()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			list, err := database.ListSecrets()
+			if err != nil {
+				return fmt.Errorf("failed to list secrets: %w", err)
+			}
+
+			return printList(cfg, list, secretColumns)
+		},
+	}
+}
+
+func rotateSecretsCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate [name]",
+		Short: "Re-encrypt secrets under a fresh data key and the current master key",
+		Long: `Re-encrypt one secret, or all secrets if no name is given, under a fresh data key.
+
+To change the master key, set the new key in ` + secrets.KeyEnv + ` and the old one in
+` + secrets.PreviousKeyEnv + ` (or the _FILE variants), run rotate, then remove the old key.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyring, err := secrets.LoadKeyring()
+			if err != nil {
+				return err
+			}
+
+			database, err := cfg.openDB()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			var toRotate []models.Secret
+			if len(args) == 1 {
+				secret, err := database.GetSecret(args[0])
+				if err != nil {
+					return err
+				}
+				toRotate = append(toRotate, *secret)
+			} else {
+				toRotate, err = database.ListSecrets()
+				if err != nil {
+					return fmt.Errorf("failed to list secrets: %w", err)
+				}
+			}
+
+			for _, secret := range toRotate {
+				plaintext, err := keyring.Open(secret.Name, &secrets.Sealed{
+					KeyID:      secret.KeyID,
+					WrappedKey: secret.WrappedKey,
+					Ciphertext: secret.Ciphertext,
+				})
+				if err != nil {
+					return err
+				}
+
+				sealed, err := keyring.Seal(secret.Name, plaintext)
+				if err != nil {
+					return fmt.Errorf("failed to encrypt secret %s: %w", secret.Name, err)
+				}
+				secret.KeyID = sealed.KeyID
+				secret.WrappedKey = sealed.WrappedKey
+				secret.Ciphertext = sealed.Ciphertext
+
+				if err := database.SetSecret(&secret); err != nil {
+					return fmt.Errorf("failed to save secret %s: %w", secret.Name, err)
+				}
+				fmt.Printf("✓ Rotated %s (key %s)\n", secret.Name, secret.KeyID)
+			}
+
+			return nil
+		},
+	}
+}
+
+func deleteSecretCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [name]",
+		Short: "Delete a secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openDB()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			if err := database.DeleteSecret(args[0]); err != nil {
+				return fmt.Errorf("failed to delete secret: %w", err)
+			}
+
+			fmt.Printf("Secret %s deleted successfully\n", args[0])
+			return nil
+		},
+	}
+}
+
+var secretColumns = []column[models.Secret]{
+	{"NAME", func(s models.Secret) interface{} { return s.Name }},
+	{"KEY ID", func(s models.Secret) interface{} { return s.KeyID }},
+	{"CREATED", func(s models.Secret) interface{} { return s.CreatedAt }},
+	{"UPDATED", func(s models.Secret) interface{} { return s.UpdatedAt }},
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/beacon/internal/api"
+	"github.com/spf13/cobra"
+	"go.temporal.io/sdk/client"
+)
+
+func ServeCmd(cfg *Config) *cobra.Command {
+	var (
+		addr      string
+		printSpec bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the HTTP API",
+		Long: `Serve the HTTP API for services, endpoints, webhooks, incidents, pings and
+monitors. The OpenAPI document is served at /openapi.json.
+
+Requests need an API token (see beacon tokens create), sent as
+Authorization: Bearer <token>.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if printSpec {
+				data, _ := json.MarshalIndent((&api.Server{}).OpenAPI(), "", "  ")
+				fmt.Println(string(data))
+				return nil
+			}
+
+			// The API scopes each request to its caller, so it serves every
+			// organization whatever --project says
+			database, err := cfg.connect()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			temporalHost := os.Getenv("TEMPORAL_HOST")
+			if temporalHost == "" {
+				temporalHost = "localhost:7233"
+			}
+
+			// Connect on first use so the API serves even while Temporal is down
+			c, err := client.NewLazyClient(client.Options{
+				HostPort: temporalHost,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create Temporal client: %w", err)
+			}
+			defer c.Close()
+
+			server := &http.Server{
+				Addr:              addr,
+				Handler:           (&api.Server{DB: database, Temporal: c}).Handler(),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			errChan := make(chan error, 1)
+			go func() {
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errChan <- err
+				}
+			}()
+
+			log.Printf("Beacon API listening on %s", addr)
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+			select {
+			case err := <-errChan:
+				return fmt.Errorf("failed to serve API: %w", err)
+			case <-sigChan:
+			}
+
+			log.Println("Shutting down API...")
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return server.Shutdown(ctx)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8000", "Address to listen on")
+	cmd.Flags().BoolVar(&printSpec, "print-spec", false, "Print the OpenAPI document and exit")
+
+	return cmd
+}
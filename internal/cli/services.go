@@ -1,100 +1,103 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
 
-	"github.com/beacon/internal/db"
 	"github.com/beacon/internal/models"
-	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
-func ServicesCmd(dbURL string) *cobra.Command {
+func ServicesCmd(cfg *Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "services",
 		Short: "Manage services",
 	}
 
-	cmd.AddCommand(createServiceCmd(dbURL))
-	cmd.AddCommand(getServiceCmd(dbURL))
-	cmd.AddCommand(listServicesCmd(dbURL))
-	cmd.AddCommand(updateServiceCmd(dbURL))
-	cmd.AddCommand(deleteServiceCmd(dbURL))
+	cmd.AddCommand(createServiceCmd(cfg))
+	cmd.AddCommand(getServiceCmd(cfg))
+	cmd.AddCommand(listServicesCmd(cfg))
+	cmd.AddCommand(updateServiceCmd(cfg))
+	cmd.AddCommand(deleteServiceCmd(cfg))
 
 	return cmd
 }
 
-func createServiceCmd(dbURL string) *cobra.Command {
-	var name, description string
+// findService looks a service up by ID, slug or name.
+func findService(database store, ref string) (*models.Service, error) {
+	service, err := database.ResolveService(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service: %w", err)
+	}
+	return service, nil
+}
+
+func createServiceCmd(cfg *Config) *cobra.Command {
+	var slug, name, description string
 
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a new service",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
 			service := &models.Service{
+				Slug:        slug,
 				Name:        name,
 				Description: description,
 			}
 
+			if err := service.Validate(); err != nil {
+				return err
+			}
 			if err := database.CreateService(service); err != nil {
 				return fmt.Errorf("failed to create service: %w", err)
 			}
 
-			data, _ := json.MarshalIndent(service, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printOne(cfg, *service, serviceColumns)
 		},
 	}
 
 	cmd.Flags().StringVar(&name, "name", "", "Service name (required)")
+	cmd.Flags().StringVar(&slug, "slug", "", "Short name for referring to the service (default derived from --name)")
 	cmd.Flags().StringVar(&description, "description", "", "Service description")
 	cmd.MarkFlagRequired("name")
 
 	return cmd
 }
 
-func getServiceCmd(dbURL string) *cobra.Command {
+func getServiceCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "get [id]",
-		Short: "Get a service by ID",
-		Args:  cobra.ExactArgs(1),
+		Use:               "get [id or slug]",
+		Short:             "Get a service by ID, slug or name",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeServices(cfg),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			id, err := uuid.Parse(args[0])
+			service, err := findService(database, args[0])
 			if err != nil {
-				return fmt.Errorf("invalid UUID: %w", err)
-			}
-
-			service, err := database.GetService(id)
-			if err != nil {
-				return fmt.Errorf("failed to get service: %w", err)
+				return err
 			}
 
-			data, _ := json.MarshalIndent(service, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printOne(cfg, *service, serviceColumns)
 		},
 	}
 }
 
-func listServicesCmd(dbURL string) *cobra.Command {
+func listServicesCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "list",
 		Short: "List all services",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -105,37 +108,34 @@ func listServicesCmd(dbURL string) *cobra.Command {
 				return fmt.Errorf("failed to list services: %w", err)
 			}
 
-			data, _ := json.MarshalIndent(services, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printList(cfg, services, serviceColumns)
 		},
 	}
 }
 
-func updateServiceCmd(dbURL string) *cobra.Command {
-	var name, description string
+func updateServiceCmd(cfg *Config) *cobra.Command {
+	var slug, name, description string
 
 	cmd := &cobra.Command{
-		Use:   "update [id]",
-		Short: "Update a service",
-		Args:  cobra.ExactArgs(1),
+		Use:               "update [id or slug]",
+		Short:             "Update a service",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeServices(cfg),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			id, err := uuid.Parse(args[0])
+			service, err := findService(database, args[0])
 			if err != nil {
-				return fmt.Errorf("invalid UUID: %w", err)
+				return err
 			}
 
-			service, err := database.GetService(id)
-			if err != nil {
-				return fmt.Errorf("failed to get service: %w", err)
+			if slug != "" {
+				service.Slug = slug
 			}
-
 			if name != "" {
 				service.Name = name
 			}
@@ -143,44 +143,57 @@ func updateServiceCmd(dbURL string) *cobra.Command {
 				service.Description = description
 			}
 
+			if err := service.Validate(); err != nil {
+				return err
+			}
 			if err := database.UpdateService(service); err != nil {
 				return fmt.Errorf("failed to update service: %w", err)
 			}
 
-			fmt.Printf("Service %s updated successfully\n", id)
+			fmt.Printf("Service %s updated successfully\n", service.ID)
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&slug, "slug", "", "Service slug")
 	cmd.Flags().StringVar(&name, "name", "", "Service name")
 	cmd.Flags().StringVar(&description, "description", "", "Service description")
 
 	return cmd
 }
 
-func deleteServiceCmd(dbURL string) *cobra.Command {
+func deleteServiceCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "delete [id]",
-		Short: "Delete a service",
-		Args:  cobra.ExactArgs(1),
+		Use:               "delete [id or slug]",
+		Short:             "Delete a service",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeServices(cfg),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			id, err := uuid.Parse(args[0])
+			service, err := findService(database, args[0])
 			if err != nil {
-				return fmt.Errorf("invalid UUID: %w", err)
+				return err
 			}
 
-			if err := database.DeleteService(id); err != nil {
+			if err := database.DeleteService(service.ID); err != nil {
 				return fmt.Errorf("failed to delete service: %w", err)
 			}
 
-			fmt.Printf("Service %s deleted successfully\n", id)
+			fmt.Printf("Service %s deleted successfully\n", service.ID)
 			return nil
 		},
 	}
-}
\ No newline at end of file
+}
+
+var serviceColumns = []column[models.Service]{
+	{"ID", func(s models.Service) interface{} { return s.ID }},
+	{"SLUG", func(s models.Service) interface{} { return s.Slug }},
+	{"NAME", func(s models.Service) interface{} { return s.Name }},
+	{"DESCRIPTION", func(s models.Service) interface{} { return s.Description }},
+	{"CREATED", func(s models.Service) interface{} { return s.CreatedAt }},
+}
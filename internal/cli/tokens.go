@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+func TokensCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Manage API tokens",
+	}
+
+	cmd.AddCommand(createTokenCmd(cfg))
+	cmd.AddCommand(listTokensCmd(cfg))
+	cmd.AddCommand(revokeTokenCmd(cfg))
+
+	return cmd
+}
+
+// parseDays parses a duration that may also be given in days, like 30d.
+func parseDays(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseExpiry turns a lifetime like 90d or 12h into an expiry time. never
+// means no expiry.
+func parseExpiry(lifetime string) (*time.Time, error) {
+	if lifetime == "never" {
+		return nil, nil
+	}
+	d, err := parseDays(lifetime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expiry %q (use e.g. 90d, 12h or never)", lifetime)
+	}
+	if d <= 0 {
+		return nil, fmt.Errorf("expiry must be in the future")
+	}
+	expires := time.Now().Add(d)
+	return &expires, nil
+}
+
+func createTokenCmd(cfg *Config) *cobra.Command {
+	var (
+		name      string
+		user      string
+		scopes    []string
+		expiresIn string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Issue an API token",
+		Long: `Issue an API token and print it. The token is only shown once.
+
+Through the API, tokens are issued to you unless --user names someone else
+(admins only). With direct database access, --user is required.
+
+Scopes narrow what the token can do below its user's roles, as
+resource:read or resource:write (write includes read), where resource is *
+or one of services, endpoints, webhooks, incidents, pings, monitors, users
+and tokens.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			token := &models.APIToken{Name: name, Scopes: scopes}
+			if token.ExpiresAt, err = parseExpiry(expiresIn); err != nil {
+				return err
+			}
+			if user != "" {
+				u, err := findUser(database, user)
+				if err != nil {
+					return fmt.Errorf("failed to get user: %w", err)
+				}
+				token.UserID = u.ID
+			} else if !cfg.usingAPI() {
+				return fmt.Errorf("--user is required without --api")
+			}
+
+			if err := token.Validate(); err != nil {
+				return err
+			}
+			secret, err := database.IssueAPIToken(token)
+			if err != nil {
+				return fmt.Errorf("failed to issue token: %w", err)
+			}
+
+			fmt.Println(secret)
+			fmt.Fprintf(os.Stderr, "Issued token %s (%s); it won't be shown again\n", token.Prefix, token.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Token name, e.g. what uses it (required)")
+	cmd.Flags().StringVar(&user, "user", "", "User ID or email to issue the token to")
+	cmd.Flags().StringSliceVar(&scopes, "scopes", nil, "Comma-separated scopes, e.g. endpoints:write,*:read (default: everything the user can do)")
+	cmd.Flags().StringVar(&expiresIn, "expires-in", "90d", "Lifetime, e.g. 30d or 12h, or never")
+	cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func listTokensCmd(cfg *Config) *cobra.Command {
+	var user string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List active tokens (yours through the API, everyone's with database access)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			var userID *uuid.UUID
+			if user != "" {
+				u, err := findUser(database, user)
+				if err != nil {
+					return fmt.Errorf("failed to get user: %w", err)
+				}
+				userID = &u.ID
+			}
+
+			tokens, err := database.ListAPITokens(userID)
+			if err != nil {
+				return fmt.Errorf("failed to list tokens: %w", err)
+			}
+
+			return printList(cfg, tokens, tokenColumns)
+		},
+	}
+
+	cmd.Flags().StringVar(&user, "user", "", "Only this user's tokens (ID or email)")
+
+	return cmd
+}
+
+func revokeTokenCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke [id]",
+		Short: "Revoke an API token",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			id, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid UUID: %w", err)
+			}
+
+			if err := database.RevokeAPIToken(id); err != nil {
+				return fmt.Errorf("failed to revoke token: %w", err)
+			}
+
+			fmt.Printf("Token %s revoked successfully\n", id)
+			return nil
+		},
+	}
+}
+
+var tokenColumns = []column[models.APIToken]{
+	{"ID", func(t models.APIToken) interface{} { return t.ID }},
+	{"USER ID", func(t models.APIToken) interface{} { return t.UserID }},
+	{"NAME", func(t models.APIToken) interface{} { return t.Name }},
+	{"PREFIX", func(t models.APIToken) interface{} { return t.Prefix }},
+	{"SCOPES", func(t models.APIToken) interface{} { return t.Scopes }},
+	{"STATUS", func(t models.APIToken) interface{} {
+		switch {
+		case t.RevokedAt != nil:
+			return status("revoked")
+		case t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()):
+			return status("expired")
+		}
+		return status("active")
+	}},
+	{"EXPIRES", func(t models.APIToken) interface{} { return t.ExpiresAt }},
+	{"LAST USED", func(t models.APIToken) interface{} { return t.LastUsedAt }},
+	{"CREATED", func(t models.APIToken) interface{} { return t.CreatedAt }},
+}
@@ -0,0 +1,866 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// Dashboard statuses, from worst to best. paused endpoints are disabled;
+// pending ones haven't been pinged yet.
+var topStatuses = []status{"down", "failing", "maintenance", "pending", "up", "paused"}
+
+const (
+	// sparkPings is how many recent pings the latency sparkline shows.
+	sparkPings = 30
+	// uptimeTTL is how long an endpoint's 24-hour uptime is reused before
+	// it's read again; it moves slowly and costs a query per endpoint.
+	uptimeTTL = time.Minute
+	// topWorkers is how many endpoints are read at once.
+	topWorkers = 8
+)
+
+func TopCmd(cfg *Config) *cobra.Command {
+	var (
+		interval  time.Duration
+		serviceID string
+		tags      []string
+		statuses  []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Show a live dashboard of every endpoint",
+		Long: `Show every endpoint's status, last response time, recent latency, uptime
+over the last 24 hours and open incident, grouped by service and refreshed
+every --interval.
+
+Statuses are down (open incident), failing (the last ping failed),
+maintenance, pending (no pings yet), up and paused (disabled).
+
+Keys: ↑/↓ or j/k select, enter shows the endpoint's recent pings and esc
+goes back, s cycles through the statuses to show, r refreshes and q quits.
+If stdin isn't a terminal, as on a wall screen, top just refreshes; if
+stdout isn't one, it prints the dashboard once.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval < time.Second {
+				return fmt.Errorf("interval must be at least 1s")
+			}
+			for _, s := range statuses {
+				if !containsStatus(topStatuses, status(s)) {
+					return fmt.Errorf("invalid status %q (use %s)", s, joinStatuses(topStatuses))
+				}
+			}
+
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			d := &dashboard{
+				database: database,
+				tags:     tags,
+				interval: interval,
+				color:    colorEnabled(os.Stdout),
+				uptimes:  map[uuid.UUID]uptime{},
+			}
+			for _, s := range statuses {
+				d.statuses = append(d.statuses, status(s))
+			}
+			if serviceID != "" {
+				service, err := findService(database, serviceID)
+				if err != nil {
+					return err
+				}
+				d.serviceID = &service.ID
+			}
+
+			if !isTerminal(os.Stdout) {
+				snapshot := d.load(uuid.Nil)
+				if snapshot.err != nil {
+					return snapshot.err
+				}
+				d.update(snapshot)
+				width, _ := terminalSize()
+				fmt.Print(strings.Join(d.frame(width, 0), "\n") + "\n")
+				return nil
+			}
+			return d.run(isTerminal(os.Stdin))
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "How often to refresh")
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Only show this service, by ID or slug")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Only show endpoints with one of these tags")
+	cmd.Flags().StringSliceVar(&statuses, "status", nil, "Only show endpoints with these statuses: "+joinStatuses(topStatuses))
+	completeServiceFlag(cfg, cmd, "service-id")
+
+	return cmd
+}
+
+// dashboard is the state of beacon top.
+type dashboard struct {
+	database  store
+	serviceID *uuid.UUID
+	tags      []string
+	statuses  []status
+	interval  time.Duration
+	color     bool
+
+	// uptimes is only used by load, which runs one at a time
+	uptimes map[uuid.UUID]uptime
+
+	rows     []topRow
+	loadedAt time.Time
+	err      error
+
+	selected uuid.UUID // endpoint under the cursor
+	detail   *topRow   // endpoint being drilled into
+	pings    []models.Ping
+}
+
+// topRow is one endpoint on the dashboard.
+type topRow struct {
+	service  models.Service
+	endpoint models.ServiceEndpoint
+	status   status
+	recent   []models.Ping // newest first
+	uptime   *float64      // percent, nil if there's no data yet
+	incident *models.Incident
+}
+
+// uptime is an endpoint's 24-hour uptime as of readAt.
+type uptime struct {
+	percent *float64
+	readAt  time.Time
+}
+
+// snapshot is what one load read.
+type snapshot struct {
+	rows  []topRow
+	pings []models.Ping // the drilled-into endpoint's
+	at    time.Time
+	err   error
+}
+
+// run draws the dashboard until it's quit or interrupted, refreshing it in
+// the background. If interactive, keys are read from the terminal as
+// they're pressed.
+func (d *dashboard) run(interactive bool) error {
+	var keys chan string
+	if interactive {
+		restore, err := rawTerminal()
+		if err != nil {
+			return fmt.Errorf("failed to set up the terminal: %w", err)
+		}
+		defer restore()
+		keys = make(chan string)
+		go readKeys(keys)
+	}
+	// Switch to the alternate screen and hide the cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	loads := make(chan snapshot)
+	loading, again := false, false
+	reload := func() {
+		if loading {
+			again = true
+			return
+		}
+		loading = true
+		var detail uuid.UUID
+		if d.detail != nil {
+			detail = d.detail.endpoint.ID
+		}
+		go func() { loads <- d.load(detail) }()
+	}
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	reload()
+	d.draw()
+	for {
+		select {
+		case <-ticker.C:
+			reload()
+		case s := <-loads:
+			loading = false
+			d.update(s)
+			if again {
+				again = false
+				reload()
+			}
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			quit, refresh := d.handle(key)
+			if quit {
+				return nil
+			}
+			if refresh {
+				reload()
+			}
+		case <-signals:
+			return nil
+		}
+		d.draw()
+	}
+}
+
+// handle acts on a key, reporting whether to quit and whether the data
+// needs reading again.
+func (d *dashboard) handle(key string) (quit, refresh bool) {
+	switch key {
+	case "q", "ctrl-c":
+		return true, false
+	case "r":
+		return false, true
+	}
+
+	if d.detail != nil {
+		switch key {
+		case "esc", "backspace", "left", "h":
+			d.detail, d.pings = nil, nil
+		}
+		return false, false
+	}
+
+	visible := d.visible()
+	i := d.cursor(visible)
+	switch key {
+	case "up", "k":
+		i--
+	case "down", "j":
+		i++
+	case "pgup":
+		i -= 10
+	case "pgdn":
+		i += 10
+	case "home", "g":
+		i = 0
+	case "end", "G":
+		i = len(visible) - 1
+	case "s":
+		d.cycleStatus()
+		return false, false
+	case "enter", "right", "l":
+		if i >= 0 && i < len(visible) {
+			row := visible[i]
+			d.detail = &row
+			return false, true
+		}
+	}
+	if len(visible) > 0 {
+		i = max(0, min(i, len(visible)-1))
+		d.selected = visible[i].endpoint.ID
+	}
+	return false, false
+}
+
+// cycleStatus shows every endpoint, then only those with each status in
+// turn.
+func (d *dashboard) cycleStatus() {
+	if len(d.statuses) != 1 {
+		d.statuses = []status{topStatuses[0]}
+		return
+	}
+	for i, s := range topStatuses {
+		if s == d.statuses[0] {
+			if i+1 < len(topStatuses) {
+				d.statuses = []status{topStatuses[i+1]}
+			} else {
+				d.statuses = nil
+			}
+			return
+		}
+	}
+	d.statuses = nil
+}
+
+// update takes in what a load read. After an error the last rows are kept,
+// so a blip doesn't blank the screen.
+func (d *dashboard) update(s snapshot) {
+	d.err = s.err
+	if s.err != nil {
+		return
+	}
+	d.rows, d.loadedAt = s.rows, s.at
+	if d.detail != nil {
+		d.pings = s.pings
+		for _, row := range s.rows {
+			if row.endpoint.ID == d.detail.endpoint.ID {
+				row := row
+				d.detail = &row
+			}
+		}
+	}
+}
+
+// load reads every endpoint in view along with its recent pings, uptime and
+// open incident, and the recent pings of detail if it's set.
+func (d *dashboard) load(detail uuid.UUID) snapshot {
+	now := time.Now()
+	s := snapshot{at: now}
+
+	services, err := d.database.ListServices()
+	if err != nil {
+		s.err = fmt.Errorf("failed to list services: %w", err)
+		return s
+	}
+	endpoints, err := d.database.ListEndpoints(d.serviceID)
+	if err != nil {
+		s.err = fmt.Errorf("failed to list endpoints: %w", err)
+		return s
+	}
+	incidents, err := d.database.ListIncidents(nil, "open")
+	if err != nil {
+		s.err = fmt.Errorf("failed to list incidents: %w", err)
+		return s
+	}
+	windows, err := d.database.ListMaintenanceWindows(d.serviceID)
+	if err != nil {
+		s.err = fmt.Errorf("failed to list maintenance windows: %w", err)
+		return s
+	}
+
+	byID := make(map[uuid.UUID]models.Service, len(services))
+	for _, service := range services {
+		byID[service.ID] = service
+	}
+	open := map[uuid.UUID]*models.Incident{}
+	for i := range incidents {
+		open[incidents[i].EndpointID] = &incidents[i]
+	}
+	inMaintenance := map[uuid.UUID]bool{}
+	for _, w := range windows {
+		if !w.StartsAt.After(now) && w.EndsAt.After(now) {
+			inMaintenance[w.ServiceID] = true
+		}
+	}
+
+	for _, endpoint := range endpoints {
+		service, ok := byID[endpoint.ServiceID]
+		if !ok || !d.tagged(endpoint) {
+			continue
+		}
+		s.rows = append(s.rows, topRow{service: service, endpoint: endpoint, incident: open[endpoint.ID]})
+	}
+	sort.SliceStable(s.rows, func(i, j int) bool {
+		a, b := s.rows[i], s.rows[j]
+		if a.service.Name != b.service.Name {
+			return a.service.Name < b.service.Name
+		}
+		return a.endpoint.Name < b.endpoint.Name
+	})
+
+	// Read each endpoint's pings and uptime, a few at a time
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		failure error
+		next    = make(chan int)
+	)
+	for w := 0; w < topWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				row := &s.rows[i]
+				recent, err := d.database.ListPings(row.endpoint.ID, "", sparkPings)
+				if err == nil {
+					row.recent = recent
+					row.uptime, err = d.uptime(row.endpoint.ID, &mu, now)
+				}
+				if err != nil {
+					mu.Lock()
+					failure = err
+					mu.Unlock()
+				}
+				row.status = rowStatus(*row, inMaintenance[row.endpoint.ServiceID])
+			}
+		}()
+	}
+	for i := range s.rows {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if failure != nil {
+		s.err = failure
+		return s
+	}
+
+	if detail != uuid.Nil {
+		if s.pings, err = d.database.ListPings(detail, "", 100); err != nil {
+			s.err = fmt.Errorf("failed to list pings: %w", err)
+		}
+	}
+	return s
+}
+
+// uptime returns an endpoint's uptime over the last 24 hours from its ping
+// windows, reusing what was read within uptimeTTL. mu guards the cache.
+func (d *dashboard) uptime(endpointID uuid.UUID, mu *sync.Mutex, now time.Time) (*float64, error) {
+	mu.Lock()
+	cached, ok := d.uptimes[endpointID]
+	mu.Unlock()
+	if ok && now.Sub(cached.readAt) < uptimeTTL {
+		return cached.percent, nil
+	}
+
+	windows, err := d.database.ListPingWindowsByTimeRange(endpointID, "", now.Add(-24*time.Hour), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ping windows: %w", err)
+	}
+	var total, success int
+	for _, w := range windows {
+		total += w.TotalPings
+		success += w.SuccessPings
+	}
+	var percent *float64
+	if total > 0 {
+		p := 100 * float64(success) / float64(total)
+		percent = &p
+	}
+
+	mu.Lock()
+	d.uptimes[endpointID] = uptime{percent: percent, readAt: now}
+	mu.Unlock()
+	return percent, nil
+}
+
+// tagged reports whether an endpoint has one of the tags being shown.
+func (d *dashboard) tagged(endpoint models.ServiceEndpoint) bool {
+	if len(d.tags) == 0 {
+		return true
+	}
+	for _, tag := range d.tags {
+		if endpoint.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// rowStatus works out an endpoint's status from its open incident and last
+// ping.
+func rowStatus(row topRow, inMaintenance bool) status {
+	switch {
+	case !row.endpoint.Enabled:
+		return "paused"
+	case inMaintenance:
+		return "maintenance"
+	case row.incident != nil:
+		return "down"
+	case len(row.recent) == 0:
+		return "pending"
+	case !row.recent[0].Success:
+		return "failing"
+	}
+	return "up"
+}
+
+// visible returns the rows with the statuses being shown.
+func (d *dashboard) visible() []topRow {
+	if len(d.statuses) == 0 {
+		return d.rows
+	}
+	var rows []topRow
+	for _, row := range d.rows {
+		if containsStatus(d.statuses, row.status) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// cursor returns the index of the selected row, or the first if it isn't
+// visible.
+func (d *dashboard) cursor(rows []topRow) int {
+	for i, row := range rows {
+		if row.endpoint.ID == d.selected {
+			return i
+		}
+	}
+	return 0
+}
+
+// draw redraws the screen in place, clearing what's left of each line
+// rather than the whole screen so it doesn't flicker.
+func (d *dashboard) draw() {
+	width, height := terminalSize()
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range d.frame(width, height) {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line + "\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	os.Stdout.WriteString(b.String())
+}
+
+// frame renders the screen as lines at most width wide. A height of 0
+// means as many lines as it takes.
+func (d *dashboard) frame(width, height int) []string {
+	var lines []string
+	if d.detail != nil {
+		lines = d.detailFrame(width)
+	} else {
+		lines = d.listFrame(width, height)
+	}
+	if height > 0 && len(lines) > height {
+		lines = lines[:height]
+	}
+	return lines
+}
+
+// listFrame renders the endpoints grouped by service, scrolled to keep the
+// selected one in view.
+func (d *dashboard) listFrame(width, height int) []string {
+	visible := d.visible()
+	counts := map[status]int{}
+	for _, row := range d.rows {
+		counts[row.status]++
+	}
+
+	summary := fmt.Sprintf("beacon top - %d endpoints", len(d.rows))
+	for _, s := range topStatuses {
+		if counts[s] > 0 {
+			summary += fmt.Sprintf("  %s %d", d.paint(statusColors[s], string(s)), counts[s])
+		}
+	}
+	showing := "all"
+	if len(d.statuses) > 0 {
+		showing = joinStatuses(d.statuses)
+	}
+	if len(d.tags) > 0 {
+		showing += " tagged " + strings.Join(d.tags, " or ")
+	}
+	help := fmt.Sprintf("showing %s - ↑/↓ select  enter pings  s status  r refresh  q quit", showing)
+	latency := ""
+	if sparkFits(width) {
+		latency = fmt.Sprintf("%-*s  ", sparkPings, "LATENCY")
+	}
+	header := []string{
+		summary,
+		d.paint("90", truncate(help, width)),
+		d.statusLine(),
+		d.paint("1", fitLine(fmt.Sprintf("  %-11s %-24s %7s  %s%7s  %s",
+			"STATUS", "ENDPOINT", "LAST", latency, "UP 24H", "INCIDENT"), width)),
+	}
+
+	var body []string
+	selectedLine := -1
+	cursor := d.cursor(visible)
+	var service uuid.UUID
+	for i, row := range visible {
+		if i == 0 || row.service.ID != service {
+			service = row.service.ID
+			body = append(body, d.paint("1", fitLine(row.service.Name+" ("+row.service.Slug+")", width)))
+		}
+		if i == cursor {
+			selectedLine = len(body)
+		}
+		body = append(body, d.rowLine(row, i == cursor, width))
+	}
+	if len(visible) == 0 {
+		body = append(body, "  No endpoints to show")
+	}
+
+	// Scroll so the selected row is on screen
+	if room := height - len(header); height > 0 && len(body) > room && room > 0 {
+		offset := 0
+		if selectedLine >= room {
+			offset = selectedLine - room + 1
+		}
+		body = body[offset:min(len(body), offset+room)]
+	}
+	return append(header, body...)
+}
+
+// statusLine says when the data was read, or why it couldn't be.
+func (d *dashboard) statusLine() string {
+	if d.err != nil {
+		return d.paint("31", "Error: "+d.err.Error())
+	}
+	if d.loadedAt.IsZero() {
+		return "Loading…"
+	}
+	return d.paint("90", fmt.Sprintf("updated %s, every %s", d.loadedAt.Format("15:04:05"), d.interval))
+}
+
+// rowLine renders one endpoint. Columns are padded before they're colored,
+// so the escape codes don't upset the alignment.
+func (d *dashboard) rowLine(row topRow, selected bool, width int) string {
+	marker := "  "
+	if selected {
+		marker = "> "
+	}
+	last := "-"
+	if len(row.recent) > 0 {
+		last = fmt.Sprintf("%dms", row.recent[0].ResponseMs)
+	}
+	up := "-"
+	if row.uptime != nil {
+		up = fmt.Sprintf("%.2f%%", *row.uptime)
+	}
+	incident := ""
+	if row.incident != nil {
+		incident = fmt.Sprintf("%s: %s", relativeTime(row.incident.StartedAt, time.Now()), row.incident.Message)
+	}
+
+	line := marker +
+		d.paint(statusColors[row.status], pad(string(row.status), 11)) + " " +
+		pad(row.endpoint.Name, 24) + " " +
+		fmt.Sprintf("%7s", truncate(last, 7)) + "  "
+	rest := width - fixedWidth
+	if sparkFits(width) {
+		line += d.sparkline(row.recent) + "  "
+		rest -= sparkPings + 2
+	}
+	line += fmt.Sprintf("%7s", up)
+	if rest > 0 && incident != "" {
+		line += "  " + d.paint("31", truncate(incident, rest))
+	}
+	if selected && d.color {
+		line = "\x1b[7m" + line + "\x1b[27m"
+	}
+	return line
+}
+
+// fixedWidth is how wide the columns of a row are before the incident,
+// leaving out the sparkline: 2+11+1+24+1+7+2+7+2.
+const fixedWidth = 57
+
+// sparkFits reports whether there's room for the sparkline, and for some
+// of the incident after it.
+func sparkFits(width int) bool {
+	return width >= fixedWidth+sparkPings+2+20
+}
+
+// sparkRunes are the bars of a sparkline, from shortest to tallest.
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the response times of pings, oldest on the left, scaled
+// between the fastest and slowest. Failed pings are red. It's padded to
+// sparkPings wide.
+func (d *dashboard) sparkline(pings []models.Ping) string {
+	lo, hi := 0, 0
+	for i, p := range pings {
+		if i == 0 || p.ResponseMs < lo {
+			lo = p.ResponseMs
+		}
+		hi = max(hi, p.ResponseMs)
+	}
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", sparkPings-len(pings)))
+	for i := len(pings) - 1; i >= 0; i-- {
+		level := 0
+		if hi > lo {
+			level = (pings[i].ResponseMs - lo) * (len(sparkRunes) - 1) / (hi - lo)
+		}
+		bar := string(sparkRunes[level])
+		if !pings[i].Success {
+			bar = d.paint("31", bar)
+		}
+		b.WriteString(bar)
+	}
+	return b.String()
+}
+
+// detailFrame renders an endpoint and its recent pings.
+func (d *dashboard) detailFrame(width int) []string {
+	row := d.detail
+	e := row.endpoint
+	checkType := e.CheckType
+	if checkType == "" {
+		checkType = models.CheckHTTP
+	}
+
+	lines := []string{
+		d.paint("1", fitLine(fmt.Sprintf("%s/%s - %s", row.service.Slug, e.Slug, e.Name), width)) + "  " +
+			d.paint(statusColors[row.status], string(row.status)),
+		d.paint("90", "esc back  r refresh  q quit"),
+		d.statusLine(),
+		fitLine(fmt.Sprintf("%s %s, every %s", checkType, e.URL, seconds(e.IntervalSec)), width),
+	}
+	var labels []string
+	if len(e.Regions) > 0 {
+		labels = append(labels, "regions: "+strings.Join(e.Regions, ", "))
+	}
+	if len(e.Tags) > 0 {
+		labels = append(labels, "tags: "+strings.Join(e.Tags, ", "))
+	}
+	if len(labels) > 0 {
+		lines = append(lines, fitLine(strings.Join(labels, "  "), width))
+	}
+	if row.incident != nil {
+		lines = append(lines, d.paint("31", fitLine(fmt.Sprintf("Incident open since %s: %s",
+			row.incident.StartedAt.Local().Format("2006-01-02 15:04:05"), row.incident.Message), width)))
+	}
+	lines = append(lines, "", d.paint("1", fitLine(fmt.Sprintf("%-10s %-12s %-6s %5s %9s  %s",
+		"TIME", "REGION", "STATUS", "CODE", "RESPONSE", "ERROR"), width)))
+	if len(d.pings) == 0 {
+		lines = append(lines, "No pings yet")
+	}
+
+	now := time.Now()
+	for _, p := range d.pings {
+		result, code := status("ok"), ""
+		if !p.Success {
+			result = "fail"
+		}
+		if p.StatusCode != 0 {
+			code = strconv.Itoa(p.StatusCode)
+		}
+		line := fmt.Sprintf("%-10s %-12s ", relativeTime(p.CreatedAt, now), pad(p.Region, 12)) +
+			d.paint(statusColors[result], pad(string(result), 6)) +
+			fmt.Sprintf(" %5s %9s", code, fmt.Sprintf("%dms", p.ResponseMs))
+		if p.Error != nil {
+			if rest := width - 48; rest > 0 {
+				line += "  " + truncate(strings.Join(strings.Fields(*p.Error), " "), rest)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// paint colors s with an ANSI code, or makes it bold for code 1, if color
+// is on. Only what was set is reset, so the selected row stays highlighted.
+func (d *dashboard) paint(code, s string) string {
+	if !d.color || code == "" {
+		return s
+	}
+	reset := "39"
+	if code == "1" {
+		reset = "22"
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[" + reset + "m"
+}
+
+// pad truncates or pads s to exactly width runes.
+func pad(s string, width int) string {
+	s = truncate(s, width)
+	return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+}
+
+// fitLine truncates a line to the terminal's width, if it's known.
+func fitLine(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return truncate(s, width)
+}
+
+func containsStatus(statuses []status, s status) bool {
+	for _, t := range statuses {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+func joinStatuses(statuses []status) string {
+	names := make([]string, len(statuses))
+	for i, s := range statuses {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+// readKeys sends the keys pressed to keys, naming the ones that send escape
+// sequences, until stdin closes.
+func readKeys(keys chan<- string) {
+	defer close(keys)
+	names := map[string]string{
+		"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+		"\x1b[5~": "pgup", "\x1b[6~": "pgdn", "\x1b[H": "home", "\x1b[F": "end",
+		"\x1b": "esc", "\r": "enter", "\n": "enter", "\x7f": "backspace", "\b": "backspace", "\x03": "ctrl-c",
+	}
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		key := string(buf[:n])
+		if name, ok := names[key]; ok {
+			key = name
+		} else if strings.HasPrefix(key, "\x1b") {
+			continue
+		}
+		keys <- key
+	}
+}
+
+// rawTerminal turns off line buffering and echo on the terminal, so keys
+// can be read as they're pressed, and returns a function that restores it.
+// It uses stty rather than platform-specific calls.
+func rawTerminal() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() { stty(strings.TrimSpace(state)) }, nil
+}
+
+// terminalSize returns the terminal's width and height, falling back to
+// $COLUMNS and $LINES and then 80x24. It asks about stdout, which is the
+// terminal even when stdin isn't.
+func terminalSize() (width, height int) {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdout
+	if out, err := cmd.Output(); err == nil {
+		if _, err := fmt.Sscan(string(out), &height, &width); err == nil && width > 0 && height > 0 {
+			return width, height
+		}
+	}
+	width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	height, _ = strconv.Atoi(os.Getenv("LINES"))
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+	return width, height
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,265 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+func UsersCmd(cfg *Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Manage users and their roles",
+		Long: `Manage users and their roles. Roles are viewer (read everything), editor
+(also change services, endpoints, webhooks, incidents and monitors) and admin
+(also manage users). A user's own role applies to every service; grants add a
+role on a single service.`,
+	}
+
+	cmd.AddCommand(createUserCmd(cfg))
+	cmd.AddCommand(listUsersCmd(cfg))
+	cmd.AddCommand(updateUserCmd(cfg))
+	cmd.AddCommand(deleteUserCmd(cfg))
+	cmd.AddCommand(grantCmd(cfg))
+	cmd.AddCommand(ungrantCmd(cfg))
+
+	return cmd
+}
+
+// findUser looks a user up by ID or email.
+func findUser(database store, ref string) (*models.User, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return database.GetUser(id)
+	}
+	return database.GetUserByEmail(ref)
+}
+
+func createUserCmd(cfg *Config) *cobra.Command {
+	var email, name, role string
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			user := &models.User{
+				Email: email,
+				Name:  name,
+				Role:  role,
+			}
+
+			if err := user.Validate(); err != nil {
+				return err
+			}
+			if err := database.CreateUser(user); err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+
+			return printOne(cfg, *user, userColumns)
+		},
+	}
+
+	cmd.Flags().StringVar(&email, "email", "", "Email address (required)")
+	cmd.Flags().StringVar(&name, "name", "", "Display name")
+	cmd.Flags().StringVar(&role, "role", "", "Role on every service: viewer, editor or admin (omit to only use grants)")
+	cmd.MarkFlagRequired("email")
+
+	return cmd
+}
+
+func listUsersCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List users and their grants",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			users, err := database.ListUsers()
+			if err != nil {
+				return fmt.Errorf("failed to list users: %w", err)
+			}
+
+			return printList(cfg, users, userColumns)
+		},
+	}
+}
+
+func updateUserCmd(cfg *Config) *cobra.Command {
+	var email, name, role string
+
+	cmd := &cobra.Command{
+		Use:   "update [id or email]",
+		Short: "Update a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			user, err := findUser(database, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+
+			if email != "" {
+				user.Email = email
+			}
+			if name != "" {
+				user.Name = name
+			}
+			if cmd.Flags().Changed("role") {
+				user.Role = role
+			}
+
+			if err := user.Validate(); err != nil {
+				return err
+			}
+			if err := database.UpdateUser(user); err != nil {
+				return fmt.Errorf("failed to update user: %w", err)
+			}
+
+			fmt.Printf("User %s updated successfully\n", user.Email)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&email, "email", "", "Email address")
+	cmd.Flags().StringVar(&name, "name", "", "Display name")
+	cmd.Flags().StringVar(&role, "role", "", `Role on every service (viewer, editor, admin, or "" for none)`)
+
+	return cmd
+}
+
+func deleteUserCmd(cfg *Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [id or email]",
+		Short: "Delete a user and revoke their tokens",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			user, err := findUser(database, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+			if err := database.DeleteUser(user.ID); err != nil {
+				return fmt.Errorf("failed to delete user: %w", err)
+			}
+
+			fmt.Printf("User %s deleted successfully\n", user.Email)
+			return nil
+		},
+	}
+}
+
+func grantCmd(cfg *Config) *cobra.Command {
+	var serviceID, role string
+
+	cmd := &cobra.Command{
+		Use:   "grant [id or email]",
+		Short: "Give a user a role on a service",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			service, err := findService(database, serviceID)
+			if err != nil {
+				return err
+			}
+			svcID := service.ID
+			user, err := findUser(database, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+
+			grant := &models.ServiceGrant{UserID: user.ID, ServiceID: svcID, Role: role}
+			if err := grant.Validate(); err != nil {
+				return err
+			}
+			if err := database.SetServiceGrant(grant); err != nil {
+				return fmt.Errorf("failed to grant role: %w", err)
+			}
+
+			fmt.Printf("%s is now %s on service %s\n", user.Email, role, service.Slug)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Service ID or slug (required)")
+	cmd.Flags().StringVar(&role, "role", "", "Role: viewer, editor or admin (required)")
+	cmd.MarkFlagRequired("service-id")
+	cmd.MarkFlagRequired("role")
+	completeServiceFlag(cfg, cmd, "service-id")
+
+	return cmd
+}
+
+func ungrantCmd(cfg *Config) *cobra.Command {
+	var serviceID string
+
+	cmd := &cobra.Command{
+		Use:   "ungrant [id or email]",
+		Short: "Remove a user's role on a service",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database, err := cfg.openStore()
+			if err != nil {
+				return err
+			}
+			defer database.Close()
+
+			service, err := findService(database, serviceID)
+			if err != nil {
+				return err
+			}
+			svcID := service.ID
+			user, err := findUser(database, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+			if err := database.DeleteServiceGrant(user.ID, svcID); err != nil {
+				return fmt.Errorf("failed to remove grant: %w", err)
+			}
+
+			fmt.Printf("Removed %s's grant on service %s\n", user.Email, service.Slug)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Service ID or slug (required)")
+	cmd.MarkFlagRequired("service-id")
+	completeServiceFlag(cfg, cmd, "service-id")
+
+	return cmd
+}
+
+var userColumns = []column[models.User]{
+	{"ID", func(u models.User) interface{} { return u.ID }},
+	{"EMAIL", func(u models.User) interface{} { return u.Email }},
+	{"NAME", func(u models.User) interface{} { return u.Name }},
+	{"ROLE", func(u models.User) interface{} { return u.Role }},
+	{"ORGANIZATION ID", func(u models.User) interface{} { return u.OrganizationID }},
+	{"GRANTS", func(u models.User) interface{} { return len(u.Grants) }},
+	{"CREATED", func(u models.User) interface{} { return u.CreatedAt }},
+}
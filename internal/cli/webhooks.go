@@ -1,37 +1,36 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
-	"github.com/beacon/internal/db"
 	"github.com/beacon/internal/models"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
-func WebhooksCmd(dbURL string) *cobra.Command {
+func WebhooksCmd(cfg *Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "webhooks",
 		Short: "Manage webhooks",
 	}
 
-	cmd.AddCommand(createWebhookCmd(dbURL))
-	cmd.AddCommand(getWebhookCmd(dbURL))
-	cmd.AddCommand(listWebhooksCmd(dbURL))
-	cmd.AddCommand(updateWebhookCmd(dbURL))
-	cmd.AddCommand(deleteWebhookCmd(dbURL))
+	cmd.AddCommand(createWebhookCmd(cfg))
+	cmd.AddCommand(getWebhookCmd(cfg))
+	cmd.AddCommand(listWebhooksCmd(cfg))
+	cmd.AddCommand(updateWebhookCmd(cfg))
+	cmd.AddCommand(deleteWebhookCmd(cfg))
 
 	return cmd
 }
 
-func createWebhookCmd(dbURL string) *cobra.Command {
+func createWebhookCmd(cfg *Config) *cobra.Command {
 	var (
 		serviceID string
 		name      string
 		url       string
 		events    string
+		headers   []string
 		enabled   bool
 	)
 
@@ -39,15 +38,15 @@ func createWebhookCmd(dbURL string) *cobra.Command {
 		Use:   "create",
 		Short: "Create a new webhook",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
 			defer database.Close()
 
-			svcID, err := uuid.Parse(serviceID)
+			service, err := findService(database, serviceID)
 			if err != nil {
-				return fmt.Errorf("invalid service UUID: %w", err)
+				return err
 			}
 
 			eventList := strings.Split(events, ",")
@@ -56,44 +55,51 @@ func createWebhookCmd(dbURL string) *cobra.Command {
 			}
 
 			webhook := &models.Webhook{
-				ServiceID: svcID,
+				ServiceID: service.ID,
 				Name:      name,
 				URL:       url,
 				Events:    eventList,
 				Enabled:   enabled,
-				Headers:   make(models.JSONB),
 			}
 
+			webhook.Headers, err = applyHeaders(nil, headers)
+			if err != nil {
+				return err
+			}
+
+			if err := webhook.Validate(); err != nil {
+				return err
+			}
 			if err := database.CreateWebhook(webhook); err != nil {
 				return fmt.Errorf("failed to create webhook: %w", err)
 			}
 
-			data, _ := json.MarshalIndent(webhook, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printOne(cfg, webhook.Redacted(), webhookColumns)
 		},
 	}
 
-	cmd.Flags().StringVar(&serviceID, "service-id", "", "Service ID (required)")
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Service ID or slug (required)")
 	cmd.Flags().StringVar(&name, "name", "", "Webhook name (required)")
 	cmd.Flags().StringVar(&url, "url", "", "Webhook URL (required)")
 	cmd.Flags().StringVar(&events, "events", "incident_start,incident_resolved", "Comma-separated list of events")
+	cmd.Flags().StringArrayVar(&headers, "header", nil, `Header as "Name: value" (repeatable, values may use {{secret "name"}})`)
 	cmd.Flags().BoolVar(&enabled, "enabled", true, "Enable webhook")
 	
 	cmd.MarkFlagRequired("service-id")
 	cmd.MarkFlagRequired("name")
 	cmd.MarkFlagRequired("url")
+	completeServiceFlag(cfg, cmd, "service-id")
 
 	return cmd
 }
 
-func getWebhookCmd(dbURL string) *cobra.Command {
+func getWebhookCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "get [id]",
 		Short: "Get a webhook by ID",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -109,21 +115,19 @@ func getWebhookCmd(dbURL string) *cobra.Command {
 				return fmt.Errorf("failed to get webhook: %w", err)
 			}
 
-			data, _ := json.MarshalIndent(webhook, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printOne(cfg, webhook.Redacted(), webhookColumns)
 		},
 	}
 }
 
-func listWebhooksCmd(dbURL string) *cobra.Command {
+func listWebhooksCmd(cfg *Config) *cobra.Command {
 	var serviceID string
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List webhooks",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -131,34 +135,37 @@ func listWebhooksCmd(dbURL string) *cobra.Command {
 
 			var svcID *uuid.UUID
 			if serviceID != "" {
-				id, err := uuid.Parse(serviceID)
+				service, err := findService(database, serviceID)
 				if err != nil {
-					return fmt.Errorf("invalid service UUID: %w", err)
+					return err
 				}
-				svcID = &id
+				svcID = &service.ID
 			}
 
 			webhooks, err := database.ListWebhooks(svcID)
 			if err != nil {
 				return fmt.Errorf("failed to list webhooks: %w", err)
 			}
+			for i := range webhooks {
+				webhooks[i] = webhooks[i].Redacted()
+			}
 
-			data, _ := json.MarshalIndent(webhooks, "", "  ")
-			fmt.Println(string(data))
-			return nil
+			return printList(cfg, webhooks, webhookColumns)
 		},
 	}
 
-	cmd.Flags().StringVar(&serviceID, "service-id", "", "Filter by service ID")
+	cmd.Flags().StringVar(&serviceID, "service-id", "", "Filter by service ID or slug")
+	completeServiceFlag(cfg, cmd, "service-id")
 
 	return cmd
 }
 
-func updateWebhookCmd(dbURL string) *cobra.Command {
+func updateWebhookCmd(cfg *Config) *cobra.Command {
 	var (
 		name    string
 		url     string
 		events  string
+		headers []string
 		enabled *bool
 	)
 
@@ -167,7 +174,7 @@ func updateWebhookCmd(dbURL string) *cobra.Command {
 		Short: "Update a webhook",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -196,10 +203,19 @@ func updateWebhookCmd(dbURL string) *cobra.Command {
 				}
 				webhook.Events = eventList
 			}
+			if cmd.Flags().Changed("header") {
+				webhook.Headers, err = applyHeaders(webhook.Headers, headers)
+				if err != nil {
+					return err
+				}
+			}
 			if enabled != nil {
 				webhook.Enabled = *enabled
 			}
 
+			if err := webhook.Validate(); err != nil {
+				return err
+			}
 			if err := database.UpdateWebhook(webhook); err != nil {
 				return fmt.Errorf("failed to update webhook: %w", err)
 			}
@@ -212,6 +228,7 @@ func updateWebhookCmd(dbURL string) *cobra.Command {
 	cmd.Flags().StringVar(&name, "name", "", "Webhook name")
 	cmd.Flags().StringVar(&url, "url", "", "Webhook URL")
 	cmd.Flags().StringVar(&events, "events", "", "Comma-separated list of events")
+	cmd.Flags().StringArrayVar(&headers, "header", nil, `Header as "Name: value" (repeatable, empty value removes)`)
 	
 	enabledFlag := false
 	cmd.Flags().BoolVar(&enabledFlag, "enabled", false, "Enable/disable webhook")
@@ -225,13 +242,13 @@ func updateWebhookCmd(dbURL string) *cobra.Command {
 	return cmd
 }
 
-func deleteWebhookCmd(dbURL string) *cobra.Command {
+func deleteWebhookCmd(cfg *Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "delete [id]",
 		Short: "Delete a webhook",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			database, err := db.NewDB(dbURL)
+			database, err := cfg.openStore()
 			if err != nil {
 				return err
 			}
@@ -250,4 +267,13 @@ func deleteWebhookCmd(dbURL string) *cobra.Command {
 			return nil
 		},
 	}
-}
\ No newline at end of file
+}
+
+var webhookColumns = []column[models.Webhook]{
+	{"ID", func(w models.Webhook) interface{} { return w.ID }},
+	{"NAME", func(w models.Webhook) interface{} { return w.Name }},
+	{"URL", func(w models.Webhook) interface{} { return w.URL }},
+	{"EVENTS", func(w models.Webhook) interface{} { return w.Events }},
+	{"STATUS", func(w models.Webhook) interface{} { return enabledStatus(w.Enabled) }},
+	{"CREATED", func(w models.Webhook) interface{} { return w.CreatedAt }},
+}
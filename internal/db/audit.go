@@ -0,0 +1,181 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Changes made through a view with an actor are recorded in the audit log.
+// The worker's own bookkeeping, like opening and resolving incidents, has
+// no actor and isn't recorded.
+
+// AsActor returns a view of the database that records its changes as made
+// by actor. It shares the connection pool and keeps the view's tenant.
+func (db *DB) AsActor(actor string) *DB {
+	return &DB{DB: db.DB, tenant: db.tenant, actor: actor}
+}
+
+// owner finds the organization an audited entity belongs to: query selects
+// it given id as $2, so it can be inlined into the insert.
+type owner struct {
+	query string
+	id    uuid.UUID
+}
+
+func ofOrganization(id uuid.UUID) owner {
+	return owner{`SELECT $2::uuid`, id}
+}
+
+func ofProject(id uuid.UUID) owner {
+	return owner{`SELECT organization_id FROM projects WHERE id = $2`, id}
+}
+
+func ofService(id uuid.UUID) owner {
+	return owner{`SELECT p.organization_id FROM services s JOIN projects p ON p.id = s.project_id WHERE s.id = $2`, id}
+}
+
+func ofEndpoint(id uuid.UUID) owner {
+	return owner{`
+		SELECT p.organization_id FROM service_endpoints e
+		JOIN services s ON s.id = e.service_id
+		JOIN projects p ON p.id = s.project_id
+		WHERE e.id = $2`, id}
+}
+
+func ofUser(id uuid.UUID) owner {
+	return owner{`SELECT organization_id FROM users WHERE id = $2`, id}
+}
+
+// audit records a change to an entity by the view's actor, in q so it
+// commits or rolls back with the change. before and after are the entity
+// either side of the change, nil for creations and deletions.
+func (db *DB) audit(q sqlx.Execer, org owner, action, entityType string, entityID uuid.UUID, before, after interface{}) error {
+	if db.actor == "" {
+		return nil
+	}
+	beforeFields, afterFields, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+	if enabled, ok := afterFields["enabled"].(bool); ok && action == models.AuditUpdate && len(afterFields) == 1 {
+		action = models.AuditDisable
+		if enabled {
+			action = models.AuditEnable
+		}
+	}
+
+	query := `
+		INSERT INTO audit_log (id, organization_id, actor, action, entity_type, entity_id, before, after, created_at)
+		VALUES ($1, (` + org.query + `), $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err = q.Exec(query, uuid.New(), org.id, db.actor, action, entityType, entityID,
+		beforeFields, afterFields, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// auditIgnored are fields that change with every write, so aren't worth
+// recording.
+var auditIgnored = map[string]bool{"updated_at": true, "last_used_at": true}
+
+// auditDiff returns the JSON fields of before and after that differ, or
+// all of them if the other is nil.
+func auditDiff(before, after interface{}) (models.JSONB, models.JSONB, error) {
+	b, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b == nil || a == nil {
+		return b, a, nil
+	}
+	changedBefore, changedAfter := models.JSONB{}, models.JSONB{}
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			changedBefore[k] = v
+		}
+	}
+	for k, v := range a {
+		if !reflect.DeepEqual(v, b[k]) {
+			changedAfter[k] = v
+		}
+	}
+	return changedBefore, changedAfter, nil
+}
+
+func auditFields(v interface{}) (models.JSONB, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields models.JSONB
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k := range auditIgnored {
+		delete(fields, k)
+	}
+	return fields, nil
+}
+
+// RecordMonitorChange records a monitor being started or stopped for an
+// endpoint; the monitors themselves live in Temporal, not the database.
+func (db *DB) RecordMonitorChange(endpointID uuid.UUID, action string) error {
+	return db.audit(db, ofEndpoint(endpointID), action, "monitor", endpointID, nil, nil)
+}
+
+// AuditFilter narrows an audit log listing. Zero fields match everything.
+type AuditFilter struct {
+	EntityType string
+	EntityID   *uuid.UUID
+	Actor      string
+	Since      time.Time
+	Limit      int
+}
+
+// ListAuditEntries returns the newest audit entries first. Tenant views
+// see their organization's entries.
+func (db *DB) ListAuditEntries(filter AuditFilter) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	var args []interface{}
+	query := `SELECT * FROM audit_log WHERE ` + db.organizationScope("organization_id", &args)
+	if filter.EntityType != "" {
+		args = append(args, filter.EntityType)
+		query += fmt.Sprintf(` AND entity_type = $%d`, len(args))
+	}
+	if filter.EntityID != nil {
+		args = append(args, *filter.EntityID)
+		query += fmt.Sprintf(` AND entity_id = $%d`, len(args))
+	}
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		query += fmt.Sprintf(` AND actor = $%d`, len(args))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		query += fmt.Sprintf(` AND created_at >= $%d`, len(args))
+	}
+	query += ` ORDER BY created_at DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if err := db.Select(&entries, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// GetCertificate returns the last certificate seen for an endpoint. It
+// returns sql.ErrNoRows if the endpoint hasn't been checked yet.
+func (db *DB) GetCertificate(endpointID uuid.UUID) (*models.Certificate, error) {
+	var cert models.Certificate
+	args := []interface{}{endpointID}
+	query := `SELECT * FROM certificates WHERE endpoint_id = $1 AND ` + db.endpointScope("endpoint_id", &args)
+	err := db.Get(&cert, query, args...)
+	if err != nil {
+		return nil, err // May return sql.ErrNoRows
+	}
+	return &cert, nil
+}
+
+// UpsertCertificate records the latest certificate seen for an endpoint.
+func (db *DB) UpsertCertificate(cert *models.Certificate) error {
+	cert.ID = uuid.New()
+	cert.CreatedAt = time.Now()
+	cert.UpdatedAt = time.Now()
+
+	if err := db.requireEndpoint(cert.EndpointID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO certificates
+		(id, endpoint_id, host, subject, issuer, sans, serial_number, not_before, not_after,
+		 chain_valid, chain_error, ocsp_status, notified_days, checked_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (endpoint_id) DO UPDATE
+		SET host = EXCLUDED.host, subject = EXCLUDED.subject, issuer = EXCLUDED.issuer,
+		    sans = EXCLUDED.sans, serial_number = EXCLUDED.serial_number,
+		    not_before = EXCLUDED.not_before, not_after = EXCLUDED.not_after,
+		    chain_valid = EXCLUDED.chain_valid, chain_error = EXCLUDED.chain_error,
+		    ocsp_status = EXCLUDED.ocsp_status, notified_days = EXCLUDED.notified_days,
+		    checked_at = EXCLUDED.checked_at, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+	return db.QueryRow(query,
+		cert.ID, cert.EndpointID, cert.Host, cert.Subject, cert.Issuer, cert.SANs, cert.SerialNumber,
+		cert.NotBefore, cert.NotAfter, cert.ChainValid, cert.ChainError, cert.OCSPStatus,
+		cert.NotifiedDays, cert.CheckedAt, cert.CreatedAt, cert.UpdatedAt).Scan(&cert.ID, &cert.CreatedAt)
+}
+
+// ListCertificates returns the certificates of all live endpoints, soonest
+// to expire first.
+func (db *DB) ListCertificates() ([]models.Certificate, error) {
+	var certs []models.Certificate
+	var args []interface{}
+	query := `
+		SELECT c.* FROM certificates c
+		JOIN service_endpoints e ON e.id = c.endpoint_id
+		WHERE e.deleted_at IS NULL AND ` + db.serviceScope("e.service_id", &args) + `
+		ORDER BY c.not_after ASC
+	`
+	err := db.Select(&certs, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list certificates: %w", err)
+	}
+	return certs, nil
+}
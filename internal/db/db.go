@@ -9,6 +9,10 @@ import (
 
 type DB struct {
 	*sqlx.DB
+	// tenant, if set, limits every query to one organization or project.
+	tenant *Tenant
+	// actor, if set, is who the view's changes are recorded as made by.
+	actor string
 }
 
 func NewDB(dsn string) (*DB, error) {
@@ -21,9 +25,9 @@ func NewDB(dsn string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db}, nil
 }
 
 func (db *DB) Close() error {
 	return db.DB.Close()
-}
\ No newline at end of file
+}
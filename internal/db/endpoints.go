@@ -1,34 +1,89 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/beacon/internal/models"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const endpointColumns = `id, service_id, slug, name, url, method, headers, expected_code, timeout_ms, interval_sec, enabled,
+	regions, quorum, tags, body, content_type, query_params, follow_redirects, max_redirects, user_agent, http_version,
+	auth, tls_config, check_type, check_config, created_at, updated_at, deleted_at`
+
+// CreateEndpoint saves a new endpoint, giving heartbeat endpoints their
+// check-in URL. The endpoint and its heartbeat are written together.
 func (db *DB) CreateEndpoint(endpoint *models.ServiceEndpoint) error {
+	var heartbeat *models.Heartbeat
+	if endpoint.CheckType == models.CheckHeartbeat {
+		token, err := NewHeartbeatToken()
+		if err != nil {
+			return fmt.Errorf("failed to generate heartbeat token: %w", err)
+		}
+		heartbeat = &models.Heartbeat{Token: token}
+		endpoint.URL = CheckinURL(token)
+	}
 	endpoint.ID = uuid.New()
 	endpoint.CreatedAt = time.Now()
 	endpoint.UpdatedAt = time.Now()
+	normalizeEndpoint(endpoint)
+
+	if err := db.requireService(endpoint.ServiceID); err != nil {
+		return err
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := checkQuota(tx, endpoint.ServiceID, endpoint.IntervalSec, true); err != nil {
+		return err
+	}
+	if endpoint.Slug, err = pickSlug(tx, "endpoint", endpoint.ServiceID, endpoint.ID, endpoint.Slug, endpoint.Name); err != nil {
+		return err
+	}
 
 	query := `
 		INSERT INTO service_endpoints 
-		(id, service_id, name, url, method, headers, expected_code, timeout_ms, interval_sec, enabled, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		(id, service_id, slug, name, url, method, headers, expected_code, timeout_ms, interval_sec, enabled,
+		 regions, quorum, tags, body, content_type, query_params, follow_redirects, max_redirects, user_agent, http_version,
+		 auth, tls_config, check_type, check_config, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23,
+		        $24, $25, $26, $27)
 	`
-	_, err := db.Exec(query, 
-		endpoint.ID, endpoint.ServiceID, endpoint.Name, endpoint.URL, endpoint.Method,
+	_, err = tx.Exec(query, 
+		endpoint.ID, endpoint.ServiceID, endpoint.Slug, endpoint.Name, endpoint.URL, endpoint.Method,
 		endpoint.Headers, endpoint.ExpectedCode, endpoint.TimeoutMs, endpoint.IntervalSec,
-		endpoint.Enabled, endpoint.CreatedAt, endpoint.UpdatedAt)
-	return err
+		endpoint.Enabled, endpoint.Regions, endpoint.Quorum, endpoint.Tags,
+		endpoint.Body, endpoint.ContentType, endpoint.QueryParams, endpoint.FollowRedirects,
+		endpoint.MaxRedirects, endpoint.UserAgent, endpoint.HTTPVersion,
+		endpoint.Auth, endpoint.TLS, endpoint.CheckType, endpoint.Check, endpoint.CreatedAt, endpoint.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if heartbeat != nil {
+		heartbeat.EndpointID = endpoint.ID
+		if err := insertHeartbeat(tx, heartbeat); err != nil {
+			return err
+		}
+	}
+	if err := db.audit(tx, ofEndpoint(endpoint.ID), models.AuditCreate, "endpoint", endpoint.ID, nil, endpoint.Redacted()); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (db *DB) GetEndpoint(id uuid.UUID) (*models.ServiceEndpoint, error) {
 	var endpoint models.ServiceEndpoint
-	query := `SELECT id, service_id, name, url, method, headers, expected_code, timeout_ms, interval_sec, enabled, created_at, updated_at, deleted_at FROM service_endpoints WHERE id = $1 AND deleted_at IS NULL`
-	err := db.Get(&endpoint, query, id)
+	args := []interface{}{id}
+	query := `SELECT ` + endpointColumns + ` FROM service_endpoints WHERE id = $1 AND deleted_at IS NULL AND ` +
+		db.serviceScope("service_id", &args)
+	err := db.Get(&endpoint, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get endpoint: %w", err)
 	}
@@ -41,15 +96,15 @@ func (db *DB) GetEndpoint(id uuid.UUID) (*models.ServiceEndpoint, error) {
 
 func (db *DB) ListEndpoints(serviceID *uuid.UUID) ([]models.ServiceEndpoint, error) {
 	var endpoints []models.ServiceEndpoint
-	var query string
 	var args []interface{}
+	query := `SELECT ` + endpointColumns + ` FROM service_endpoints WHERE deleted_at IS NULL AND ` +
+		db.serviceScope("service_id", &args)
 
 	if serviceID != nil {
-		query = `SELECT id, service_id, name, url, method, headers, expected_code, timeout_ms, interval_sec, enabled, created_at, updated_at, deleted_at FROM service_endpoints WHERE service_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC`
 		args = append(args, *serviceID)
-	} else {
-		query = `SELECT id, service_id, name, url, method, headers, expected_code, timeout_ms, interval_sec, enabled, created_at, updated_at, deleted_at FROM service_endpoints WHERE deleted_at IS NULL ORDER BY created_at DESC`
+		query += fmt.Sprintf(` AND service_id = $%d`, len(args))
 	}
+	query += ` ORDER BY created_at DESC`
 
 	err := db.Select(&endpoints, query, args...)
 	if err != nil {
@@ -58,32 +113,137 @@ func (db *DB) ListEndpoints(serviceID *uuid.UUID) ([]models.ServiceEndpoint, err
 	return endpoints, nil
 }
 
+// UpdateEndpoint saves an endpoint, enforcing its organization's minimum
+// interval. An endpoint that has just become a heartbeat endpoint is given
+// a check-in URL, and one that has stopped being one loses its heartbeat,
+// so the old check-in URL stops working. An empty slug keeps the current
+// one.
 func (db *DB) UpdateEndpoint(endpoint *models.ServiceEndpoint) error {
+	before, err := db.GetEndpoint(endpoint.ID)
+	if err != nil {
+		return err
+	}
+	wasHeartbeat := before.CheckType == models.CheckHeartbeat
+	if wasHeartbeat && endpoint.CheckType != models.CheckHeartbeat && endpoint.URL == before.URL {
+		return fmt.Errorf("the check-in URL only works for heartbeat endpoints; set the URL to check")
+	}
+	var heartbeat *models.Heartbeat
+	if endpoint.CheckType == models.CheckHeartbeat {
+		_, err := db.GetHeartbeat(endpoint.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			token, err := NewHeartbeatToken()
+			if err != nil {
+				return fmt.Errorf("failed to generate heartbeat token: %w", err)
+			}
+			heartbeat = &models.Heartbeat{EndpointID: endpoint.ID, Token: token}
+			endpoint.URL = CheckinURL(token)
+		} else if err != nil {
+			return err
+		}
+	}
 	endpoint.UpdatedAt = time.Now()
+	normalizeEndpoint(endpoint)
+	if endpoint.Slug == "" {
+		endpoint.Slug = before.Slug
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := checkQuota(tx, endpoint.ServiceID, endpoint.IntervalSec, false); err != nil {
+		return err
+	}
+	if endpoint.Slug != before.Slug {
+		if _, err := pickSlug(tx, "endpoint", before.ServiceID, endpoint.ID, endpoint.Slug, endpoint.Name); err != nil {
+			return err
+		}
+	}
+
+	args := []interface{}{
+		endpoint.ID, endpoint.Name, endpoint.URL, endpoint.Method, endpoint.Headers,
+		endpoint.ExpectedCode, endpoint.TimeoutMs, endpoint.IntervalSec, endpoint.Enabled,
+		endpoint.Regions, endpoint.Quorum,
+		endpoint.Body, endpoint.ContentType, endpoint.QueryParams, endpoint.FollowRedirects,
+		endpoint.MaxRedirects, endpoint.UserAgent, endpoint.HTTPVersion, endpoint.Auth,
+		endpoint.TLS, endpoint.CheckType, endpoint.Check, endpoint.UpdatedAt, endpoint.Slug, endpoint.Tags,
+	}
 	query := `
 		UPDATE service_endpoints 
 		SET name = $2, url = $3, method = $4, headers = $5, expected_code = $6, 
-		    timeout_ms = $7, interval_sec = $8, enabled = $9, updated_at = $10
-		WHERE id = $1 AND deleted_at IS NULL
-	`
-	_, err := db.Exec(query, 
-		endpoint.ID, endpoint.Name, endpoint.URL, endpoint.Method, endpoint.Headers,
-		endpoint.ExpectedCode, endpoint.TimeoutMs, endpoint.IntervalSec, endpoint.Enabled,
-		endpoint.UpdatedAt)
+		    timeout_ms = $7, interval_sec = $8, enabled = $9, regions = $10, quorum = $11,
+		    body = $12, content_type = $13, query_params = $14, follow_redirects = $15,
+		    max_redirects = $16, user_agent = $17, http_version = $18, auth = $19,
+		    tls_config = $20, check_type = $21, check_config = $22, updated_at = $23, slug = $24,
+		    tags = $25
+		WHERE id = $1 AND deleted_at IS NULL AND ` + db.serviceScope("service_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	switch {
+	case heartbeat != nil:
+		if err := insertHeartbeat(tx, heartbeat); err != nil {
+			return err
+		}
+	case wasHeartbeat && endpoint.CheckType != models.CheckHeartbeat:
+		if _, err := tx.Exec(`DELETE FROM heartbeats WHERE endpoint_id = $1`, endpoint.ID); err != nil {
+			return err
+		}
+	}
+	if err := db.audit(tx, ofEndpoint(endpoint.ID), models.AuditUpdate, "endpoint", endpoint.ID, before.Redacted(), endpoint.Redacted()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetDNSBaseline records the answers a baseline dns check compares against.
+// It leaves an existing baseline alone, so only the first region to resolve
+// the record sets it.
+func (db *DB) SetDNSBaseline(id uuid.UUID, answers []string) error {
+	baseline, err := json.Marshal(answers)
+	if err != nil {
+		return err
+	}
+	args := []interface{}{id, string(baseline)}
+	query := `
+		UPDATE service_endpoints
+		SET check_config = jsonb_set(COALESCE(check_config, '{}'), '{dns_baseline}', $2::jsonb)
+		WHERE id = $1 AND NOT COALESCE(check_config ? 'dns_baseline', false) AND ` + db.serviceScope("service_id", &args)
+	_, err = db.Exec(query, args...)
 	return err
 }
 
 func (db *DB) DeleteEndpoint(id uuid.UUID) error {
+	before, err := db.GetEndpoint(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	query := `UPDATE service_endpoints SET deleted_at = $2 WHERE id = $1`
-	_, err := db.Exec(query, id, now)
-	return err
+	args := []interface{}{id, now}
+	query := `UPDATE service_endpoints SET deleted_at = $2 WHERE id = $1 AND ` + db.serviceScope("service_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofEndpoint(id), models.AuditDelete, "endpoint", id, before.Redacted(), nil); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (db *DB) ListEnabledEndpoints() ([]models.ServiceEndpoint, error) {
 	var endpoints []models.ServiceEndpoint
-	query := `SELECT id, service_id, name, url, method, headers, expected_code, timeout_ms, interval_sec, enabled, created_at, updated_at, deleted_at FROM service_endpoints WHERE enabled = true AND deleted_at IS NULL`
-	err := db.Select(&endpoints, query)
+	var args []interface{}
+	query := `SELECT ` + endpointColumns + ` FROM service_endpoints WHERE enabled = true AND deleted_at IS NULL AND ` +
+		db.serviceScope("service_id", &args)
+	err := db.Select(&endpoints, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list enabled endpoints: %w", err)
 	}
@@ -94,4 +254,23 @@ func (db *DB) ListEnabledEndpoints() ([]models.ServiceEndpoint, error) {
 		}
 	}
 	return endpoints, nil
-}
\ No newline at end of file
+}
+// normalizeEndpoint fills in defaults: an HTTP check, non-null regions and
+// tags columns and a quorum of at least 1.
+func normalizeEndpoint(endpoint *models.ServiceEndpoint) {
+	if endpoint.CheckType == "" {
+		endpoint.CheckType = models.CheckHTTP
+	}
+	if endpoint.Regions == nil {
+		endpoint.Regions = pq.StringArray{}
+	}
+	if endpoint.Tags == nil {
+		endpoint.Tags = pq.StringArray{}
+	}
+	if endpoint.Quorum < 1 {
+		endpoint.Quorum = 1
+	}
+	if endpoint.CheckType == models.CheckDNS && endpoint.Check.RecordType == "" {
+		endpoint.Check.RecordType = "A"
+	}
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// NewHeartbeatToken returns a random, URL-safe check-in token.
+func NewHeartbeatToken() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CheckinURL returns the check-in URL for a heartbeat token, on the
+// receiver at BEACON_RECEIVER_URL.
+func CheckinURL(token string) string {
+	base := os.Getenv("BEACON_RECEIVER_URL")
+	if base == "" {
+		base = "http://localhost:8090"
+	}
+	return strings.TrimRight(base, "/") + "/ping/" + token
+}
+
+func (db *DB) CreateHeartbeat(heartbeat *models.Heartbeat) error {
+	if err := db.requireEndpoint(heartbeat.EndpointID); err != nil {
+		return err
+	}
+	return insertHeartbeat(db, heartbeat)
+}
+
+// insertHeartbeat adds a heartbeat row, inside the endpoint's transaction
+// when it's created along with the endpoint.
+func insertHeartbeat(q sqlx.Execer, heartbeat *models.Heartbeat) error {
+	heartbeat.CreatedAt = time.Now()
+	heartbeat.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO heartbeats (endpoint_id, token, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := q.Exec(query, heartbeat.EndpointID, heartbeat.Token, heartbeat.CreatedAt, heartbeat.UpdatedAt)
+	return err
+}
+
+func (db *DB) GetHeartbeat(endpointID uuid.UUID) (*models.Heartbeat, error) {
+	var heartbeat models.Heartbeat
+	args := []interface{}{endpointID}
+	query := `SELECT * FROM heartbeats WHERE endpoint_id = $1 AND ` + db.endpointScope("endpoint_id", &args)
+	err := db.Get(&heartbeat, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get heartbeat: %w", err)
+	}
+	return &heartbeat, nil
+}
+
+// GetHeartbeatByToken returns the heartbeat of a live endpoint. It returns
+// sql.ErrNoRows if the token is unknown.
+func (db *DB) GetHeartbeatByToken(token string) (*models.Heartbeat, error) {
+	var heartbeat models.Heartbeat
+	args := []interface{}{token}
+	query := `
+		SELECT h.* FROM heartbeats h
+		JOIN service_endpoints e ON e.id = h.endpoint_id
+		WHERE h.token = $1 AND e.deleted_at IS NULL AND ` + db.serviceScope("e.service_id", &args)
+	err := db.Get(&heartbeat, query, args...)
+	if err != nil {
+		return nil, err // May return sql.ErrNoRows
+	}
+	return &heartbeat, nil
+}
+
+func (db *DB) ListHeartbeats() ([]models.Heartbeat, error) {
+	var heartbeats []models.Heartbeat
+	var args []interface{}
+	query := `
+		SELECT h.* FROM heartbeats h
+		JOIN service_endpoints e ON e.id = h.endpoint_id
+		WHERE e.deleted_at IS NULL AND ` + db.serviceScope("e.service_id", &args) + `
+		ORDER BY h.created_at DESC
+	`
+	err := db.Select(&heartbeats, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list heartbeats: %w", err)
+	}
+	return heartbeats, nil
+}
+
+// RecordCheckin updates a heartbeat with a check-in at the given time.
+func (db *DB) RecordCheckin(heartbeat *models.Heartbeat, status string, at time.Time) error {
+	heartbeat.LastStatus = status
+	heartbeat.LastCheckinAt = &at
+	switch status {
+	case models.HeartbeatStart:
+		heartbeat.LastStartAt = &at
+	case models.HeartbeatSuccess:
+		heartbeat.LastSuccessAt = &at
+	}
+	heartbeat.UpdatedAt = time.Now()
+
+	args := []interface{}{heartbeat.EndpointID, heartbeat.LastStatus, heartbeat.LastStartAt,
+		heartbeat.LastSuccessAt, heartbeat.LastCheckinAt, heartbeat.UpdatedAt}
+	query := `
+		UPDATE heartbeats
+		SET last_status = $2, last_start_at = $3, last_success_at = $4, last_checkin_at = $5, updated_at = $6
+		WHERE endpoint_id = $1 AND ` + db.endpointScope("endpoint_id", &args)
+	_, err := db.Exec(query, args...)
+	return err
+}
+
+// RotateHeartbeatToken replaces a heartbeat's token, invalidating the old
+// check-in URL.
+func (db *DB) RotateHeartbeatToken(endpointID uuid.UUID, token string) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{endpointID, token, time.Now()}
+	query := `UPDATE heartbeats SET token = $2, updated_at = $3 WHERE endpoint_id = $1 AND ` + db.endpointScope("endpoint_id", &args)
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("endpoint %s has no heartbeat", endpointID)
+	}
+	// The token is a credential, so only the rotation is recorded
+	if err := db.audit(tx, ofEndpoint(endpointID), models.AuditUpdate, "heartbeat", endpointID, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
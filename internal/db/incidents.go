@@ -13,6 +13,10 @@ func (db *DB) CreateIncident(incident *models.Incident) error {
 	incident.CreatedAt = time.Now()
 	incident.UpdatedAt = time.Now()
 
+	if err := db.requireEndpoint(incident.EndpointID); err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO incidents 
 		(id, endpoint_id, started_at, status, message, created_at, updated_at)
@@ -26,8 +30,9 @@ func (db *DB) CreateIncident(incident *models.Incident) error {
 
 func (db *DB) GetIncident(id uuid.UUID) (*models.Incident, error) {
 	var incident models.Incident
-	query := `SELECT * FROM incidents WHERE id = $1`
-	err := db.Get(&incident, query, id)
+	args := []interface{}{id}
+	query := `SELECT * FROM incidents WHERE id = $1 AND ` + db.endpointScope("endpoint_id", &args)
+	err := db.Get(&incident, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get incident: %w", err)
 	}
@@ -36,21 +41,18 @@ func (db *DB) GetIncident(id uuid.UUID) (*models.Incident, error) {
 
 func (db *DB) ListIncidents(endpointID *uuid.UUID, status string) ([]models.Incident, error) {
 	var incidents []models.Incident
-	var query string
 	var args []interface{}
+	query := `SELECT * FROM incidents WHERE ` + db.endpointScope("endpoint_id", &args)
 
-	if endpointID != nil && status != "" {
-		query = `SELECT * FROM incidents WHERE endpoint_id = $1 AND status = $2 ORDER BY started_at DESC`
-		args = append(args, *endpointID, status)
-	} else if endpointID != nil {
-		query = `SELECT * FROM incidents WHERE endpoint_id = $1 ORDER BY started_at DESC`
+	if endpointID != nil {
 		args = append(args, *endpointID)
-	} else if status != "" {
-		query = `SELECT * FROM incidents WHERE status = $1 ORDER BY started_at DESC`
+		query += fmt.Sprintf(` AND endpoint_id = $%d`, len(args))
+	}
+	if status != "" {
 		args = append(args, status)
-	} else {
-		query = `SELECT * FROM incidents ORDER BY started_at DESC`
+		query += fmt.Sprintf(` AND status = $%d`, len(args))
 	}
+	query += ` ORDER BY started_at DESC`
 
 	err := db.Select(&incidents, query, args...)
 	if err != nil {
@@ -61,32 +63,54 @@ func (db *DB) ListIncidents(endpointID *uuid.UUID, status string) ([]models.Inci
 
 func (db *DB) GetOpenIncident(endpointID uuid.UUID) (*models.Incident, error) {
 	var incident models.Incident
-	query := `SELECT * FROM incidents WHERE endpoint_id = $1 AND status = 'open' ORDER BY started_at DESC LIMIT 1`
-	err := db.Get(&incident, query, endpointID)
+	args := []interface{}{endpointID}
+	query := `SELECT * FROM incidents WHERE endpoint_id = $1 AND status = 'open' AND ` +
+		db.endpointScope("endpoint_id", &args) + ` ORDER BY started_at DESC LIMIT 1`
+	err := db.Get(&incident, query, args...)
 	if err != nil {
 		return nil, err // May return sql.ErrNoRows
 	}
 	return &incident, nil
 }
 
+// ResolveIncident resolves an incident. Resolving one by hand, through a
+// view with an actor, is recorded in the audit log.
 func (db *DB) ResolveIncident(id uuid.UUID) error {
+	before, err := db.GetIncident(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
+	args := []interface{}{id, now, now}
 	query := `
 		UPDATE incidents 
 		SET status = 'resolved', resolved_at = $2, updated_at = $3
-		WHERE id = $1
-	`
-	_, err := db.Exec(query, id, now, now)
-	return err
+		WHERE id = $1 AND ` + db.endpointScope("endpoint_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	after := *before
+	after.Status, after.ResolvedAt = "resolved", &now
+	if err := db.audit(tx, ofEndpoint(before.EndpointID), models.AuditResolve, "incident", id, before, &after); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (db *DB) UpdateIncident(incident *models.Incident) error {
 	incident.UpdatedAt = time.Now()
+	args := []interface{}{incident.ID, incident.Message, incident.UpdatedAt}
 	query := `
 		UPDATE incidents 
 		SET message = $2, updated_at = $3
-		WHERE id = $1
-	`
-	_, err := db.Exec(query, incident.ID, incident.Message, incident.UpdatedAt)
+		WHERE id = $1 AND ` + db.endpointScope("endpoint_id", &args)
+	_, err := db.Exec(query, args...)
 	return err
 }
\ No newline at end of file
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrSlugTaken is returned when a service or endpoint is given a slug
+// another one in the same project or service already has.
+var ErrSlugTaken = errors.New("slug taken")
+
+// NotFoundError is returned when a reference matches nothing. It wraps
+// sql.ErrNoRows, like a failed lookup by ID.
+type NotFoundError struct {
+	Kind string // service, endpoint
+	Ref  string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Kind, e.Ref)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return sql.ErrNoRows
+}
+
+// AmbiguousError is returned when a reference matches more than one
+// service or endpoint. Candidates describe each match.
+type AmbiguousError struct {
+	Kind       string
+	Ref        string
+	Candidates []string
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%s %s is ambiguous; it matches %s. Use a more specific name or an ID",
+		e.Kind, e.Ref, strings.Join(e.Candidates, ", "))
+}
+
+// Unique returns the only match for ref, or a NotFoundError or
+// AmbiguousError listing each match as describe gives it.
+func Unique[T any](kind, ref string, matches []T, describe func(T) string) (*T, error) {
+	switch len(matches) {
+	case 0:
+		return nil, &NotFoundError{Kind: kind, Ref: ref}
+	case 1:
+		return &matches[0], nil
+	}
+	candidates := make([]string, len(matches))
+	for i, m := range matches {
+		candidates[i] = describe(m)
+	}
+	return nil, &AmbiguousError{Kind: kind, Ref: ref, Candidates: candidates}
+}
+
+// preferSlug narrows matches to those whose slug is ref, if there are any,
+// so that a slug wins over another entity's name.
+func preferSlug[T any](matches []T, ref string, slug func(T) string) []T {
+	var exact []T
+	for _, m := range matches {
+		if slug(m) == ref {
+			exact = append(exact, m)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return matches
+}
+
+// FindServices returns the services ref names: by slug, or failing that by
+// name ignoring case.
+func (db *DB) FindServices(ref string) ([]models.Service, error) {
+	var services []models.Service
+	args := []interface{}{ref}
+	query := `SELECT * FROM services WHERE (slug = $1 OR lower(name) = lower($1)) AND deleted_at IS NULL AND ` +
+		db.projectScope("project_id", &args) + ` ORDER BY created_at`
+	if err := db.Select(&services, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to find services: %w", err)
+	}
+	return preferSlug(services, ref, func(s models.Service) string { return s.Slug }), nil
+}
+
+// FindEndpoints returns the endpoints ref names, as <service>/<endpoint> or
+// just <endpoint> to look in every service. Each part matches a slug, or
+// failing that a name ignoring case; the service can also be an ID.
+func (db *DB) FindEndpoints(ref string) ([]models.ServiceEndpoint, error) {
+	if serviceRef, endpointRef, ok := strings.Cut(ref, "/"); ok {
+		var services []models.Service
+		if id, err := uuid.Parse(serviceRef); err == nil {
+			if service, err := db.GetService(id); err == nil {
+				services = append(services, *service)
+			}
+		} else if services, err = db.FindServices(serviceRef); err != nil {
+			return nil, err
+		}
+
+		var endpoints []models.ServiceEndpoint
+		for _, service := range services {
+			found, err := db.findEndpoints(endpointRef, &service.ID)
+			if err != nil {
+				return nil, err
+			}
+			endpoints = append(endpoints, found...)
+		}
+		// Otherwise the slash may be part of an endpoint's name
+		if len(endpoints) > 0 {
+			return endpoints, nil
+		}
+	}
+	return db.findEndpoints(ref, nil)
+}
+
+func (db *DB) findEndpoints(ref string, serviceID *uuid.UUID) ([]models.ServiceEndpoint, error) {
+	var endpoints []models.ServiceEndpoint
+	args := []interface{}{ref}
+	query := `SELECT ` + endpointColumns + ` FROM service_endpoints
+		WHERE (slug = $1 OR lower(name) = lower($1)) AND deleted_at IS NULL AND ` + db.serviceScope("service_id", &args)
+	if serviceID != nil {
+		args = append(args, *serviceID)
+		query += fmt.Sprintf(` AND service_id = $%d`, len(args))
+	}
+	query += ` ORDER BY created_at`
+	if err := db.Select(&endpoints, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to find endpoints: %w", err)
+	}
+	return preferSlug(endpoints, ref, func(e models.ServiceEndpoint) string { return e.Slug }), nil
+}
+
+// ResolveService looks a service up by ID, slug or name.
+func (db *DB) ResolveService(ref string) (*models.Service, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return db.GetService(id)
+	}
+	services, err := db.FindServices(ref)
+	if err != nil {
+		return nil, err
+	}
+	return Unique("service", ref, services, func(s models.Service) string {
+		return fmt.Sprintf("%s (%s)", s.Slug, s.ID)
+	})
+}
+
+// ResolveEndpoint looks an endpoint up by ID or by reference, as
+// FindEndpoints takes them.
+func (db *DB) ResolveEndpoint(ref string) (*models.ServiceEndpoint, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return db.GetEndpoint(id)
+	}
+	endpoints, err := db.FindEndpoints(ref)
+	if err != nil {
+		return nil, err
+	}
+	return Unique("endpoint", ref, endpoints, func(e models.ServiceEndpoint) string {
+		service := e.ServiceID.String()
+		if s, err := db.GetService(e.ServiceID); err == nil {
+			service = s.Slug
+		}
+		return fmt.Sprintf("%s/%s (%s)", service, e.Slug, e.ID)
+	})
+}
+
+// slugScopes say where service and endpoint slugs must be unique.
+var slugScopes = map[string]struct{ table, parent, parentKind string }{
+	"service":  {"services", "project_id", "project"},
+	"endpoint": {"service_endpoints", "service_id", "service"},
+}
+
+// pickSlug returns the slug to save for a service or endpoint. A slug that
+// was given must be free within the parent project or service; otherwise
+// one is made from the name, numbered if need be to make it unique.
+func pickSlug(tx *sqlx.Tx, kind string, parentID, id uuid.UUID, slug, name string) (string, error) {
+	scope := slugScopes[kind]
+	taken := func(slug string) (bool, error) {
+		var exists bool
+		query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s = $1 AND slug = $2 AND id <> $3 AND deleted_at IS NULL)`,
+			scope.table, scope.parent)
+		err := tx.Get(&exists, query, parentID, slug, id)
+		return exists, err
+	}
+
+	if slug != "" {
+		exists, err := taken(slug)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "", fmt.Errorf("%w: the %s already has a %s %s", ErrSlugTaken, scope.parentKind, kind, slug)
+		}
+		return slug, nil
+	}
+
+	base := models.Slugify(name)
+	if base == "" {
+		base = kind
+	}
+	// Leave room for the number
+	if len(base) > 58 {
+		base = strings.TrimRight(base[:58], "-")
+	}
+	for n := 1; ; n++ {
+		slug = base
+		if n > 1 {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		exists, err := taken(slug)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return slug, nil
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+func (db *DB) CreateMaintenanceWindow(window *models.MaintenanceWindow) error {
+	window.ID = uuid.New()
+	window.CreatedAt = time.Now()
+	window.UpdatedAt = time.Now()
+
+	if err := db.requireService(window.ServiceID); err != nil {
+		return err
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO maintenance_windows (id, service_id, name, starts_at, ends_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = tx.Exec(query, window.ID, window.ServiceID, window.Name, window.StartsAt, window.EndsAt,
+		window.CreatedAt, window.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofService(window.ServiceID), models.AuditCreate, "maintenance_window", window.ID, nil, window); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *DB) GetMaintenanceWindow(id uuid.UUID) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	args := []interface{}{id}
+	query := `SELECT * FROM maintenance_windows WHERE id = $1 AND deleted_at IS NULL AND ` + db.serviceScope("service_id", &args)
+	if err := db.Get(&window, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+	return &window, nil
+}
+
+// ListMaintenanceWindows lists maintenance windows by start time, of one
+// service if serviceID is set.
+func (db *DB) ListMaintenanceWindows(serviceID *uuid.UUID) ([]models.MaintenanceWindow, error) {
+	var windows []models.MaintenanceWindow
+	var args []interface{}
+	query := `SELECT * FROM maintenance_windows WHERE deleted_at IS NULL AND ` + db.serviceScope("service_id", &args)
+	if serviceID != nil {
+		args = append(args, *serviceID)
+		query += fmt.Sprintf(` AND service_id = $%d`, len(args))
+	}
+	query += ` ORDER BY starts_at`
+	if err := db.Select(&windows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+	return windows, nil
+}
+
+func (db *DB) UpdateMaintenanceWindow(window *models.MaintenanceWindow) error {
+	before, err := db.GetMaintenanceWindow(window.ID)
+	if err != nil {
+		return err
+	}
+	window.UpdatedAt = time.Now()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{window.ID, window.Name, window.StartsAt, window.EndsAt, window.UpdatedAt}
+	query := `
+		UPDATE maintenance_windows
+		SET name = $2, starts_at = $3, ends_at = $4, updated_at = $5
+		WHERE id = $1 AND deleted_at IS NULL AND ` + db.serviceScope("service_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofService(before.ServiceID), models.AuditUpdate, "maintenance_window", window.ID, before, window); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *DB) DeleteMaintenanceWindow(id uuid.UUID) error {
+	before, err := db.GetMaintenanceWindow(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{id, time.Now()}
+	query := `UPDATE maintenance_windows SET deleted_at = $2 WHERE id = $1 AND ` + db.serviceScope("service_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofService(before.ServiceID), models.AuditDelete, "maintenance_window", id, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// InMaintenance reports whether an endpoint's service has a maintenance
+// window covering at.
+func (db *DB) InMaintenance(endpointID uuid.UUID, at time.Time) (bool, error) {
+	var active bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM maintenance_windows m
+			JOIN service_endpoints e ON e.service_id = m.service_id
+			WHERE e.id = $1 AND m.deleted_at IS NULL AND m.starts_at <= $2 AND m.ends_at > $2
+		)
+	`
+	if err := db.Get(&active, query, endpointID, at); err != nil {
+		return false, fmt.Errorf("failed to check maintenance windows: %w", err)
+	}
+	return active, nil
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// CreateOrganization creates an organization with a default project. A
+// tenant's view can't create organizations.
+func (db *DB) CreateOrganization(org *models.Organization) error {
+	if db.tenant != nil {
+		return fmt.Errorf("organizations can only be created by operators")
+	}
+	org.ID = uuid.New()
+	org.CreatedAt = time.Now()
+	org.UpdatedAt = time.Now()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO organizations (id, slug, name, max_endpoints, min_interval_sec, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := tx.Exec(query, org.ID, org.Slug, org.Name, org.MaxEndpoints, org.MinIntervalSec,
+		org.CreatedAt, org.UpdatedAt); err != nil {
+		return err
+	}
+	query = `
+		INSERT INTO projects (id, organization_id, slug, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.Exec(query, uuid.New(), org.ID, models.DefaultSlug, "Default", org.CreatedAt, org.UpdatedAt); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofOrganization(org.ID), models.AuditCreate, "organization", org.ID, nil, org); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *DB) GetOrganization(id uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	args := []interface{}{id}
+	query := `SELECT * FROM organizations WHERE id = $1 AND deleted_at IS NULL AND ` + db.organizationScope("id", &args)
+	err := db.Get(&org, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return &org, nil
+}
+
+func (db *DB) GetOrganizationBySlug(slug string) (*models.Organization, error) {
+	var org models.Organization
+	args := []interface{}{slug}
+	query := `SELECT * FROM organizations WHERE slug = $1 AND deleted_at IS NULL AND ` + db.organizationScope("id", &args)
+	err := db.Get(&org, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization %s: %w", slug, err)
+	}
+	return &org, nil
+}
+
+func (db *DB) ListOrganizations() ([]models.Organization, error) {
+	var orgs []models.Organization
+	var args []interface{}
+	query := `SELECT * FROM organizations WHERE deleted_at IS NULL AND ` + db.organizationScope("id", &args) + ` ORDER BY slug`
+	err := db.Select(&orgs, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	return orgs, nil
+}
+
+// UpdateOrganization saves an organization's name, slug and quotas. Quotas
+// apply to endpoints created or updated afterwards.
+func (db *DB) UpdateOrganization(org *models.Organization) error {
+	before, err := db.GetOrganization(org.ID)
+	if err != nil {
+		return err
+	}
+	org.UpdatedAt = time.Now()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{org.ID, org.Slug, org.Name, org.MaxEndpoints, org.MinIntervalSec, org.UpdatedAt}
+	query := `
+		UPDATE organizations
+		SET slug = $2, name = $3, max_endpoints = $4, min_interval_sec = $5, updated_at = $6
+		WHERE id = $1 AND deleted_at IS NULL AND ` + db.organizationScope("id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofOrganization(org.ID), models.AuditUpdate, "organization", org.ID, before, org); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteOrganization removes an organization with its projects and users,
+// revoking the users' tokens. It fails with ErrNotEmpty while the
+// organization has live services.
+func (db *DB) DeleteOrganization(id uuid.UUID) error {
+	if db.tenant != nil {
+		return fmt.Errorf("organizations can only be deleted by operators")
+	}
+	before, err := db.GetOrganization(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var services int
+	query := `
+		SELECT COUNT(*) FROM services s JOIN projects p ON p.id = s.project_id
+		WHERE p.organization_id = $1 AND s.deleted_at IS NULL
+	`
+	if err := tx.Get(&services, query, id); err != nil {
+		return err
+	}
+	if services > 0 {
+		return fmt.Errorf("%w: organization still has %d services", ErrNotEmpty, services)
+	}
+
+	for _, query := range []string{
+		`UPDATE organizations SET deleted_at = $2 WHERE id = $1`,
+		`UPDATE projects SET deleted_at = $2 WHERE organization_id = $1 AND deleted_at IS NULL`,
+		`UPDATE api_tokens SET revoked_at = $2
+		 WHERE user_id IN (SELECT id FROM users WHERE organization_id = $1) AND revoked_at IS NULL`,
+		`UPDATE users SET deleted_at = $2 WHERE organization_id = $1 AND deleted_at IS NULL`,
+	} {
+		if _, err := tx.Exec(query, id, now); err != nil {
+			return err
+		}
+	}
+	if err := db.audit(tx, ofOrganization(id), models.AuditDelete, "organization", id, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
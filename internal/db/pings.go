@@ -12,43 +12,61 @@ func (db *DB) CreatePing(ping *models.Ping) error {
 	ping.ID = uuid.New()
 	ping.CreatedAt = time.Now()
 
+	if err := db.requireEndpoint(ping.EndpointID); err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO pings (id, endpoint_id, status_code, response_ms, success, error, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO pings (id, endpoint_id, status_code, response_ms, success, error, region,
+		                   dns_ms, connect_ms, tls_ms, ttfb_ms, transfer_ms, query_ms, remote_ip, conn_reused,
+		                   handshake_ms, first_message_ms, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 	`
 	_, err := db.Exec(query, ping.ID, ping.EndpointID, ping.StatusCode, 
-		ping.ResponseMs, ping.Success, ping.Error, ping.CreatedAt)
+		ping.ResponseMs, ping.Success, ping.Error, ping.Region,
+		ping.DNSMs, ping.ConnectMs, ping.TLSMs, ping.TTFBMs, ping.TransferMs, ping.QueryMs, ping.RemoteIP, ping.ConnReused,
+		ping.HandshakeMs, ping.FirstMessageMs, ping.Details, ping.CreatedAt)
 	return err
 }
 
 func (db *DB) GetPing(id uuid.UUID) (*models.Ping, error) {
 	var ping models.Ping
-	query := `SELECT * FROM pings WHERE id = $1`
-	err := db.Get(&ping, query, id)
+	args := []interface{}{id}
+	query := `SELECT * FROM pings WHERE id = $1 AND ` + db.endpointScope("endpoint_id", &args)
+	err := db.Get(&ping, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ping: %w", err)
 	}
 	return &ping, nil
 }
 
-func (db *DB) ListPings(endpointID uuid.UUID, limit int) ([]models.Ping, error) {
+// ListPings returns the most recent pings for an endpoint. An empty region
+// returns pings from every region.
+func (db *DB) ListPings(endpointID uuid.UUID, region string, limit int) ([]models.Ping, error) {
 	var pings []models.Ping
-	query := `SELECT * FROM pings WHERE endpoint_id = $1 ORDER BY created_at DESC LIMIT $2`
-	err := db.Select(&pings, query, endpointID, limit)
+	args := []interface{}{endpointID, region, limit}
+	query := `
+		SELECT * FROM pings 
+		WHERE endpoint_id = $1 AND ($2 = '' OR region = $2) AND ` + db.endpointScope("endpoint_id", &args) + `
+		ORDER BY created_at DESC LIMIT $3
+	`
+	err := db.Select(&pings, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pings: %w", err)
 	}
 	return pings, nil
 }
 
-func (db *DB) ListPingsByTimeRange(endpointID uuid.UUID, start, end time.Time) ([]models.Ping, error) {
+func (db *DB) ListPingsByTimeRange(endpointID uuid.UUID, region string, start, end time.Time) ([]models.Ping, error) {
 	var pings []models.Ping
+	args := []interface{}{endpointID, region, start, end}
 	query := `
 		SELECT * FROM pings 
-		WHERE endpoint_id = $1 AND created_at >= $2 AND created_at <= $3
+		WHERE endpoint_id = $1 AND ($2 = '' OR region = $2) AND created_at >= $3 AND created_at <= $4
+		  AND ` + db.endpointScope("endpoint_id", &args) + `
 		ORDER BY created_at DESC
 	`
-	err := db.Select(&pings, query, endpointID, start, end)
+	err := db.Select(&pings, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pings by time range: %w", err)
 	}
@@ -56,7 +74,8 @@ func (db *DB) ListPingsByTimeRange(endpointID uuid.UUID, start, end time.Time) (
 }
 
 func (db *DB) DeleteOldPings(before time.Time) error {
-	query := `DELETE FROM pings WHERE created_at < $1`
-	_, err := db.Exec(query, before)
+	args := []interface{}{before}
+	query := `DELETE FROM pings WHERE created_at < $1 AND ` + db.endpointScope("endpoint_id", &args)
+	_, err := db.Exec(query, args...)
 	return err
 }
\ No newline at end of file
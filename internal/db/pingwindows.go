@@ -12,52 +12,66 @@ func (db *DB) CreatePingWindow(window *models.PingWindow) error {
 	window.ID = uuid.New()
 	window.CreatedAt = time.Now()
 
+	if err := db.requireEndpoint(window.EndpointID); err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO ping_windows 
 		(id, endpoint_id, window_start, window_end, total_pings, success_pings, 
-		 avg_response_ms, min_response_ms, max_response_ms, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 avg_response_ms, min_response_ms, max_response_ms, region,
+		 avg_dns_ms, avg_connect_ms, avg_tls_ms, avg_ttfb_ms, avg_transfer_ms, avg_query_ms,
+		 avg_handshake_ms, avg_first_message_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 	`
 	_, err := db.Exec(query, 
 		window.ID, window.EndpointID, window.WindowStart, window.WindowEnd,
 		window.TotalPings, window.SuccessPings, window.AvgResponseMs,
-		window.MinResponseMs, window.MaxResponseMs, window.CreatedAt)
+		window.MinResponseMs, window.MaxResponseMs, window.Region,
+		window.AvgDNSMs, window.AvgConnectMs, window.AvgTLSMs, window.AvgTTFBMs, window.AvgTransferMs, window.AvgQueryMs,
+		window.AvgHandshakeMs, window.AvgFirstMessageMs, window.CreatedAt)
 	return err
 }
 
 func (db *DB) GetPingWindow(id uuid.UUID) (*models.PingWindow, error) {
 	var window models.PingWindow
-	query := `SELECT * FROM ping_windows WHERE id = $1`
-	err := db.Get(&window, query, id)
+	args := []interface{}{id}
+	query := `SELECT * FROM ping_windows WHERE id = $1 AND ` + db.endpointScope("endpoint_id", &args)
+	err := db.Get(&window, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ping window: %w", err)
 	}
 	return &window, nil
 }
 
-func (db *DB) ListPingWindows(endpointID uuid.UUID, limit int) ([]models.PingWindow, error) {
+// ListPingWindows returns the most recent windows for an endpoint. An empty
+// region returns windows from every region.
+func (db *DB) ListPingWindows(endpointID uuid.UUID, region string, limit int) ([]models.PingWindow, error) {
 	var windows []models.PingWindow
+	args := []interface{}{endpointID, region, limit}
 	query := `
 		SELECT * FROM ping_windows 
-		WHERE endpoint_id = $1 
+		WHERE endpoint_id = $1 AND ($2 = '' OR region = $2) AND ` + db.endpointScope("endpoint_id", &args) + `
 		ORDER BY window_start DESC 
-		LIMIT $2
+		LIMIT $3
 	`
-	err := db.Select(&windows, query, endpointID, limit)
+	err := db.Select(&windows, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list ping windows: %w", err)
 	}
 	return windows, nil
 }
 
-func (db *DB) ListPingWindowsByTimeRange(endpointID uuid.UUID, start, end time.Time) ([]models.PingWindow, error) {
+func (db *DB) ListPingWindowsByTimeRange(endpointID uuid.UUID, region string, start, end time.Time) ([]models.PingWindow, error) {
 	var windows []models.PingWindow
+	args := []interface{}{endpointID, region, start, end}
 	query := `
 		SELECT * FROM ping_windows 
-		WHERE endpoint_id = $1 AND window_start >= $2 AND window_end <= $3
+		WHERE endpoint_id = $1 AND ($2 = '' OR region = $2) AND window_start >= $3 AND window_end <= $4
+		  AND ` + db.endpointScope("endpoint_id", &args) + `
 		ORDER BY window_start DESC
 	`
-	err := db.Select(&windows, query, endpointID, start, end)
+	err := db.Select(&windows, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list ping windows by time range: %w", err)
 	}
@@ -65,7 +79,8 @@ func (db *DB) ListPingWindowsByTimeRange(endpointID uuid.UUID, start, end time.T
 }
 
 func (db *DB) DeleteOldPingWindows(before time.Time) error {
-	query := `DELETE FROM ping_windows WHERE created_at < $1`
-	_, err := db.Exec(query, before)
+	args := []interface{}{before}
+	query := `DELETE FROM ping_windows WHERE created_at < $1 AND ` + db.endpointScope("endpoint_id", &args)
+	_, err := db.Exec(query, args...)
 	return err
 }
\ No newline at end of file
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// CreateProject creates a project. A tenant's view puts it in the tenant's
+// organization.
+func (db *DB) CreateProject(project *models.Project) error {
+	if db.tenant != nil && project.OrganizationID == uuid.Nil {
+		project.OrganizationID = db.tenant.OrganizationID
+	}
+	if _, err := db.GetOrganization(project.OrganizationID); err != nil {
+		return err
+	}
+	project.ID = uuid.New()
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = time.Now()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO projects (id, organization_id, slug, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	if _, err := tx.Exec(query, project.ID, project.OrganizationID, project.Slug, project.Name,
+		project.CreatedAt, project.UpdatedAt); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofProject(project.ID), models.AuditCreate, "project", project.ID, nil, project); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *DB) GetProject(id uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	args := []interface{}{id}
+	query := `SELECT * FROM projects WHERE id = $1 AND deleted_at IS NULL AND ` + db.projectScope("id", &args)
+	err := db.Get(&project, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	return &project, nil
+}
+
+func (db *DB) ListProjects(organizationID *uuid.UUID) ([]models.Project, error) {
+	var projects []models.Project
+	var args []interface{}
+	query := `SELECT * FROM projects WHERE deleted_at IS NULL AND ` + db.projectScope("id", &args)
+	if organizationID != nil {
+		args = append(args, *organizationID)
+		query += fmt.Sprintf(` AND organization_id = $%d`, len(args))
+	}
+	query += ` ORDER BY slug`
+	err := db.Select(&projects, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	return projects, nil
+}
+
+func (db *DB) UpdateProject(project *models.Project) error {
+	before, err := db.GetProject(project.ID)
+	if err != nil {
+		return err
+	}
+	project.UpdatedAt = time.Now()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{project.ID, project.Slug, project.Name, project.UpdatedAt}
+	query := `
+		UPDATE projects
+		SET slug = $2, name = $3, updated_at = $4
+		WHERE id = $1 AND deleted_at IS NULL AND ` + db.projectScope("id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofProject(project.ID), models.AuditUpdate, "project", project.ID, before, project); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteProject removes a project. It fails with ErrNotEmpty while the
+// project has live services.
+func (db *DB) DeleteProject(id uuid.UUID) error {
+	before, err := db.GetProject(id)
+	if err != nil {
+		return err
+	}
+	var services int
+	query := `SELECT COUNT(*) FROM services WHERE project_id = $1 AND deleted_at IS NULL`
+	if err := db.Get(&services, query, id); err != nil {
+		return err
+	}
+	if services > 0 {
+		return fmt.Errorf("%w: project still has %d services", ErrNotEmpty, services)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{id, time.Now()}
+	query = `UPDATE projects SET deleted_at = $2 WHERE id = $1 AND ` + db.projectScope("id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofProject(id), models.AuditDelete, "project", id, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// Secrets belong to an organization: the tenant's, or the default
+// organization for views without a tenant.
+
+// SetSecret creates the named secret or replaces its value.
+func (db *DB) SetSecret(secret *models.Secret) error {
+	orgID, err := db.defaultOrganization()
+	if err != nil {
+		return err
+	}
+	before, err := db.GetSecret(secret.Name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	secret.ID = uuid.New()
+	secret.OrganizationID = orgID
+	secret.CreatedAt = time.Now()
+	secret.UpdatedAt = time.Now()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO secrets (id, organization_id, name, key_id, wrapped_key, ciphertext, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (organization_id, name) DO UPDATE
+		SET key_id = EXCLUDED.key_id, wrapped_key = EXCLUDED.wrapped_key,
+		    ciphertext = EXCLUDED.ciphertext, updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(query,
+		secret.ID, secret.OrganizationID, secret.Name, secret.KeyID, secret.WrappedKey, secret.Ciphertext,
+		secret.CreatedAt, secret.UpdatedAt).Scan(&secret.ID, &secret.CreatedAt)
+	if err != nil {
+		return err
+	}
+	// Values aren't serialized, so a changed value shows as a new key ID at
+	// most
+	action := models.AuditUpdate
+	if before == nil {
+		action = models.AuditCreate
+	}
+	if err := db.audit(tx, ofOrganization(orgID), action, "secret", secret.ID, before, secret); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *DB) GetSecret(name string) (*models.Secret, error) {
+	orgID, err := db.defaultOrganization()
+	if err != nil {
+		return nil, err
+	}
+	var secret models.Secret
+	query := `SELECT * FROM secrets WHERE organization_id = $1 AND name = $2`
+	err = db.Get(&secret, query, orgID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %q: %w", name, err)
+	}
+	return &secret, nil
+}
+
+func (db *DB) ListSecrets() ([]models.Secret, error) {
+	orgID, err := db.defaultOrganization()
+	if err != nil {
+		return nil, err
+	}
+	var secrets []models.Secret
+	query := `SELECT * FROM secrets WHERE organization_id = $1 ORDER BY name`
+	err = db.Select(&secrets, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	return secrets, nil
+}
+
+// DeleteSecret removes a secret outright; encrypted values aren't kept
+// around after deletion.
+func (db *DB) DeleteSecret(name string) error {
+	orgID, err := db.defaultOrganization()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var before models.Secret
+	query := `DELETE FROM secrets WHERE organization_id = $1 AND name = $2 RETURNING *`
+	err = tx.Get(&before, query, orgID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("secret %q not found", name)
+	}
+	if err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofOrganization(orgID), models.AuditDelete, "secret", before.ID, &before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
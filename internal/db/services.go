@@ -8,23 +8,49 @@ import (
 	"github.com/google/uuid"
 )
 
+// CreateService creates a service in its project, or in the default project
+// if it has none.
 func (db *DB) CreateService(service *models.Service) error {
+	if service.ProjectID == uuid.Nil {
+		projectID, err := db.defaultProject()
+		if err != nil {
+			return err
+		}
+		service.ProjectID = projectID
+	} else if _, err := db.GetProject(service.ProjectID); err != nil {
+		return err
+	}
 	service.ID = uuid.New()
 	service.CreatedAt = time.Now()
 	service.UpdatedAt = time.Now()
 
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if service.Slug, err = pickSlug(tx, "service", service.ProjectID, service.ID, service.Slug, service.Name); err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO services (id, name, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO services (id, project_id, slug, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
-	_, err := db.Exec(query, service.ID, service.Name, service.Description, service.CreatedAt, service.UpdatedAt)
-	return err
+	if _, err := tx.Exec(query, service.ID, service.ProjectID, service.Slug, service.Name, service.Description, service.CreatedAt, service.UpdatedAt); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofService(service.ID), models.AuditCreate, "service", service.ID, nil, service); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (db *DB) GetService(id uuid.UUID) (*models.Service, error) {
 	var service models.Service
-	query := `SELECT * FROM services WHERE id = $1 AND deleted_at IS NULL`
-	err := db.Get(&service, query, id)
+	args := []interface{}{id}
+	query := `SELECT * FROM services WHERE id = $1 AND deleted_at IS NULL AND ` + db.projectScope("project_id", &args)
+	err := db.Get(&service, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get service: %w", err)
 	}
@@ -33,28 +59,72 @@ func (db *DB) GetService(id uuid.UUID) (*models.Service, error) {
 
 func (db *DB) ListServices() ([]models.Service, error) {
 	var services []models.Service
-	query := `SELECT * FROM services WHERE deleted_at IS NULL ORDER BY created_at DESC`
-	err := db.Select(&services, query)
+	var args []interface{}
+	query := `SELECT * FROM services WHERE deleted_at IS NULL AND ` + db.projectScope("project_id", &args) + ` ORDER BY created_at DESC`
+	err := db.Select(&services, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list services: %w", err)
 	}
 	return services, nil
 }
 
+// UpdateService saves a service's slug, name and description, keeping the
+// slug if it's left empty. Services can't move between projects.
 func (db *DB) UpdateService(service *models.Service) error {
+	before, err := db.GetService(service.ID)
+	if err != nil {
+		return err
+	}
 	service.UpdatedAt = time.Now()
+	if service.Slug == "" {
+		service.Slug = before.Slug
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if service.Slug != before.Slug {
+		if _, err := pickSlug(tx, "service", before.ProjectID, service.ID, service.Slug, service.Name); err != nil {
+			return err
+		}
+	}
+
+	args := []interface{}{service.ID, service.Name, service.Description, service.UpdatedAt, service.Slug}
 	query := `
 		UPDATE services 
-		SET name = $2, description = $3, updated_at = $4
-		WHERE id = $1 AND deleted_at IS NULL
-	`
-	_, err := db.Exec(query, service.ID, service.Name, service.Description, service.UpdatedAt)
-	return err
+		SET name = $2, description = $3, updated_at = $4, slug = $5
+		WHERE id = $1 AND deleted_at IS NULL AND ` + db.projectScope("project_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofService(service.ID), models.AuditUpdate, "service", service.ID, before, service); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (db *DB) DeleteService(id uuid.UUID) error {
+	before, err := db.GetService(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	query := `UPDATE services SET deleted_at = $2 WHERE id = $1`
-	_, err := db.Exec(query, id, now)
-	return err
-}
\ No newline at end of file
+	args := []interface{}{id, now}
+	query := `UPDATE services SET deleted_at = $2 WHERE id = $1 AND ` + db.projectScope("project_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofService(id), models.AuditDelete, "service", id, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
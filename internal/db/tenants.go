@@ -0,0 +1,228 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrQuotaExceeded is returned when a write would break an
+	// organization's quotas.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrNotEmpty is returned when deleting an organization or project
+	// that still has live services.
+	ErrNotEmpty = errors.New("not empty")
+)
+
+// Tenant identifies an organization and, optionally, one of its projects.
+type Tenant struct {
+	OrganizationID uuid.UUID
+	ProjectID      *uuid.UUID
+}
+
+// ForTenant returns a view of the database that only sees a tenant's
+// records. It shares the connection pool, so only one of them needs
+// closing, and keeps the view's actor.
+func (db *DB) ForTenant(t Tenant) *DB {
+	return &DB{DB: db.DB, tenant: &t, actor: db.actor}
+}
+
+// Tenant returns the tenant the view is limited to, or nil if it sees
+// everything.
+func (db *DB) Tenant() *Tenant {
+	return db.tenant
+}
+
+// The scope helpers return a condition limiting column to the tenant's
+// records, appending their arguments to args. Without a tenant they match
+// everything.
+
+func (db *DB) organizationScope(column string, args *[]interface{}) string {
+	if db.tenant == nil {
+		return "TRUE"
+	}
+	*args = append(*args, db.tenant.OrganizationID)
+	return fmt.Sprintf("%s = $%d", column, len(*args))
+}
+
+func (db *DB) projectScope(column string, args *[]interface{}) string {
+	if db.tenant == nil {
+		return "TRUE"
+	}
+	*args = append(*args, db.tenant.OrganizationID, db.tenant.ProjectID)
+	n := len(*args)
+	return fmt.Sprintf(`%s IN (
+		SELECT id FROM projects WHERE organization_id = $%d AND ($%d::uuid IS NULL OR id = $%d))`, column, n-1, n, n)
+}
+
+func (db *DB) serviceScope(column string, args *[]interface{}) string {
+	if db.tenant == nil {
+		return "TRUE"
+	}
+	return column + " IN (SELECT id FROM services WHERE " + db.projectScope("project_id", args) + ")"
+}
+
+func (db *DB) endpointScope(column string, args *[]interface{}) string {
+	if db.tenant == nil {
+		return "TRUE"
+	}
+	return column + " IN (SELECT id FROM service_endpoints WHERE " + db.serviceScope("service_id", args) + ")"
+}
+
+func (db *DB) userScope(column string, args *[]interface{}) string {
+	if db.tenant == nil {
+		return "TRUE"
+	}
+	return column + " IN (SELECT id FROM users WHERE " + db.organizationScope("organization_id", args) + ")"
+}
+
+// requireService checks that a tenant's view can see a service before
+// writing records for it. Views without a tenant skip the check.
+func (db *DB) requireService(id uuid.UUID) error {
+	if db.tenant == nil {
+		return nil
+	}
+	_, err := db.GetService(id)
+	return err
+}
+
+// requireEndpoint is requireService for records that belong to endpoints.
+func (db *DB) requireEndpoint(id uuid.UUID) error {
+	if db.tenant == nil {
+		return nil
+	}
+	_, err := db.GetEndpoint(id)
+	return err
+}
+
+// ResolveTenant looks up a tenant by slug: an organization, or an
+// organization and project as org/project.
+func (db *DB) ResolveTenant(ref string) (Tenant, error) {
+	orgSlug, projectSlug, hasProject := strings.Cut(ref, "/")
+	org, err := db.GetOrganizationBySlug(orgSlug)
+	if err != nil {
+		return Tenant{}, err
+	}
+	t := Tenant{OrganizationID: org.ID}
+	if hasProject {
+		var id uuid.UUID
+		query := `SELECT id FROM projects WHERE organization_id = $1 AND slug = $2 AND deleted_at IS NULL`
+		if err := db.Get(&id, query, org.ID, projectSlug); err != nil {
+			return Tenant{}, fmt.Errorf("failed to get project %s: %w", ref, err)
+		}
+		t.ProjectID = &id
+	}
+	return t, nil
+}
+
+// defaultProject returns the project new services go into when none is
+// given: the tenant's project, or else the default project of the tenant's
+// organization or, without a tenant, of the default organization.
+func (db *DB) defaultProject() (uuid.UUID, error) {
+	if db.tenant != nil && db.tenant.ProjectID != nil {
+		return *db.tenant.ProjectID, nil
+	}
+	var id uuid.UUID
+	var args []interface{}
+	query := `
+		SELECT p.id FROM projects p
+		JOIN organizations o ON o.id = p.organization_id
+		WHERE p.slug = 'default' AND p.deleted_at IS NULL AND o.deleted_at IS NULL AND `
+	if db.tenant != nil {
+		query += db.organizationScope("o.id", &args)
+	} else {
+		query += "o.slug = 'default'"
+	}
+	if err := db.Get(&id, query, args...); err != nil {
+		return uuid.Nil, fmt.Errorf("no project given and no default project: %w", err)
+	}
+	return id, nil
+}
+
+// defaultOrganization returns the tenant's organization, or the default
+// organization without a tenant.
+func (db *DB) defaultOrganization() (uuid.UUID, error) {
+	if db.tenant != nil {
+		return db.tenant.OrganizationID, nil
+	}
+	org, err := db.GetOrganizationBySlug(models.DefaultSlug)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return org.ID, nil
+}
+
+// ServiceOrganization returns the organization a service belongs to, even
+// if it has been deleted.
+func (db *DB) ServiceOrganization(serviceID uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	query := `
+		SELECT p.organization_id FROM services s
+		JOIN projects p ON p.id = s.project_id
+		WHERE s.id = $1
+	`
+	if err := db.Get(&id, query, serviceID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get organization of service %s: %w", serviceID, err)
+	}
+	return id, nil
+}
+
+// EndpointOrganization returns the organization an endpoint belongs to,
+// even if it has been deleted.
+func (db *DB) EndpointOrganization(endpointID uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	query := `
+		SELECT p.organization_id FROM service_endpoints e
+		JOIN services s ON s.id = e.service_id
+		JOIN projects p ON p.id = s.project_id
+		WHERE e.id = $1
+	`
+	if err := db.Get(&id, query, endpointID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get organization of endpoint %s: %w", endpointID, err)
+	}
+	return id, nil
+}
+
+// checkQuota enforces the quotas of the organization owning a service on
+// an endpoint checked every intervalSec, counting it against MaxEndpoints
+// if adding. The organization row stays locked until tx ends, so
+// concurrent creates can't both slip under the limit.
+func checkQuota(tx *sqlx.Tx, serviceID uuid.UUID, intervalSec int, adding bool) error {
+	var org models.Organization
+	query := `
+		SELECT o.* FROM organizations o
+		JOIN projects p ON p.organization_id = o.id
+		JOIN services s ON s.project_id = p.id
+		WHERE s.id = $1
+		FOR UPDATE OF o
+	`
+	if err := tx.Get(&org, query, serviceID); err != nil {
+		return fmt.Errorf("failed to get organization of service %s: %w", serviceID, err)
+	}
+
+	if org.MinIntervalSec > 0 && intervalSec < org.MinIntervalSec {
+		return fmt.Errorf("%w: organization %s checks at most every %ds", ErrQuotaExceeded, org.Slug, org.MinIntervalSec)
+	}
+	if !adding || org.MaxEndpoints == 0 {
+		return nil
+	}
+	var count int
+	query = `
+		SELECT COUNT(*) FROM service_endpoints e
+		JOIN services s ON s.id = e.service_id
+		JOIN projects p ON p.id = s.project_id
+		WHERE p.organization_id = $1 AND e.deleted_at IS NULL AND s.deleted_at IS NULL
+	`
+	if err := tx.Get(&count, query, org.ID); err != nil {
+		return err
+	}
+	if count >= org.MaxEndpoints {
+		return fmt.Errorf("%w: organization %s allows at most %d endpoints", ErrQuotaExceeded, org.Slug, org.MaxEndpoints)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// apiTokenPrefix marks Beacon API tokens so they're recognisable, e.g. by
+// secret scanners.
+const apiTokenPrefix = "bcn_"
+
+// HashAPIToken returns the hash stored for a token.
+func HashAPIToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// IssueAPIToken generates a new token for t.UserID and stores its hash. The
+// token itself is returned and can't be recovered later.
+func (db *DB) IssueAPIToken(t *models.APIToken) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	t.ID = uuid.New()
+	t.Prefix = token[:len(apiTokenPrefix)+8]
+	t.Hash = HashAPIToken(token)
+	t.CreatedAt = time.Now()
+	if t.Scopes == nil {
+		t.Scopes = []string{}
+	}
+	if db.tenant != nil {
+		if _, err := db.GetUser(t.UserID); err != nil {
+			return "", err
+		}
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO api_tokens (id, user_id, name, prefix, token_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = tx.Exec(query, t.ID, t.UserID, t.Name, t.Prefix, t.Hash, t.Scopes, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+	if err := db.audit(tx, ofUser(t.UserID), models.AuditCreate, "token", t.ID, nil, t); err != nil {
+		return "", err
+	}
+	return token, tx.Commit()
+}
+
+// GetAPITokenByHash returns the token with the given hash if it belongs to
+// a live user, whether or not it is still active. It returns sql.ErrNoRows
+// if there is none. It is used to authenticate, so it ignores the tenant.
+func (db *DB) GetAPITokenByHash(hash []byte) (*models.APIToken, error) {
+	var token models.APIToken
+	query := `
+		SELECT t.* FROM api_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND u.deleted_at IS NULL
+	`
+	err := db.Get(&token, query, hash)
+	if err != nil {
+		return nil, err // May return sql.ErrNoRows
+	}
+	return &token, nil
+}
+
+func (db *DB) GetAPIToken(id uuid.UUID) (*models.APIToken, error) {
+	var token models.APIToken
+	args := []interface{}{id}
+	query := `SELECT * FROM api_tokens WHERE id = $1 AND ` + db.userScope("user_id", &args)
+	err := db.Get(&token, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return &token, nil
+}
+
+// ListAPITokens returns the unrevoked tokens of a user, or of every user if
+// userID is nil.
+func (db *DB) ListAPITokens(userID *uuid.UUID) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	var args []interface{}
+	query := `SELECT * FROM api_tokens WHERE revoked_at IS NULL AND ` + db.userScope("user_id", &args)
+
+	if userID != nil {
+		args = append(args, *userID)
+		query += fmt.Sprintf(` AND user_id = $%d`, len(args))
+	}
+	query += ` ORDER BY created_at DESC`
+
+	err := db.Select(&tokens, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (db *DB) RevokeAPIToken(id uuid.UUID) error {
+	before, err := db.GetAPIToken(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{id, time.Now()}
+	query := `UPDATE api_tokens SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL AND ` + db.userScope("user_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofUser(before.UserID), models.AuditDelete, "token", id, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TouchAPIToken records when a token was last used.
+func (db *DB) TouchAPIToken(id uuid.UUID, at time.Time) error {
+	query := `UPDATE api_tokens SET last_used_at = $2 WHERE id = $1`
+	_, err := db.Exec(query, id, at)
+	return err
+}
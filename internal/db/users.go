@@ -0,0 +1,230 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// CreateUser creates a user. A tenant's view puts them in the tenant's
+// organization.
+func (db *DB) CreateUser(user *models.User) error {
+	if db.tenant != nil {
+		user.OrganizationID = &db.tenant.OrganizationID
+	}
+	user.ID = uuid.New()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO users (id, organization_id, email, name, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	if _, err := tx.Exec(query, user.ID, user.OrganizationID, user.Email, user.Name, user.Role, user.CreatedAt, user.UpdatedAt); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofUser(user.ID), models.AuditCreate, "user", user.ID, nil, user); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetUser returns a live user with their service grants.
+func (db *DB) GetUser(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	args := []interface{}{id}
+	query := `SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL AND ` + db.organizationScope("organization_id", &args)
+	if err := db.Get(&user, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if err := db.Select(&user.Grants, `SELECT * FROM service_grants WHERE user_id = $1 ORDER BY created_at`, id); err != nil {
+		return nil, fmt.Errorf("failed to get grants: %w", err)
+	}
+	return &user, nil
+}
+
+// GetUserByEmail looks a live user up by email, ignoring case.
+func (db *DB) GetUserByEmail(email string) (*models.User, error) {
+	var id uuid.UUID
+	args := []interface{}{email}
+	query := `SELECT id FROM users WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL AND ` +
+		db.organizationScope("organization_id", &args)
+	if err := db.Get(&id, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", email, err)
+	}
+	return db.GetUser(id)
+}
+
+func (db *DB) ListUsers() ([]models.User, error) {
+	var users []models.User
+	var args []interface{}
+	query := `SELECT * FROM users WHERE deleted_at IS NULL AND ` + db.organizationScope("organization_id", &args) + ` ORDER BY email`
+	if err := db.Select(&users, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	var grants []models.ServiceGrant
+	args = nil
+	query = `SELECT * FROM service_grants WHERE ` + db.userScope("user_id", &args) + ` ORDER BY created_at`
+	if err := db.Select(&grants, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list grants: %w", err)
+	}
+	byUser := make(map[uuid.UUID][]models.ServiceGrant)
+	for _, grant := range grants {
+		byUser[grant.UserID] = append(byUser[grant.UserID], grant)
+	}
+	for i := range users {
+		users[i].Grants = byUser[users[i].ID]
+	}
+	return users, nil
+}
+
+// UpdateUser saves a user. A tenant's view keeps them in the tenant's
+// organization.
+func (db *DB) UpdateUser(user *models.User) error {
+	before, err := db.GetUser(user.ID)
+	if err != nil {
+		return err
+	}
+	if db.tenant != nil {
+		user.OrganizationID = &db.tenant.OrganizationID
+	}
+	user.UpdatedAt = time.Now()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{user.ID, user.OrganizationID, user.Email, user.Name, user.Role, user.UpdatedAt}
+	query := `
+		UPDATE users
+		SET organization_id = $2, email = $3, name = $4, role = $5, updated_at = $6
+		WHERE id = $1 AND deleted_at IS NULL AND ` + db.organizationScope("organization_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	// Grants only change through SetServiceGrant and DeleteServiceGrant
+	after := *user
+	after.Grants = before.Grants
+	if err := db.audit(tx, ofUser(user.ID), models.AuditUpdate, "user", user.ID, before, &after); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteUser removes a user and revokes their tokens.
+func (db *DB) DeleteUser(id uuid.UUID) error {
+	before, err := db.GetUser(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET deleted_at = $2 WHERE id = $1`, id, now); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE api_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`, id, now); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofUser(id), models.AuditDelete, "user", id, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetServiceGrant gives a user a role on a service, replacing any role they
+// already had on it. The service must be in the user's organization.
+func (db *DB) SetServiceGrant(grant *models.ServiceGrant) error {
+	user, err := db.GetUser(grant.UserID)
+	if err != nil {
+		return err
+	}
+	if err := db.requireService(grant.ServiceID); err != nil {
+		return err
+	}
+	if user.OrganizationID != nil {
+		orgID, err := db.ServiceOrganization(grant.ServiceID)
+		if err != nil {
+			return err
+		}
+		if orgID != *user.OrganizationID {
+			return fmt.Errorf("service %s is in another organization", grant.ServiceID)
+		}
+	}
+
+	grant.CreatedAt = time.Now()
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO service_grants (user_id, service_id, role, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, service_id) DO UPDATE SET role = EXCLUDED.role
+	`
+	if _, err := tx.Exec(query, grant.UserID, grant.ServiceID, grant.Role, grant.CreatedAt); err != nil {
+		return err
+	}
+	before, action := findGrant(user.Grants, grant.ServiceID), models.AuditUpdate
+	if before == nil {
+		action = models.AuditCreate
+	}
+	if err := db.audit(tx, ofUser(user.ID), action, "grant", user.ID, before, grant); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteServiceGrant takes a user's role on a service away. Grants are
+// recorded in the audit log under the user's ID.
+func (db *DB) DeleteServiceGrant(userID, serviceID uuid.UUID) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var before models.ServiceGrant
+	args := []interface{}{userID, serviceID}
+	query := `DELETE FROM service_grants WHERE user_id = $1 AND service_id = $2 AND ` + db.userScope("user_id", &args) +
+		` RETURNING *`
+	err = tx.Get(&before, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofUser(userID), models.AuditDelete, "grant", userID, &before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func findGrant(grants []models.ServiceGrant, serviceID uuid.UUID) *models.ServiceGrant {
+	for i := range grants {
+		if grants[i].ServiceID == serviceID {
+			return &grants[i]
+		}
+	}
+	return nil
+}
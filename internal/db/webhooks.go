@@ -14,22 +14,39 @@ func (db *DB) CreateWebhook(webhook *models.Webhook) error {
 	webhook.CreatedAt = time.Now()
 	webhook.UpdatedAt = time.Now()
 
+	if err := db.requireService(webhook.ServiceID); err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO webhooks 
 		(id, service_id, name, url, events, headers, enabled, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
-	_, err := db.Exec(query, 
+	_, err = tx.Exec(query, 
 		webhook.ID, webhook.ServiceID, webhook.Name, webhook.URL,
 		pq.Array(webhook.Events), webhook.Headers, webhook.Enabled,
 		webhook.CreatedAt, webhook.UpdatedAt)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofService(webhook.ServiceID), models.AuditCreate, "webhook", webhook.ID, nil, webhook.Redacted()); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (db *DB) GetWebhook(id uuid.UUID) (*models.Webhook, error) {
 	var webhook models.Webhook
-	query := `SELECT * FROM webhooks WHERE id = $1 AND deleted_at IS NULL`
-	err := db.Get(&webhook, query, id)
+	args := []interface{}{id}
+	query := `SELECT * FROM webhooks WHERE id = $1 AND deleted_at IS NULL AND ` + db.serviceScope("service_id", &args)
+	err := db.Get(&webhook, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get webhook: %w", err)
 	}
@@ -38,15 +55,14 @@ func (db *DB) GetWebhook(id uuid.UUID) (*models.Webhook, error) {
 
 func (db *DB) ListWebhooks(serviceID *uuid.UUID) ([]models.Webhook, error) {
 	var webhooks []models.Webhook
-	var query string
 	var args []interface{}
+	query := `SELECT * FROM webhooks WHERE deleted_at IS NULL AND ` + db.serviceScope("service_id", &args)
 
 	if serviceID != nil {
-		query = `SELECT * FROM webhooks WHERE service_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC`
 		args = append(args, *serviceID)
-	} else {
-		query = `SELECT * FROM webhooks WHERE deleted_at IS NULL ORDER BY created_at DESC`
+		query += fmt.Sprintf(` AND service_id = $%d`, len(args))
 	}
+	query += ` ORDER BY created_at DESC`
 
 	err := db.Select(&webhooks, query, args...)
 	if err != nil {
@@ -57,11 +73,12 @@ func (db *DB) ListWebhooks(serviceID *uuid.UUID) ([]models.Webhook, error) {
 
 func (db *DB) ListEnabledWebhooks(serviceID uuid.UUID, event string) ([]models.Webhook, error) {
 	var webhooks []models.Webhook
+	args := []interface{}{serviceID, event}
 	query := `
 		SELECT * FROM webhooks 
-		WHERE service_id = $1 AND enabled = true AND $2 = ANY(events) AND deleted_at IS NULL
-	`
-	err := db.Select(&webhooks, query, serviceID, event)
+		WHERE service_id = $1 AND enabled = true AND $2 = ANY(events) AND deleted_at IS NULL AND ` +
+		db.serviceScope("service_id", &args)
+	err := db.Select(&webhooks, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list enabled webhooks: %w", err)
 	}
@@ -69,21 +86,55 @@ func (db *DB) ListEnabledWebhooks(serviceID uuid.UUID, event string) ([]models.W
 }
 
 func (db *DB) UpdateWebhook(webhook *models.Webhook) error {
+	before, err := db.GetWebhook(webhook.ID)
+	if err != nil {
+		return err
+	}
 	webhook.UpdatedAt = time.Now()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{
+		webhook.ID, webhook.Name, webhook.URL, pq.Array(webhook.Events),
+		webhook.Headers, webhook.Enabled, webhook.UpdatedAt,
+	}
 	query := `
 		UPDATE webhooks 
 		SET name = $2, url = $3, events = $4, headers = $5, enabled = $6, updated_at = $7
-		WHERE id = $1 AND deleted_at IS NULL
-	`
-	_, err := db.Exec(query, 
-		webhook.ID, webhook.Name, webhook.URL, pq.Array(webhook.Events),
-		webhook.Headers, webhook.Enabled, webhook.UpdatedAt)
-	return err
+		WHERE id = $1 AND deleted_at IS NULL AND ` + db.serviceScope("service_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofService(before.ServiceID), models.AuditUpdate, "webhook", webhook.ID, before.Redacted(), webhook.Redacted()); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (db *DB) DeleteWebhook(id uuid.UUID) error {
+	before, err := db.GetWebhook(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	query := `UPDATE webhooks SET deleted_at = $2 WHERE id = $1`
-	_, err := db.Exec(query, id, now)
-	return err
+	args := []interface{}{id, now}
+	query := `UPDATE webhooks SET deleted_at = $2 WHERE id = $1 AND ` + db.serviceScope("service_id", &args)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := db.audit(tx, ofService(before.ServiceID), models.AuditDelete, "webhook", id, before.Redacted(), nil); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
\ No newline at end of file
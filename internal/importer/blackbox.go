@@ -0,0 +1,327 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+)
+
+// prometheusConfig is the part of a Prometheus config an import reads.
+type prometheusConfig struct {
+	Global struct {
+		ScrapeInterval string `json:"scrape_interval"`
+		ScrapeTimeout  string `json:"scrape_timeout"`
+	} `json:"global"`
+	ScrapeConfigs []struct {
+		JobName        string              `json:"job_name"`
+		MetricsPath    string              `json:"metrics_path"`
+		ScrapeInterval string              `json:"scrape_interval"`
+		ScrapeTimeout  string              `json:"scrape_timeout"`
+		Params         map[string][]string `json:"params"`
+		StaticConfigs  []struct {
+			Targets []string `json:"targets"`
+		} `json:"static_configs"`
+		FileSDConfigs   []interface{} `json:"file_sd_configs"`
+		HTTPSDConfigs   []interface{} `json:"http_sd_configs"`
+		DNSSDConfigs    []interface{} `json:"dns_sd_configs"`
+		ConsulSDConfigs []interface{} `json:"consul_sd_configs"`
+		KubernetesSD    []interface{} `json:"kubernetes_sd_configs"`
+	} `json:"scrape_configs"`
+}
+
+// blackboxConfig is the part of a blackbox_exporter config an import reads.
+type blackboxConfig struct {
+	Modules map[string]blackboxModule `json:"modules"`
+}
+
+type blackboxModule struct {
+	Prober  string `json:"prober"`
+	Timeout string `json:"timeout"`
+	HTTP    struct {
+		Method                      string            `json:"method"`
+		Headers                     map[string]string `json:"headers"`
+		Body                        string            `json:"body"`
+		ValidStatusCodes            []int             `json:"valid_status_codes"`
+		NoFollowRedirects           bool              `json:"no_follow_redirects"`
+		FailIfBodyMatchesRegexp     []string          `json:"fail_if_body_matches_regexp"`
+		FailIfBodyNotMatchesRegexp  []string          `json:"fail_if_body_not_matches_regexp"`
+		FailIfHeaderMatchesRegexp   []interface{}     `json:"fail_if_header_matches_regexp"`
+		FailIfHeaderNotMatchesRegex []interface{}     `json:"fail_if_header_not_matches_regexp"`
+		BasicAuth                   *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"basic_auth"`
+		BearerToken string `json:"bearer_token"`
+		TLSConfig   struct {
+			InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+			ServerName         string `json:"server_name"`
+		} `json:"tls_config"`
+	} `json:"http"`
+	TCP struct {
+		QueryResponse []interface{} `json:"query_response"`
+		TLS           bool          `json:"tls"`
+	} `json:"tcp"`
+	DNS struct {
+		QueryName string `json:"query_name"`
+		QueryType string `json:"query_type"`
+	} `json:"dns"`
+	GRPC struct {
+		Service string `json:"service"`
+		TLS     bool   `json:"tls"`
+	} `json:"grpc"`
+}
+
+// alertmanagerConfig is the part of an Alertmanager config an import reads.
+type alertmanagerConfig struct {
+	Receivers []map[string]interface{} `json:"receivers"`
+}
+
+// Blackbox maps the blackbox_exporter jobs in a Prometheus config onto
+// Beacon: each job becomes a service, with an endpoint per static target.
+// The blackbox_exporter config, if given, says what each module checks;
+// without it modules are guessed from their names. The webhook receivers
+// in an Alertmanager config, if given, become webhooks of every service.
+func Blackbox(prometheus, modules, alertmanager []byte) (*Migration, error) {
+	var config prometheusConfig
+	if err := decode(prometheus, &config); err != nil {
+		return nil, fmt.Errorf("invalid Prometheus config: %w", err)
+	}
+	var blackbox blackboxConfig
+	if modules != nil {
+		if err := decode(modules, &blackbox); err != nil {
+			return nil, fmt.Errorf("invalid blackbox_exporter config: %w", err)
+		}
+	}
+
+	m := &Migration{}
+	interval := promDuration(config.Global.ScrapeInterval, time.Minute)
+	timeout := promDuration(config.Global.ScrapeTimeout, 10*time.Second)
+	guessed := map[string]bool{}
+	for _, job := range config.ScrapeConfigs {
+		source := fmt.Sprintf("job %q", job.JobName)
+		if job.MetricsPath != "/probe" && len(job.Params["module"]) == 0 {
+			m.note("%s: skipped: not a blackbox_exporter job", source)
+			continue
+		}
+		if len(job.FileSDConfigs)+len(job.HTTPSDConfigs)+len(job.DNSSDConfigs)+len(job.ConsulSDConfigs)+len(job.KubernetesSD) > 0 {
+			m.note("%s: only static_configs targets are imported; targets from service discovery aren't", source)
+		}
+
+		moduleName := "http_2xx"
+		if names := job.Params["module"]; len(names) > 0 {
+			moduleName = names[0]
+		}
+		module, found := blackbox.Modules[moduleName]
+		if !found {
+			module = guessModule(moduleName)
+			guessed[moduleName] = true
+		}
+
+		jobInterval := promDuration(job.ScrapeInterval, interval)
+		jobTimeout := promDuration(module.Timeout, promDuration(job.ScrapeTimeout, timeout))
+		for _, static := range job.StaticConfigs {
+			for _, target := range static.Targets {
+				endpoint, ok := blackboxEndpoint(target, module, fmt.Sprintf("%s target %s", source, target), m)
+				if !ok {
+					continue
+				}
+				endpoint.IntervalSec = int(jobInterval.Seconds())
+				endpoint.TimeoutMs = int(jobTimeout.Milliseconds())
+				m.addEndpoint(job.JobName, fmt.Sprintf("%s target %s", source, target), endpoint)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(guessed))
+	for name := range guessed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m.note("module %q: not in a blackbox_exporter config, so guessed from its name as a %s probe", name, guessModule(name).Prober)
+	}
+
+	if alertmanager != nil {
+		if err := alertmanagerWebhooks(alertmanager, m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// guessModule assumes what a module checks from names like the ones in
+// blackbox_exporter's example config.
+func guessModule(name string) blackboxModule {
+	var module blackboxModule
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "icmp"), strings.Contains(lower, "ping"):
+		module.Prober = "icmp"
+	case strings.Contains(lower, "tcp"), strings.Contains(lower, "ssh"), strings.Contains(lower, "irc"):
+		module.Prober = "tcp"
+	case strings.Contains(lower, "dns"):
+		module.Prober = "dns"
+	case strings.Contains(lower, "grpc"):
+		module.Prober = "grpc"
+	default:
+		module.Prober = "http"
+	}
+	if strings.Contains(lower, "post") {
+		module.HTTP.Method = "POST"
+	}
+	return module
+}
+
+// blackboxEndpoint maps a target probed with a module, reporting what
+// doesn't carry over.
+func blackboxEndpoint(target string, module blackboxModule, source string, m *Migration) (models.ServiceEndpoint, bool) {
+	endpoint := newEndpoint(target, target)
+
+	switch module.Prober {
+	case "http":
+		if !strings.Contains(target, "://") {
+			endpoint.URL = "http://" + target
+		}
+		h := module.HTTP
+		if h.Method != "" {
+			endpoint.Method = strings.ToUpper(h.Method)
+		}
+		endpoint.Body = h.Body
+		for name, value := range h.Headers {
+			endpoint.Headers[name] = value
+		}
+		if len(h.ValidStatusCodes) > 0 {
+			endpoint.ExpectedCode = h.ValidStatusCodes[0]
+			if len(h.ValidStatusCodes) > 1 {
+				m.note("%s: accepts status %v; Beacon expects exactly %d", source, h.ValidStatusCodes, endpoint.ExpectedCode)
+			}
+		}
+		endpoint.FollowRedirects = !h.NoFollowRedirects
+		switch {
+		case h.BasicAuth != nil:
+			endpoint.Auth = &models.AuthConfig{Type: models.AuthBasic, Username: h.BasicAuth.Username, Password: h.BasicAuth.Password}
+		case h.BearerToken != "":
+			endpoint.Auth = &models.AuthConfig{Type: models.AuthBearer, Token: h.BearerToken}
+		}
+		if h.TLSConfig.InsecureSkipVerify || h.TLSConfig.ServerName != "" {
+			endpoint.TLS = &models.TLSConfig{InsecureSkipVerify: h.TLSConfig.InsecureSkipVerify, ServerName: h.TLSConfig.ServerName}
+		}
+		if len(h.FailIfBodyMatchesRegexp) > 0 {
+			m.note("%s: left out fail_if_body_matches_regexp; Beacon can't check that the body doesn't match", source)
+		}
+		if len(h.FailIfHeaderMatchesRegexp)+len(h.FailIfHeaderNotMatchesRegex) > 0 {
+			m.note("%s: left out the header regexp checks", source)
+		}
+		if patterns := h.FailIfBodyNotMatchesRegexp; len(patterns) > 0 {
+			endpoint = bodyCheck(endpoint, models.Assertion{Source: models.SourceRegex, Expression: patterns[0], Operator: "exists"})
+			if len(patterns) > 1 {
+				m.note("%s: only the first of fail_if_body_not_matches_regexp is checked", source)
+			}
+		}
+	case "tcp":
+		endpoint.CheckType = models.CheckTCP
+		if module.TCP.TLS {
+			endpoint.CheckType = models.CheckTLS
+		}
+		if len(module.TCP.QueryResponse) > 0 {
+			m.note("%s: left out query_response; set the tcp check's send and expect by hand", source)
+		}
+	case "dns":
+		if module.DNS.QueryName == "" {
+			m.note("%s: not imported: the module doesn't say what name to look up", source)
+			return endpoint, false
+		}
+		// blackbox_exporter's target is the nameserver to ask
+		endpoint.Name = module.DNS.QueryName + " @ " + target
+		endpoint.CheckType = models.CheckDNS
+		endpoint.URL = module.DNS.QueryName
+		endpoint.Check.Nameserver = target
+		endpoint.Check.RecordType = strings.ToUpper(module.DNS.QueryType)
+	case "grpc":
+		endpoint.CheckType = models.CheckGRPC
+		endpoint.Check.GRPCService = module.GRPC.Service
+		endpoint.Check.GRPCPlaintext = !module.GRPC.TLS
+	default:
+		m.note("%s: not imported: Beacon has no %s check", source, module.Prober)
+		return endpoint, false
+	}
+	return endpoint, true
+}
+
+// alertmanagerWebhooks adds the webhook receivers to every service and
+// reports the other kinds.
+func alertmanagerWebhooks(data []byte, m *Migration) error {
+	var config alertmanagerConfig
+	if err := decode(data, &config); err != nil {
+		return fmt.Errorf("invalid Alertmanager config: %w", err)
+	}
+
+	var webhooks []models.Webhook
+	for _, receiver := range config.Receivers {
+		name, _ := receiver["name"].(string)
+		kinds := make([]string, 0, len(receiver))
+		for kind := range receiver {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			switch kind {
+			case "name":
+			case "webhook_configs":
+				configs, _ := receiver[kind].([]interface{})
+				for i, c := range configs {
+					c, _ := c.(map[string]interface{})
+					url, _ := c["url"].(string)
+					if url == "" {
+						m.note("receiver %q: webhook %d not imported: it has no url", name, i+1)
+						continue
+					}
+					webhook := models.NewWebhook()
+					webhook.Name = name
+					webhook.URL = url
+					if resolved, ok := c["send_resolved"].(bool); ok && !resolved {
+						webhook.Events = []string{"incident_start"}
+					}
+					webhooks = append(webhooks, webhook)
+				}
+			default:
+				m.note("receiver %q: %s not imported: Beacon only sends generic JSON webhooks", name, kind)
+			}
+		}
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+	m.note("Alertmanager routes aren't mapped: every webhook receiver is added to every service")
+	for _, s := range m.Manifest.Services {
+		for _, webhook := range webhooks {
+			m.addWebhook(s.Name, webhook)
+		}
+	}
+	return nil
+}
+
+var promDurationPart = regexp.MustCompile(`(\d+)(ms|y|w|d|h|m|s)`)
+
+// promDuration parses a Prometheus duration such as 1m30s or 1d, returning
+// fallback if it's empty or invalid.
+func promDuration(s string, fallback time.Duration) time.Duration {
+	if s == "" || promDurationPart.ReplaceAllString(s, "") != "" {
+		return fallback
+	}
+	units := map[string]time.Duration{
+		"ms": time.Millisecond, "s": time.Second, "m": time.Minute, "h": time.Hour,
+		"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour,
+	}
+	var d time.Duration
+	for _, part := range promDurationPart.FindAllStringSubmatch(s, -1) {
+		n, _ := strconv.Atoi(part[1])
+		d += time.Duration(n) * units[part[2]]
+	}
+	return d
+}
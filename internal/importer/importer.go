@@ -0,0 +1,134 @@
+// Package importer turns API descriptions from other tools, such as OpenAPI
+// specs and Postman collections, into endpoints to monitor.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/beacon/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Options narrows and adjusts what is imported.
+type Options struct {
+	// BaseURL, if set, replaces the URL requests are sent to: an OpenAPI
+	// spec's server URL, or the scheme and host of Postman requests.
+	BaseURL string
+	// Tags keeps only operations with one of these tags, or Postman
+	// requests in a folder with one of these names. Matching ignores case.
+	Tags []string
+	// Paths keeps only operations whose path starts with one of these.
+	Paths []string
+}
+
+// Result is what an import produced.
+type Result struct {
+	Endpoints []models.ServiceEndpoint
+	// Skipped lists what passed the filters but couldn't be imported.
+	Skipped []Skipped
+	// Warnings are about endpoints imported with something left out.
+	Warnings []string
+}
+
+// Skipped is an operation or request that couldn't be imported, and why.
+type Skipped struct {
+	Source string
+	Reason string
+}
+
+func (r *Result) skip(source, format string, args ...interface{}) {
+	r.Skipped = append(r.Skipped, Skipped{Source: source, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (r *Result) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Key identifies an endpoint for deciding whether it already exists: its
+// method and URL.
+func Key(endpoint models.ServiceEndpoint) string {
+	method := strings.ToUpper(endpoint.Method)
+	if method == "" {
+		method = "GET"
+	}
+	return method + " " + endpoint.URL
+}
+
+// decode parses YAML or JSON into v. JSON is valid YAML, so both are read
+// with the YAML parser and then decoded as JSON to use v's JSON tags.
+func decode(data []byte, v interface{}) error {
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return err
+	}
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// matches applies the tag and path filters.
+func (o Options) matches(tags []string, path string) bool {
+	if len(o.Tags) > 0 && !anyTag(o.Tags, tags) {
+		return false
+	}
+	if len(o.Paths) == 0 {
+		return true
+	}
+	for _, prefix := range o.Paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyTag(want, have []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if strings.EqualFold(w, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newEndpoint returns a GET endpoint with the defaults new endpoints get.
+func newEndpoint(name, url string) models.ServiceEndpoint {
+	endpoint := models.NewEndpoint()
+	endpoint.Name = name
+	endpoint.URL = url
+	return endpoint
+}
+
+var codePattern = regexp.MustCompile(`^[23]([0-9]{2}|XX)$`)
+
+// expectedCode picks the status a check should expect from the documented
+// ones: the lowest 2xx or 3xx, with 2XX meaning 200. Without any, it's 200.
+func expectedCode(codes []string) int {
+	best := 0
+	for _, code := range codes {
+		code = strings.ToUpper(code)
+		if !codePattern.MatchString(code) {
+			continue
+		}
+		var n int
+		if strings.HasSuffix(code, "XX") {
+			n = int(code[0]-'0') * 100
+		} else {
+			fmt.Sscanf(code, "%d", &n)
+		}
+		if best == 0 || n < best {
+			best = n
+		}
+	}
+	if best == 0 {
+		return 200
+	}
+	return best
+}
@@ -0,0 +1,130 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/beacon/internal/manifest"
+	"github.com/beacon/internal/models"
+)
+
+// Migration is another monitoring tool's setup mapped onto Beacon: a
+// manifest to review and apply, and a report of what couldn't be mapped.
+type Migration struct {
+	Manifest manifest.Manifest
+	Report   []string
+}
+
+func (m *Migration) note(format string, args ...interface{}) {
+	m.Report = append(m.Report, fmt.Sprintf(format, args...))
+}
+
+// service returns the manifest's service with the name, adding it if it's
+// new. The pointer is only good until the next service is added.
+func (m *Migration) service(name string) *manifest.Service {
+	for i := range m.Manifest.Services {
+		if m.Manifest.Services[i].Name == name {
+			return &m.Manifest.Services[i]
+		}
+	}
+	m.Manifest.Services = append(m.Manifest.Services, manifest.Service{Name: name})
+	return &m.Manifest.Services[len(m.Manifest.Services)-1]
+}
+
+// addEndpoint validates an endpoint and adds it to a service, numbering
+// its name if the service already has one by that name. source names what
+// it was mapped from, for the report.
+func (m *Migration) addEndpoint(service, source string, endpoint models.ServiceEndpoint) {
+	if err := endpoint.Validate(); err != nil {
+		m.note("%s: not imported: %v", source, err)
+		return
+	}
+	s := m.service(service)
+	endpoint.Name = uniqueName(endpoint.Name, func(name string) bool {
+		for _, e := range s.Endpoints {
+			if e.Name == name {
+				return true
+			}
+		}
+		return false
+	})
+	s.Endpoints = append(s.Endpoints, endpoint)
+}
+
+// addWebhook adds a webhook to a service unless it already has one with
+// the same URL.
+func (m *Migration) addWebhook(service string, webhook models.Webhook) {
+	s := m.service(service)
+	for _, w := range s.Webhooks {
+		if w.URL == webhook.URL {
+			return
+		}
+	}
+	webhook.Name = uniqueName(webhook.Name, func(name string) bool {
+		for _, w := range s.Webhooks {
+			if w.Name == name {
+				return true
+			}
+		}
+		return false
+	})
+	s.Webhooks = append(s.Webhooks, webhook)
+}
+
+func uniqueName(name string, taken func(string) bool) string {
+	candidate := name
+	for n := 2; taken(candidate); n++ {
+		candidate = fmt.Sprintf("%s (%d)", name, n)
+	}
+	return candidate
+}
+
+// bodyCheck turns an HTTP endpoint into a one-step transaction that also
+// asserts on the response body, since plain HTTP checks only look at the
+// status.
+func bodyCheck(endpoint models.ServiceEndpoint, assertion models.Assertion) models.ServiceEndpoint {
+	headers := map[string]string{}
+	for name, value := range endpoint.Headers {
+		headers[name] = fmt.Sprint(value)
+	}
+	endpoint.CheckType = models.CheckTransaction
+	endpoint.Check.Steps = []models.TransactionStep{{
+		Name:         endpoint.Name,
+		Method:       endpoint.Method,
+		URL:          endpoint.URL,
+		Headers:      headers,
+		Body:         endpoint.Body,
+		ExpectedCode: endpoint.ExpectedCode,
+		Assert:       []models.Assertion{assertion},
+	}}
+	return endpoint
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// secretName derives a secret name from what it's for, like
+// orders-db-connection for "Orders DB".
+func secretName(name, suffix string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-") + "-" + suffix
+}
+
+// flexBool decodes the booleans some tools write as 0 or 1.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null":
+		*b = false
+	default:
+		var v bool
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*b = flexBool(v)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/beacon/internal/models"
+)
+
+// openAPISpec is the part of an OpenAPI 3 or Swagger 2 document an import
+// reads.
+type openAPISpec struct {
+	Swagger string `json:"swagger"`
+	OpenAPI string `json:"openapi"`
+
+	// OpenAPI 3
+	Servers []struct {
+		URL       string `json:"url"`
+		Variables map[string]struct {
+			Default string `json:"default"`
+		} `json:"variables"`
+	} `json:"servers"`
+	Components struct {
+		Parameters map[string]openAPIParameter `json:"parameters"`
+	} `json:"components"`
+
+	// Swagger 2
+	Host       string                      `json:"host"`
+	BasePath   string                      `json:"basePath"`
+	Schemes    []string                    `json:"schemes"`
+	Parameters map[string]openAPIParameter `json:"parameters"`
+
+	Paths map[string]struct {
+		Parameters []openAPIParameter `json:"parameters"`
+		Get        *openAPIOperation  `json:"get"`
+	} `json:"paths"`
+}
+
+type openAPIOperation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary"`
+	Tags        []string               `json:"tags"`
+	Deprecated  bool                   `json:"deprecated"`
+	Parameters  []openAPIParameter     `json:"parameters"`
+	Responses   map[string]interface{} `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Ref      string        `json:"$ref"`
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required"`
+	Example  interface{}   `json:"example"`
+	XExample interface{}   `json:"x-example"`
+	Default  interface{}   `json:"default"`
+	Enum     []interface{} `json:"enum"`
+	Schema   *struct {
+		Example interface{}   `json:"example"`
+		Default interface{}   `json:"default"`
+		Enum    []interface{} `json:"enum"`
+	} `json:"schema"`
+}
+
+// value returns a value to send for the parameter: its example, default or
+// first allowed value, in that order.
+func (p openAPIParameter) value() (string, bool) {
+	candidates := []interface{}{p.Example, p.XExample, p.Default}
+	if p.Schema != nil {
+		candidates = append(candidates, p.Schema.Example, p.Schema.Default)
+	}
+	if len(p.Enum) > 0 {
+		candidates = append(candidates, p.Enum[0])
+	}
+	if p.Schema != nil && len(p.Schema.Enum) > 0 {
+		candidates = append(candidates, p.Schema.Enum[0])
+	}
+	for _, c := range candidates {
+		if c != nil {
+			return fmt.Sprint(c), true
+		}
+	}
+	return "", false
+}
+
+// OpenAPI reads an OpenAPI 3 or Swagger 2 spec, in YAML or JSON, and returns
+// an endpoint for each GET operation that passes the filters. Path and
+// required query and header parameters are filled in from their examples
+// or defaults; operations with one that has neither are skipped.
+func OpenAPI(data []byte, opts Options) (*Result, error) {
+	var spec openAPISpec
+	if err := decode(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	if spec.OpenAPI == "" && spec.Swagger == "" {
+		return nil, fmt.Errorf("invalid OpenAPI spec: no openapi or swagger version")
+	}
+
+	base, err := spec.baseURL(opts.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Go through paths in order, so imports are repeatable
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	result := &Result{}
+	for _, path := range paths {
+		item := spec.Paths[path]
+		op := item.Get
+		if op == nil || !opts.matches(op.Tags, path) {
+			continue
+		}
+		source := "GET " + path
+		if op.Deprecated {
+			result.skip(source, "deprecated")
+			continue
+		}
+
+		endpoint, reason := spec.endpoint(base, path, item.Parameters, op)
+		if reason != "" {
+			result.skip(source, "%s", reason)
+			continue
+		}
+		result.Endpoints = append(result.Endpoints, endpoint)
+	}
+	return result, nil
+}
+
+// baseURL returns the URL paths are relative to: override if set, or the
+// spec's first server.
+func (s *openAPISpec) baseURL(override string) (string, error) {
+	if override != "" {
+		return strings.TrimRight(override, "/"), nil
+	}
+
+	var base string
+	if s.Swagger != "" {
+		if s.Host != "" {
+			scheme := "https"
+			if len(s.Schemes) > 0 {
+				scheme = s.Schemes[0]
+			}
+			base = scheme + "://" + s.Host + s.BasePath
+		}
+	} else if len(s.Servers) > 0 {
+		server := s.Servers[0]
+		base = server.URL
+		for name, v := range server.Variables {
+			base = strings.ReplaceAll(base, "{"+name+"}", v.Default)
+		}
+	}
+
+	if u, err := url.Parse(base); err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("the spec doesn't give an absolute server URL; set one with --base-url")
+	}
+	return strings.TrimRight(base, "/"), nil
+}
+
+// endpoint builds the endpoint for a GET operation, or says why it can't.
+func (s *openAPISpec) endpoint(base, path string, shared []openAPIParameter, op *openAPIOperation) (models.ServiceEndpoint, string) {
+	name := op.OperationID
+	if name == "" {
+		name = op.Summary
+	}
+	if name == "" {
+		name = "GET " + path
+	}
+
+	// Operation parameters override path-level ones with the same name
+	params := map[string]openAPIParameter{}
+	for _, p := range append(append([]openAPIParameter{}, shared...), op.Parameters...) {
+		resolved, ok := s.resolve(p)
+		if !ok {
+			return models.ServiceEndpoint{}, fmt.Sprintf("parameter reference %s not found", p.Ref)
+		}
+		params[resolved.In+":"+resolved.Name] = resolved
+	}
+
+	names := make([]string, 0, len(params))
+	for key := range params {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	query := url.Values{}
+	headers := models.JSONB{}
+	for _, key := range names {
+		p := params[key]
+		if p.In != "path" && !p.Required {
+			continue
+		}
+		value, ok := p.value()
+		switch p.In {
+		case "path":
+			if !ok {
+				return models.ServiceEndpoint{}, fmt.Sprintf("path parameter %s has no example or default", p.Name)
+			}
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(value))
+		case "query":
+			if !ok {
+				return models.ServiceEndpoint{}, fmt.Sprintf("required query parameter %s has no example or default", p.Name)
+			}
+			query.Set(p.Name, value)
+		case "header":
+			if !ok {
+				return models.ServiceEndpoint{}, fmt.Sprintf("required header %s has no example or default", p.Name)
+			}
+			headers[p.Name] = value
+		}
+	}
+	if strings.Contains(path, "{") {
+		return models.ServiceEndpoint{}, "path has parameters that aren't declared"
+	}
+
+	u := base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	endpoint := newEndpoint(name, u)
+	endpoint.Headers = headers
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	endpoint.ExpectedCode = expectedCode(codes)
+	return endpoint, ""
+}
+
+// resolve follows a parameter's $ref to the spec's shared parameters.
+func (s *openAPISpec) resolve(p openAPIParameter) (openAPIParameter, bool) {
+	if p.Ref == "" {
+		return p, true
+	}
+	if name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/"); ok {
+		shared, found := s.Components.Parameters[name]
+		return shared, found && shared.Ref == ""
+	}
+	if name, ok := strings.CutPrefix(p.Ref, "#/parameters/"); ok {
+		shared, found := s.Parameters[name]
+		return shared, found && shared.Ref == ""
+	}
+	return p, false
+}
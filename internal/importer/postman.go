@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/beacon/internal/models"
+)
+
+// postmanCollection is the part of a Postman v2.1 collection an import
+// reads.
+type postmanCollection struct {
+	Info struct {
+		Name string `json:"name"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+	Auth     *postmanAuth      `json:"auth"`
+}
+
+// postmanItem is a folder, with items of its own, or a request.
+type postmanItem struct {
+	Name     string          `json:"name"`
+	Item     []postmanItem   `json:"item"`
+	Request  *postmanRequest `json:"request"`
+	Response []struct {
+		Code int `json:"code"`
+	} `json:"response"`
+	Auth *postmanAuth `json:"auth"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	URL    postmanURL        `json:"url"`
+	Header []postmanKeyValue `json:"header"`
+	Auth   *postmanAuth      `json:"auth"`
+}
+
+// UnmarshalJSON accepts a request given as just its URL.
+func (r *postmanRequest) UnmarshalJSON(data []byte) error {
+	var raw string
+	if json.Unmarshal(data, &raw) == nil {
+		*r = postmanRequest{Method: "GET", URL: postmanURL{Raw: raw}}
+		return nil
+	}
+	type request postmanRequest
+	return json.Unmarshal(data, (*request)(r))
+}
+
+type postmanURL struct {
+	Raw string `json:"raw"`
+}
+
+// UnmarshalJSON accepts a URL given as a string or an object.
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	if json.Unmarshal(data, &u.Raw) == nil {
+		return nil
+	}
+	var object struct {
+		Raw string `json:"raw"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	u.Raw = object.Raw
+	return nil
+}
+
+type postmanKeyValue struct {
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value"`
+	Disabled bool        `json:"disabled"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Basic  []postmanKeyValue `json:"basic"`
+	Bearer []postmanKeyValue `json:"bearer"`
+	APIKey []postmanKeyValue `json:"apikey"`
+}
+
+// get returns the value of one of an auth method's settings.
+func get(values []postmanKeyValue, key string) string {
+	for _, kv := range values {
+		if kv.Key == key && kv.Value != nil {
+			return fmt.Sprint(kv.Value)
+		}
+	}
+	return ""
+}
+
+var postmanVariable = regexp.MustCompile(`{{\s*([^{}]+?)\s*}}`)
+
+// Postman reads a Postman v2.1 collection and returns an endpoint for each
+// GET request that passes the filters. Folders count as tags. Collection
+// variables are filled in; requests whose URL uses any other variable are
+// skipped, unless it stands for the scheme and host and BaseURL is set.
+// Saved example responses set the expected status.
+func Postman(data []byte, opts Options) (*Result, error) {
+	var collection postmanCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("invalid Postman collection: %w", err)
+	}
+	if collection.Info.Name == "" && len(collection.Item) == 0 {
+		return nil, fmt.Errorf("invalid Postman collection: no info or items")
+	}
+
+	variables := map[string]string{}
+	for _, v := range collection.Variable {
+		if !v.Disabled && v.Value != nil {
+			variables[v.Key] = fmt.Sprint(v.Value)
+		}
+	}
+
+	result := &Result{}
+	var walk func(items []postmanItem, folders []string, auth *postmanAuth)
+	walk = func(items []postmanItem, folders []string, auth *postmanAuth) {
+		for _, item := range items {
+			itemAuth := auth
+			if item.Auth != nil {
+				itemAuth = item.Auth
+			}
+			if item.Request == nil {
+				walk(item.Item, append(folders[:len(folders):len(folders)], item.Name), itemAuth)
+				continue
+			}
+			if item.Request.Auth != nil {
+				itemAuth = item.Request.Auth
+			}
+			method := strings.ToUpper(item.Request.Method)
+			if method != "" && method != "GET" {
+				continue
+			}
+			if len(opts.Tags) > 0 && !anyTag(opts.Tags, folders) {
+				continue
+			}
+			source := strings.Join(append(folders[:len(folders):len(folders)], item.Name), "/")
+
+			raw, err := postmanBaseURL(item.Request.URL.Raw, opts.BaseURL, variables)
+			if err != nil {
+				result.skip(source, "%v", err)
+				continue
+			}
+			u, err := url.Parse(raw)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				result.skip(source, "URL %s isn't absolute", raw)
+				continue
+			}
+			if !opts.matches(folders, u.Path) {
+				continue
+			}
+
+			endpoint := newEndpoint(item.Name, raw)
+			for _, header := range item.Request.Header {
+				if header.Disabled {
+					continue
+				}
+				value, ok := substitute(fmt.Sprint(header.Value), variables)
+				if !ok {
+					result.warn("%s: left out header %s, which uses an unknown variable", source, header.Key)
+					continue
+				}
+				endpoint.Headers[header.Key] = value
+			}
+			endpoint.Auth = postmanAuthConfig(itemAuth, variables, source, result)
+			var codes []string
+			for _, response := range item.Response {
+				codes = append(codes, fmt.Sprint(response.Code))
+			}
+			endpoint.ExpectedCode = expectedCode(codes)
+			result.Endpoints = append(result.Endpoints, endpoint)
+		}
+	}
+	walk(collection.Item, nil, collection.Auth)
+	return result, nil
+}
+
+// postmanBaseURL fills in a request URL's variables, replacing its scheme
+// and host with base if set.
+func postmanBaseURL(raw, base string, variables map[string]string) (string, error) {
+	if base != "" {
+		base = strings.TrimRight(base, "/")
+		if loc := postmanVariable.FindStringIndex(raw); loc != nil && loc[0] == 0 {
+			// A leading variable, like {{baseUrl}}, stands for the origin
+			raw = base + raw[loc[1]:]
+		} else if u, err := url.Parse(raw); err == nil && u.Host != "" {
+			raw = base + strings.TrimPrefix(raw, u.Scheme+"://"+u.Host)
+		}
+	}
+	value, ok := substitute(raw, variables)
+	if !ok {
+		return "", fmt.Errorf("URL %s uses an unknown variable", raw)
+	}
+	return value, nil
+}
+
+// substitute fills in {{variables}}, reporting whether all were known.
+func substitute(s string, variables map[string]string) (string, bool) {
+	ok := true
+	s = postmanVariable.ReplaceAllStringFunc(s, func(match string) string {
+		name := postmanVariable.FindStringSubmatch(match)[1]
+		value, found := variables[name]
+		if !found {
+			ok = false
+			return match
+		}
+		return value
+	})
+	return s, ok
+}
+
+// postmanAuthConfig maps the auth methods Beacon supports, warning about
+// the rest.
+func postmanAuthConfig(auth *postmanAuth, variables map[string]string, source string, result *Result) *models.AuthConfig {
+	if auth == nil || auth.Type == "" || auth.Type == "noauth" {
+		return nil
+	}
+	var config *models.AuthConfig
+	switch auth.Type {
+	case "basic":
+		config = &models.AuthConfig{Type: models.AuthBasic, Username: get(auth.Basic, "username"), Password: get(auth.Basic, "password")}
+	case "bearer":
+		config = &models.AuthConfig{Type: models.AuthBearer, Token: get(auth.Bearer, "token")}
+	case "apikey":
+		in := get(auth.APIKey, "in")
+		if in == "" {
+			in = "header"
+		}
+		config = &models.AuthConfig{Type: models.AuthAPIKey, KeyName: get(auth.APIKey, "key"), KeyValue: get(auth.APIKey, "value"), KeyIn: in}
+	default:
+		result.warn("%s: left out %s auth, which Beacon doesn't support", source, auth.Type)
+		return nil
+	}
+
+	for _, field := range []*string{&config.Username, &config.Password, &config.Token, &config.KeyName, &config.KeyValue} {
+		value, ok := substitute(*field, variables)
+		if !ok {
+			result.warn("%s: left out %s auth, which uses an unknown variable", source, auth.Type)
+			return nil
+		}
+		*field = value
+	}
+	if err := config.Validate(); err != nil {
+		result.warn("%s: left out %s auth: %v", source, auth.Type, err)
+		return nil
+	}
+	return config
+}
@@ -0,0 +1,288 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/beacon/internal/models"
+)
+
+// kumaBackup is the part of an Uptime Kuma backup an import reads.
+type kumaBackup struct {
+	Version          string             `json:"version"`
+	NotificationList []kumaNotification `json:"notificationList"`
+	MonitorList      []kumaMonitor      `json:"monitorList"`
+}
+
+type kumaNotification struct {
+	ID     int      `json:"id"`
+	Name   string   `json:"name"`
+	Config string   `json:"config"`
+	Active flexBool `json:"active"`
+}
+
+type kumaMonitor struct {
+	ID                  int             `json:"id"`
+	Name                string          `json:"name"`
+	Type                string          `json:"type"`
+	Parent              *int            `json:"parent"`
+	Active              flexBool        `json:"active"`
+	URL                 string          `json:"url"`
+	Method              string          `json:"method"`
+	Body                string          `json:"body"`
+	Headers             string          `json:"headers"`
+	Interval            int             `json:"interval"`
+	Timeout             float64         `json:"timeout"`
+	MaxRetries          int             `json:"maxretries"`
+	AcceptedStatusCodes []string        `json:"accepted_statuscodes"`
+	MaxRedirects        int             `json:"maxredirects"`
+	IgnoreTLS           flexBool        `json:"ignoreTls"`
+	UpsideDown          flexBool        `json:"upsideDown"`
+	Keyword             string          `json:"keyword"`
+	InvertKeyword       flexBool        `json:"invertKeyword"`
+	Hostname            string          `json:"hostname"`
+	Port                int             `json:"port"`
+	DNSResolveType      string          `json:"dns_resolve_type"`
+	DNSResolveServer    string          `json:"dns_resolve_server"`
+	AuthMethod          string          `json:"authMethod"`
+	BasicAuthUser       string          `json:"basic_auth_user"`
+	BasicAuthPass       string          `json:"basic_auth_pass"`
+	OAuthTokenURL       string          `json:"oauth_token_url"`
+	OAuthClientID       string          `json:"oauth_client_id"`
+	OAuthClientSecret   string          `json:"oauth_client_secret"`
+	OAuthScopes         string          `json:"oauth_scopes"`
+	GRPCURL             string          `json:"grpcUrl"`
+	GRPCServiceName     string          `json:"grpcServiceName"`
+	GRPCEnableTLS       flexBool        `json:"grpcEnableTls"`
+	Notifications       map[string]bool `json:"notificationIDList"`
+}
+
+// UptimeKuma maps an Uptime Kuma backup (Settings → Backup → Export) onto
+// Beacon. Groups become services, and monitors outside any group go into
+// defaultService. Webhook notifications become webhooks of the services
+// whose monitors use them; other notification types are reported.
+func UptimeKuma(data []byte, defaultService string) (*Migration, error) {
+	var backup kumaBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("invalid Uptime Kuma backup: %w", err)
+	}
+	if backup.MonitorList == nil {
+		return nil, fmt.Errorf("invalid Uptime Kuma backup: no monitorList")
+	}
+
+	m := &Migration{}
+	groups := map[int]string{}
+	for _, monitor := range backup.MonitorList {
+		if monitor.Type == "group" {
+			groups[monitor.ID] = monitor.Name
+		}
+	}
+	webhooks := kumaWebhooks(backup.NotificationList, m)
+
+	// Codes other than Uptime Kuma's default range are reported per monitor
+	var anyTwoHundred int
+	for _, monitor := range backup.MonitorList {
+		if monitor.Type == "group" {
+			continue
+		}
+		service := defaultService
+		if monitor.Parent != nil && groups[*monitor.Parent] != "" {
+			service = groups[*monitor.Parent]
+		}
+		source := fmt.Sprintf("monitor %q (%s)", monitor.Name, monitor.Type)
+
+		endpoint, ok := kumaEndpoint(monitor, source, m)
+		if !ok {
+			continue
+		}
+		if codes := monitor.AcceptedStatusCodes; len(codes) > 0 {
+			code, exact := kumaStatus(codes)
+			endpoint.ExpectedCode = code
+			if len(endpoint.Check.Steps) > 0 {
+				endpoint.Check.Steps[0].ExpectedCode = code
+			}
+			switch {
+			case exact:
+			case len(codes) == 1 && codes[0] == "200-299":
+				anyTwoHundred++
+			default:
+				m.note("%s: accepts status %s; Beacon expects exactly %d", source, strings.Join(codes, ", "), code)
+			}
+		}
+		m.addEndpoint(service, source, endpoint)
+
+		for id, on := range monitor.Notifications {
+			if webhook, found := webhooks[id]; on && found {
+				m.addWebhook(service, webhook)
+			}
+		}
+	}
+	if anyTwoHundred > 0 {
+		m.note("monitors accepting any 2xx status (%d): Beacon expects exactly 200 from them", anyTwoHundred)
+	}
+	return m, nil
+}
+
+// kumaEndpoint maps a monitor, reporting what doesn't carry over. ok is
+// false if the monitor can't be imported at all.
+func kumaEndpoint(monitor kumaMonitor, source string, m *Migration) (endpoint models.ServiceEndpoint, ok bool) {
+	if monitor.UpsideDown {
+		m.note("%s: not imported: Beacon has no upside-down mode", source)
+		return endpoint, false
+	}
+
+	endpoint = newEndpoint(monitor.Name, monitor.URL)
+	endpoint.Enabled = bool(monitor.Active)
+	if monitor.Interval > 0 {
+		endpoint.IntervalSec = monitor.Interval
+	}
+	if monitor.Timeout > 0 {
+		endpoint.TimeoutMs = int(monitor.Timeout * 1000)
+	}
+	if monitor.MaxRetries > 0 {
+		m.note("%s: retries (%d) aren't supported; Beacon opens an incident on the first failed check", source, monitor.MaxRetries)
+	}
+
+	switch monitor.Type {
+	case "http", "keyword":
+		if !kumaHTTP(&endpoint, monitor, source, m) {
+			return endpoint, false
+		}
+		if monitor.Type == "keyword" {
+			if monitor.InvertKeyword {
+				m.note("%s: not imported: Beacon can't check that a keyword is absent", source)
+				return endpoint, false
+			}
+			endpoint = bodyCheck(endpoint, models.Assertion{Source: models.SourceBody, Operator: "contains", Value: monitor.Keyword})
+		}
+	case "port":
+		endpoint.CheckType = models.CheckTCP
+		endpoint.URL = fmt.Sprintf("%s:%d", monitor.Hostname, monitor.Port)
+	case "dns":
+		endpoint.CheckType = models.CheckDNS
+		endpoint.URL = monitor.Hostname
+		endpoint.Check.RecordType = strings.ToUpper(monitor.DNSResolveType)
+		endpoint.Check.Nameserver = monitor.DNSResolveServer
+	case "push":
+		endpoint.CheckType = models.CheckHeartbeat
+		endpoint.URL = ""
+		m.note("%s: gets a new check-in URL; point the job at it after applying", source)
+	case "postgres", "mysql", "redis":
+		endpoint.CheckType = monitor.Type
+		name := secretName(monitor.Name, "connection")
+		endpoint.URL = fmt.Sprintf(`{{secret %q}}`, name)
+		m.note("%s: store the connection string with: beacon secrets set %s", source, name)
+	case "grpc-keyword":
+		m.note("%s: imported as a gRPC health check of %s, without the method call or keyword", source, monitor.GRPCServiceName)
+		endpoint.CheckType = models.CheckGRPC
+		endpoint.URL = monitor.GRPCURL
+		endpoint.Check.GRPCService = monitor.GRPCServiceName
+		endpoint.Check.GRPCPlaintext = !bool(monitor.GRPCEnableTLS)
+	case "json-query":
+		m.note("%s: not imported: its JSONata query can't be converted; recreate it as a transaction check with a JSONPath assertion", source)
+		return endpoint, false
+	default:
+		m.note("%s: not imported: Beacon has no %s check", source, monitor.Type)
+		return endpoint, false
+	}
+	return endpoint, true
+}
+
+// kumaHTTP maps the HTTP settings of a monitor.
+func kumaHTTP(endpoint *models.ServiceEndpoint, monitor kumaMonitor, source string, m *Migration) bool {
+	if monitor.Method != "" {
+		endpoint.Method = strings.ToUpper(monitor.Method)
+	}
+	endpoint.Body = monitor.Body
+	if strings.TrimSpace(monitor.Headers) != "" {
+		var headers map[string]interface{}
+		if err := json.Unmarshal([]byte(monitor.Headers), &headers); err != nil {
+			m.note("%s: left out headers, which aren't a JSON object", source)
+		} else if headers != nil {
+			endpoint.Headers = headers
+		}
+	}
+	if monitor.MaxRedirects == 0 {
+		endpoint.FollowRedirects = false
+	} else {
+		endpoint.MaxRedirects = monitor.MaxRedirects
+	}
+	if monitor.IgnoreTLS {
+		endpoint.TLS = &models.TLSConfig{InsecureSkipVerify: true}
+	}
+
+	switch monitor.AuthMethod {
+	case "", "null":
+	case "basic":
+		endpoint.Auth = &models.AuthConfig{Type: models.AuthBasic, Username: monitor.BasicAuthUser, Password: monitor.BasicAuthPass}
+	case "oauth2-cc":
+		endpoint.Auth = &models.AuthConfig{
+			Type:         models.AuthOAuth2,
+			TokenURL:     monitor.OAuthTokenURL,
+			ClientID:     monitor.OAuthClientID,
+			ClientSecret: monitor.OAuthClientSecret,
+			Scopes:       strings.Fields(monitor.OAuthScopes),
+		}
+	default:
+		m.note("%s: not imported: Beacon doesn't support %s auth", source, monitor.AuthMethod)
+		return false
+	}
+	return true
+}
+
+// kumaStatus picks the status to expect from accepted codes such as
+// "200-299" or "301": the first one, or the start of the first range.
+// exact reports whether that is the only code accepted.
+func kumaStatus(codes []string) (code int, exact bool) {
+	first := codes[0]
+	low, high, isRange := strings.Cut(first, "-")
+	code, err := strconv.Atoi(strings.TrimSpace(low))
+	if err != nil {
+		return 200, false
+	}
+	return code, len(codes) == 1 && (!isRange || strings.TrimSpace(high) == strings.TrimSpace(low))
+}
+
+// kumaWebhooks maps the webhook notifications, by ID, and reports the
+// others.
+func kumaWebhooks(notifications []kumaNotification, m *Migration) map[string]models.Webhook {
+	webhooks := map[string]models.Webhook{}
+	for _, n := range notifications {
+		var config struct {
+			Type              string `json:"type"`
+			WebhookURL        string `json:"webhookURL"`
+			WebhookType       string `json:"webhookContentType"`
+			AdditionalHeaders string `json:"webhookAdditionalHeaders"`
+		}
+		if err := json.Unmarshal([]byte(n.Config), &config); err != nil {
+			m.note("notification %q: not imported: unreadable config", n.Name)
+			continue
+		}
+		source := fmt.Sprintf("notification %q (%s)", n.Name, config.Type)
+		if config.Type != "webhook" {
+			m.note("%s: not imported: Beacon only sends generic JSON webhooks", source)
+			continue
+		}
+		if config.WebhookType != "" && config.WebhookType != "json" {
+			m.note("%s: sends %s bodies; Beacon's webhook sends its own JSON payload", source, config.WebhookType)
+		}
+
+		webhook := models.NewWebhook()
+		webhook.Name = n.Name
+		webhook.URL = config.WebhookURL
+		webhook.Enabled = bool(n.Active)
+		if strings.TrimSpace(config.AdditionalHeaders) != "" {
+			if err := json.Unmarshal([]byte(config.AdditionalHeaders), &webhook.Headers); err != nil {
+				m.note("%s: left out additional headers, which aren't a JSON object", source)
+			}
+		}
+		if err := webhook.Validate(); err != nil {
+			m.note("%s: not imported: %v", source, err)
+			continue
+		}
+		webhooks[strconv.Itoa(n.ID)] = webhook
+	}
+	return webhooks
+}
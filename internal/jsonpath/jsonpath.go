@@ -0,0 +1,161 @@
+// Package jsonpath evaluates a small subset of JSONPath against decoded
+// JSON: member access ($.a.b or $['a b']), array indices ($.items[0],
+// negative indices count from the end) and wildcards ($.items[*].id).
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// segment is one step of a compiled path: a member name, an array index, or
+// a wildcard over all members or elements.
+type segment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// Path is a compiled JSONPath expression.
+type Path struct {
+	expr     string
+	segments []segment
+}
+
+// Compile parses a JSONPath expression. The leading $ is optional.
+func Compile(expr string) (*Path, error) {
+	p := &Path{expr: expr}
+	rest := strings.TrimSpace(expr)
+	rest = strings.TrimPrefix(rest, "$")
+
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: unclosed [", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				p.segments = append(p.segments, segment{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				p.segments = append(p.segments, segment{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("jsonpath %q: invalid index %q", expr, inner)
+				}
+				p.segments = append(p.segments, segment{index: index, isIndex: true})
+			}
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			if key == "" {
+				return nil, fmt.Errorf("jsonpath %q: empty member name", expr)
+			}
+			if key == "*" {
+				p.segments = append(p.segments, segment{wildcard: true})
+			} else {
+				p.segments = append(p.segments, segment{key: key})
+			}
+		default:
+			// Allow "a.b" as shorthand for "$.a.b"
+			if len(p.segments) == 0 {
+				rest = "." + rest
+				continue
+			}
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", expr, rest)
+		}
+	}
+	return p, nil
+}
+
+// String returns the expression the path was compiled from.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Get returns the value at the path. Paths with wildcards return a slice of
+// every match. ok is false if nothing matched.
+func (p *Path) Get(doc interface{}) (value interface{}, ok bool) {
+	matches := []interface{}{doc}
+	wildcard := false
+	for _, seg := range p.segments {
+		var next []interface{}
+		for _, node := range matches {
+			next = append(next, seg.apply(node)...)
+		}
+		matches = next
+		wildcard = wildcard || seg.wildcard
+	}
+	if wildcard {
+		return matches, len(matches) > 0
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+	return matches[0], true
+}
+
+func (s segment) apply(node interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			values := make([]interface{}, 0, len(v))
+			for _, value := range v {
+				values = append(values, value)
+			}
+			return values
+		}
+		if value, ok := v[s.key]; ok && !s.isIndex {
+			return []interface{}{value}
+		}
+	case []interface{}:
+		if s.wildcard {
+			return v
+		}
+		if s.isIndex {
+			index := s.index
+			if index < 0 {
+				index += len(v)
+			}
+			if index >= 0 && index < len(v) {
+				return []interface{}{v[index]}
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup compiles expr and evaluates it against doc.
+func Lookup(doc interface{}, expr string) (interface{}, bool, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := p.Get(doc)
+	return value, ok, nil
+}
+
+// Format renders a matched value as text: strings as-is, everything else as
+// JSON, so numbers and booleans compare naturally as strings.
+func Format(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
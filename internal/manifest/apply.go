@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// Monitors starts and stops endpoints' monitors. Starting a monitor that is
+// already running, or stopping one that isn't, isn't an error.
+type Monitors interface {
+	StartMonitor(endpointID uuid.UUID) error
+	StopMonitor(endpointID uuid.UUID) error
+}
+
+// Apply makes a plan's changes in order, reporting each to w. It stops at
+// the first change that fails, leaving the ones before it made. Monitors
+// are started and stopped as planned unless monitors is nil; since the
+// change itself is saved by then, failures there are reported and counted
+// but don't stop the rest.
+func Apply(store Store, monitors Monitors, plan *Plan, w io.Writer) error {
+	// Services created so far, for creating what belongs to them
+	created := map[string]uuid.UUID{}
+	var monitorFailures int
+
+	for _, c := range plan.Changes {
+		if c.Kind == KindEndpoint && c.Monitor == MonitorStop && c.Action == ActionDelete && monitors != nil {
+			// Stop monitors before their endpoint goes
+			if err := monitors.StopMonitor(c.endpoint.ID); err != nil {
+				fmt.Fprintf(w, "✗ Failed to stop monitoring %s: %v\n", c.Path(), err)
+				monitorFailures++
+			}
+		}
+
+		if err := applyChange(store, c, created); err != nil {
+			return fmt.Errorf("failed to %s %s %s: %w", c.Action, c.Kind, c.Path(), err)
+		}
+		fmt.Fprintf(w, "✓ %s %s %s\n", pastTense[c.Action], c.Kind, c.Path())
+
+		if c.Kind != KindEndpoint || c.Action == ActionDelete {
+			continue
+		}
+		switch c.Monitor {
+		case MonitorStart, MonitorStop:
+			if monitors == nil {
+				continue
+			}
+			var err error
+			if c.Monitor == MonitorStart {
+				err = monitors.StartMonitor(c.endpoint.ID)
+			} else {
+				err = monitors.StopMonitor(c.endpoint.ID)
+			}
+			if err != nil {
+				fmt.Fprintf(w, "✗ Failed to %s monitoring %s: %v\n", c.Monitor, c.Path(), err)
+				monitorFailures++
+			} else {
+				fmt.Fprintf(w, "✓ %s monitoring %s\n", pastTense[c.Monitor], c.Path())
+			}
+		case MonitorRestart:
+			fmt.Fprintf(w, "! Restart monitoring %s (endpoint %s) to pick up the new schedule\n", c.Path(), c.endpoint.ID)
+		}
+	}
+
+	if monitorFailures > 0 {
+		return fmt.Errorf("the manifest was applied, but %d monitors couldn't be started or stopped", monitorFailures)
+	}
+	return nil
+}
+
+var pastTense = map[string]string{
+	ActionCreate: "Created", ActionUpdate: "Updated", ActionDelete: "Deleted",
+	MonitorStart: "Started", MonitorStop: "Stopped",
+}
+
+func applyChange(store Store, c Change, created map[string]uuid.UUID) error {
+	switch c.Kind {
+	case KindService:
+		switch c.Action {
+		case ActionCreate:
+			if err := store.CreateService(c.service); err != nil {
+				return err
+			}
+			created[c.Service] = c.service.ID
+			return nil
+		case ActionUpdate:
+			return store.UpdateService(c.service)
+		default:
+			return store.DeleteService(c.service.ID)
+		}
+
+	case KindEndpoint:
+		switch c.Action {
+		case ActionCreate:
+			if c.endpoint.ServiceID == uuid.Nil {
+				c.endpoint.ServiceID = created[c.Service]
+			}
+			return store.CreateEndpoint(c.endpoint)
+		case ActionUpdate:
+			return store.UpdateEndpoint(c.endpoint)
+		default:
+			return store.DeleteEndpoint(c.endpoint.ID)
+		}
+
+	case KindWebhook:
+		switch c.Action {
+		case ActionCreate:
+			if c.webhook.ServiceID == uuid.Nil {
+				c.webhook.ServiceID = created[c.Service]
+			}
+			return store.CreateWebhook(c.webhook)
+		case ActionUpdate:
+			return store.UpdateWebhook(c.webhook)
+		default:
+			return store.DeleteWebhook(c.webhook.ID)
+		}
+
+	default:
+		switch c.Action {
+		case ActionCreate:
+			if c.window.ServiceID == uuid.Nil {
+				c.window.ServiceID = created[c.Service]
+			}
+			return store.CreateMaintenanceWindow(c.window)
+		case ActionUpdate:
+			return store.UpdateMaintenanceWindow(c.window)
+		default:
+			return store.DeleteMaintenanceWindow(c.window.ID)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// Package manifest describes services and their endpoints, webhooks and
+// maintenance windows as a YAML or JSON document, and reconciles the
+// database with it.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is the declared state of a set of services. Services are keyed
+// by name, and their endpoints, webhooks and maintenance windows by name
+// within the service. Fields use the same names as the API's JSON.
+type Manifest struct {
+	Services []Service `json:"services"`
+}
+
+// Service is a service and everything that belongs to it.
+type Service struct {
+	Name               string                     `json:"name"`
+	Slug               string                     `json:"slug"`
+	Description        string                     `json:"description"`
+	Endpoints          []models.ServiceEndpoint   `json:"endpoints"`
+	Webhooks           []models.Webhook           `json:"webhooks"`
+	MaintenanceWindows []models.MaintenanceWindow `json:"maintenance_windows"`
+}
+
+// Store is what reconciling needs of the database. The CLI's direct access
+// and the API client both implement it.
+type Store interface {
+	CreateService(service *models.Service) error
+	ListServices() ([]models.Service, error)
+	UpdateService(service *models.Service) error
+	DeleteService(id uuid.UUID) error
+
+	CreateEndpoint(endpoint *models.ServiceEndpoint) error
+	ListEndpoints(serviceID *uuid.UUID) ([]models.ServiceEndpoint, error)
+	UpdateEndpoint(endpoint *models.ServiceEndpoint) error
+	DeleteEndpoint(id uuid.UUID) error
+
+	CreateWebhook(webhook *models.Webhook) error
+	ListWebhooks(serviceID *uuid.UUID) ([]models.Webhook, error)
+	UpdateWebhook(webhook *models.Webhook) error
+	DeleteWebhook(id uuid.UUID) error
+
+	CreateMaintenanceWindow(window *models.MaintenanceWindow) error
+	ListMaintenanceWindows(serviceID *uuid.UUID) ([]models.MaintenanceWindow, error)
+	UpdateMaintenanceWindow(window *models.MaintenanceWindow) error
+	DeleteMaintenanceWindow(id uuid.UUID) error
+}
+
+// document is a manifest as written. Endpoints and webhooks are decoded
+// over the defaults new ones get, so a manifest only needs the fields it
+// changes.
+type document struct {
+	Services []struct {
+		Name               string            `json:"name"`
+		Slug               string            `json:"slug"`
+		Description        string            `json:"description"`
+		Endpoints          []json.RawMessage `json:"endpoints"`
+		Webhooks           []json.RawMessage `json:"webhooks"`
+		MaintenanceWindows []json.RawMessage `json:"maintenance_windows"`
+	} `json:"services"`
+}
+
+// Read parses and validates a manifest. JSON is valid YAML, so both are read
+// with the YAML parser and then decoded as JSON, which gives them the models'
+// JSON field names and types.
+func Read(r io.Reader) (*Manifest, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if data, err = json.Marshal(tree); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	var doc document
+	if err := decodeStrict(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	m := &Manifest{}
+	services := map[string]bool{}
+	for _, s := range doc.Services {
+		if s.Name == "" {
+			return nil, fmt.Errorf("invalid manifest: service name is required")
+		}
+		if services[s.Name] {
+			return nil, fmt.Errorf("invalid manifest: service %s is declared twice", s.Name)
+		}
+		services[s.Name] = true
+		if err := (&models.Service{Name: s.Name, Slug: s.Slug}).Validate(); err != nil {
+			return nil, fmt.Errorf("invalid service %s: %w", s.Name, err)
+		}
+		service := Service{Name: s.Name, Slug: s.Slug, Description: s.Description}
+
+		names := map[string]bool{}
+		for _, raw := range s.Endpoints {
+			endpoint := models.NewEndpoint()
+			if err := decodeStrict(raw, &endpoint); err != nil {
+				return nil, fmt.Errorf("invalid endpoint in service %s: %w", s.Name, err)
+			}
+			if err := endpoint.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid endpoint %s/%s: %w", s.Name, endpoint.Name, err)
+			}
+			if endpoint.CheckType == models.CheckHeartbeat && endpoint.URL != "" {
+				return nil, fmt.Errorf("invalid endpoint %s/%s: heartbeat endpoints are given a check-in URL; don't set URL", s.Name, endpoint.Name)
+			}
+			if names[endpoint.Name] {
+				return nil, fmt.Errorf("invalid manifest: endpoint %s/%s is declared twice", s.Name, endpoint.Name)
+			}
+			names[endpoint.Name] = true
+			service.Endpoints = append(service.Endpoints, endpoint)
+		}
+
+		names = map[string]bool{}
+		for _, raw := range s.Webhooks {
+			webhook := models.NewWebhook()
+			if err := decodeStrict(raw, &webhook); err != nil {
+				return nil, fmt.Errorf("invalid webhook in service %s: %w", s.Name, err)
+			}
+			if err := webhook.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid webhook %s/%s: %w", s.Name, webhook.Name, err)
+			}
+			if names[webhook.Name] {
+				return nil, fmt.Errorf("invalid manifest: webhook %s/%s is declared twice", s.Name, webhook.Name)
+			}
+			names[webhook.Name] = true
+			service.Webhooks = append(service.Webhooks, webhook)
+		}
+
+		names = map[string]bool{}
+		for _, raw := range s.MaintenanceWindows {
+			var window models.MaintenanceWindow
+			if err := decodeStrict(raw, &window); err != nil {
+				return nil, fmt.Errorf("invalid maintenance window in service %s: %w", s.Name, err)
+			}
+			if err := window.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid maintenance window %s/%s: %w", s.Name, window.Name, err)
+			}
+			if names[window.Name] {
+				return nil, fmt.Errorf("invalid manifest: maintenance window %s/%s is declared twice", s.Name, window.Name)
+			}
+			names[window.Name] = true
+			service.MaintenanceWindows = append(service.MaintenanceWindows, window)
+		}
+
+		m.Services = append(m.Services, service)
+	}
+	return m, nil
+}
+
+// decodeStrict decodes JSON, rejecting unknown fields so typos in a
+// manifest aren't silently ignored.
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// Export returns the current state as a manifest, with services and what
+// belongs to them sorted by name. Credentials are redacted, and applying
+// the manifest as exported keeps the stored ones.
+func Export(store Store) (*Manifest, error) {
+	state, err := load(store)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	for _, s := range state.services {
+		service := Service{Name: s.Name, Slug: s.Slug, Description: s.Description}
+		for _, endpoint := range state.endpoints[s.ID] {
+			// Check-in URLs are assigned, like IDs
+			if endpoint.CheckType == models.CheckHeartbeat {
+				endpoint.URL = ""
+			}
+			endpoint = endpoint.Redacted()
+			endpoint.Warnings = nil
+			service.Endpoints = append(service.Endpoints, endpoint)
+		}
+		for _, webhook := range state.webhooks[s.ID] {
+			service.Webhooks = append(service.Webhooks, webhook.Redacted())
+		}
+		service.MaintenanceWindows = state.windows[s.ID]
+		m.Services = append(m.Services, service)
+	}
+	return m, nil
+}
+
+// Formats Write can produce.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// exportOmitted are the fields the database assigns, which a manifest
+// leaves out.
+var exportOmitted = map[string]bool{
+	"id": true, "service_id": true, "created_at": true, "updated_at": true, "deleted_at": true,
+}
+
+// Write writes a manifest in format, leaving out the fields the database
+// assigns and the ones that are empty.
+func Write(w io.Writer, m *Manifest, format string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return err
+	}
+	tree = prune(tree)
+
+	switch format {
+	case FormatJSON:
+		data, err = json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case FormatYAML, "":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(tree); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unknown format %q (use yaml or json)", format)
+	}
+}
+
+// prune drops assigned fields and empty values from decoded JSON.
+func prune(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			value = prune(value)
+			if exportOmitted[key] || empty(value) {
+				delete(v, key)
+				continue
+			}
+			v[key] = value
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = prune(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// empty reports whether a decoded JSON value is null, an empty string or
+// an empty object or array. Manifests treat all of them as unset.
+func empty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// state is what the store holds, grouped by service.
+type state struct {
+	services  []models.Service
+	endpoints map[uuid.UUID][]models.ServiceEndpoint
+	webhooks  map[uuid.UUID][]models.Webhook
+	windows   map[uuid.UUID][]models.MaintenanceWindow
+}
+
+// load reads the current state, refusing duplicate names, which would make
+// the manifest's keys ambiguous.
+func load(store Store) (*state, error) {
+	services, err := store.ListServices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	endpoints, err := store.ListEndpoints(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoints: %w", err)
+	}
+	webhooks, err := store.ListWebhooks(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	windows, err := store.ListMaintenanceWindows(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Name < endpoints[j].Name })
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].Name < webhooks[j].Name })
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Name < windows[j].Name })
+
+	s := &state{
+		services:  services,
+		endpoints: map[uuid.UUID][]models.ServiceEndpoint{},
+		webhooks:  map[uuid.UUID][]models.Webhook{},
+		windows:   map[uuid.UUID][]models.MaintenanceWindow{},
+	}
+	names := map[string]bool{}
+	for _, service := range services {
+		if names[service.Name] {
+			return nil, fmt.Errorf("there are two services named %s; rename one to use a manifest", service.Name)
+		}
+		names[service.Name] = true
+	}
+	for _, endpoint := range endpoints {
+		s.endpoints[endpoint.ServiceID] = append(s.endpoints[endpoint.ServiceID], endpoint)
+	}
+	for _, webhook := range webhooks {
+		s.webhooks[webhook.ServiceID] = append(s.webhooks[webhook.ServiceID], webhook)
+	}
+	for _, window := range windows {
+		s.windows[window.ServiceID] = append(s.windows[window.ServiceID], window)
+	}
+	for _, service := range services {
+		if err := unique(service.Name, "endpoints", s.endpoints[service.ID], func(e models.ServiceEndpoint) string { return e.Name }); err != nil {
+			return nil, err
+		}
+		if err := unique(service.Name, "webhooks", s.webhooks[service.ID], func(w models.Webhook) string { return w.Name }); err != nil {
+			return nil, err
+		}
+		if err := unique(service.Name, "maintenance windows", s.windows[service.ID], func(w models.MaintenanceWindow) string { return w.Name }); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func unique[T any](service, kind string, items []T, name func(T) string) error {
+	seen := map[string]bool{}
+	for _, item := range items {
+		if seen[name(item)] {
+			return fmt.Errorf("service %s has two %s named %s; rename one to use a manifest", service, kind, name(item))
+		}
+		seen[name(item)] = true
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+)
+
+// Actions a change can take.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Kinds of entity a manifest declares.
+const (
+	KindService           = "service"
+	KindEndpoint          = "endpoint"
+	KindWebhook           = "webhook"
+	KindMaintenanceWindow = "maintenance window"
+)
+
+// What a change does to an endpoint's monitor.
+const (
+	MonitorStart = "start"
+	MonitorStop  = "stop"
+	// MonitorRestart marks schedule changes, which running monitors only
+	// pick up when restarted. Apply leaves that to the user, since a
+	// cancelled monitor takes a moment to finish.
+	MonitorRestart = "restart"
+)
+
+// Change is one create, update or delete that brings the store in line
+// with a manifest.
+type Change struct {
+	Action string
+	Kind   string
+	// Service is the name of the service, and Name that of the endpoint,
+	// webhook or maintenance window in it. Name is empty for services.
+	Service string
+	Name    string
+	// Fields lists what an update changes.
+	Fields  []string
+	Monitor string
+
+	// The entity to save: the declared one, with the stored one's ID for
+	// updates, or the stored one for deletes.
+	service  *models.Service
+	endpoint *models.ServiceEndpoint
+	webhook  *models.Webhook
+	window   *models.MaintenanceWindow
+}
+
+// Path names the changed entity as service or service/name.
+func (c Change) Path() string {
+	if c.Name == "" {
+		return c.Service
+	}
+	return c.Service + "/" + c.Name
+}
+
+func (c Change) String() string {
+	symbol := map[string]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-"}[c.Action]
+	s := fmt.Sprintf("%s %s %s", symbol, c.Kind, c.Path())
+	if len(c.Fields) > 0 {
+		s += " (" + strings.Join(c.Fields, ", ") + ")"
+	}
+	switch c.Monitor {
+	case MonitorStart:
+		s += ", starts monitor"
+	case MonitorStop:
+		s += ", stops monitor"
+	case MonitorRestart:
+		s += ", monitor needs a restart"
+	}
+	return s
+}
+
+// Plan is the changes that would bring the store in line with a manifest,
+// in the order Apply makes them.
+type Plan struct {
+	Changes []Change
+}
+
+// Counts returns the number of creates, updates and deletes in the plan.
+func (p *Plan) Counts() (create, update, delete int) {
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			create++
+		case ActionUpdate:
+			update++
+		case ActionDelete:
+			delete++
+		}
+	}
+	return create, update, delete
+}
+
+// Write prints the plan, one change per line, and a summary.
+func (p *Plan) Write(w io.Writer) {
+	if len(p.Changes) == 0 {
+		fmt.Fprintln(w, "No changes. The manifest matches the current state.")
+		return
+	}
+	for _, c := range p.Changes {
+		fmt.Fprintln(w, c)
+	}
+	create, update, delete := p.Counts()
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to change, %d to delete.\n", create, update, delete)
+}
+
+// Compute works out the changes that would make the store match m. What
+// the manifest doesn't declare is left alone unless prune is set, in which
+// case it is deleted.
+func Compute(store Store, m *Manifest, prune bool) (*Plan, error) {
+	current, err := load(store)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{}
+
+	declared := map[string]bool{}
+	for i := range m.Services {
+		s := &m.Services[i]
+		declared[s.Name] = true
+
+		var existing *models.Service
+		for j := range current.services {
+			if current.services[j].Name == s.Name {
+				existing = &current.services[j]
+			}
+		}
+		if existing == nil {
+			service := &models.Service{Name: s.Name, Slug: s.Slug, Description: s.Description}
+			plan.add(Change{Action: ActionCreate, Kind: KindService, Service: s.Name, service: service})
+			if err := plan.addChildren(s, current, nil, false); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// An empty slug keeps the one the service has
+		var fields []string
+		service := *existing
+		if s.Slug != "" && existing.Slug != s.Slug {
+			service.Slug = s.Slug
+			fields = append(fields, "slug")
+		}
+		if existing.Description != s.Description {
+			service.Description = s.Description
+			fields = append(fields, "description")
+		}
+		if len(fields) > 0 {
+			plan.add(Change{Action: ActionUpdate, Kind: KindService, Service: s.Name, Fields: fields, service: &service})
+		}
+		if err := plan.addChildren(s, current, existing, prune); err != nil {
+			return nil, err
+		}
+	}
+
+	if prune {
+		for i := range current.services {
+			service := &current.services[i]
+			if declared[service.Name] {
+				continue
+			}
+			// Everything in it goes too, so nothing is left monitoring
+			if err := plan.addChildren(&Service{Name: service.Name}, current, service, true); err != nil {
+				return nil, err
+			}
+			plan.add(Change{Action: ActionDelete, Kind: KindService, Service: service.Name, service: service})
+		}
+	}
+	return plan, nil
+}
+
+func (p *Plan) add(c Change) {
+	p.Changes = append(p.Changes, c)
+}
+
+// addChildren plans the changes to a service's endpoints, webhooks and
+// maintenance windows. existing is the stored service, or nil if it is yet
+// to be created. Deletes come first, so replacing an endpoint doesn't
+// count against the organization's quota twice.
+func (p *Plan) addChildren(s *Service, current *state, existing *models.Service, prune bool) error {
+	var (
+		endpoints []models.ServiceEndpoint
+		webhooks  []models.Webhook
+		windows   []models.MaintenanceWindow
+	)
+	// Children of services yet to be created get their ID when applied
+	var serviceID uuid.UUID
+	if existing != nil {
+		serviceID = existing.ID
+		endpoints = current.endpoints[existing.ID]
+		webhooks = current.webhooks[existing.ID]
+		windows = current.windows[existing.ID]
+	}
+
+	if prune {
+		for i := range endpoints {
+			e := &endpoints[i]
+			if findEndpoint(s.Endpoints, e.Name) == nil {
+				c := Change{Action: ActionDelete, Kind: KindEndpoint, Service: s.Name, Name: e.Name, endpoint: e}
+				if e.Enabled {
+					c.Monitor = MonitorStop
+				}
+				p.add(c)
+			}
+		}
+		for i := range webhooks {
+			if findWebhook(s.Webhooks, webhooks[i].Name) == nil {
+				p.add(Change{Action: ActionDelete, Kind: KindWebhook, Service: s.Name, Name: webhooks[i].Name, webhook: &webhooks[i]})
+			}
+		}
+		for i := range windows {
+			if findWindow(s.MaintenanceWindows, windows[i].Name) == nil {
+				p.add(Change{Action: ActionDelete, Kind: KindMaintenanceWindow, Service: s.Name, Name: windows[i].Name, window: &windows[i]})
+			}
+		}
+	}
+
+	for _, declared := range s.Endpoints {
+		endpoint := declared
+		c := Change{Kind: KindEndpoint, Service: s.Name, Name: endpoint.Name, endpoint: &endpoint}
+		cur := findEndpoint(endpoints, endpoint.Name)
+		if cur == nil {
+			c.Action = ActionCreate
+			endpoint.ServiceID = serviceID
+			if endpoint.Enabled {
+				c.Monitor = MonitorStart
+			}
+			p.add(c)
+			continue
+		}
+
+		endpoint.ID, endpoint.ServiceID = cur.ID, cur.ServiceID
+		endpoint.CreatedAt, endpoint.UpdatedAt, endpoint.DeletedAt = cur.CreatedAt, cur.UpdatedAt, cur.DeletedAt
+		if endpoint.CheckType == models.CheckHeartbeat && cur.CheckType == models.CheckHeartbeat {
+			endpoint.URL = cur.URL
+		}
+		endpoint.KeepRedacted(*cur)
+		if endpoint.Slug == "" {
+			endpoint.Slug = cur.Slug
+		}
+		fields, err := changedFields(normalizeEndpoint(endpoint), normalizeEndpoint(*cur))
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		c.Action, c.Fields = ActionUpdate, fields
+		switch {
+		case endpoint.Enabled && !cur.Enabled:
+			c.Monitor = MonitorStart
+		case !endpoint.Enabled && cur.Enabled:
+			c.Monitor = MonitorStop
+		case endpoint.Enabled && changesSchedule(fields):
+			c.Monitor = MonitorRestart
+		}
+		p.add(c)
+	}
+
+	for _, declared := range s.Webhooks {
+		webhook := declared
+		c := Change{Kind: KindWebhook, Service: s.Name, Name: webhook.Name, webhook: &webhook}
+		cur := findWebhook(webhooks, webhook.Name)
+		if cur == nil {
+			c.Action = ActionCreate
+			webhook.ServiceID = serviceID
+			p.add(c)
+			continue
+		}
+		webhook.ID, webhook.ServiceID = cur.ID, cur.ServiceID
+		webhook.CreatedAt, webhook.UpdatedAt, webhook.DeletedAt = cur.CreatedAt, cur.UpdatedAt, cur.DeletedAt
+		webhook.KeepRedacted(*cur)
+		fields, err := changedFields(webhook, *cur)
+		if err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			c.Action, c.Fields = ActionUpdate, fields
+			p.add(c)
+		}
+	}
+
+	for _, declared := range s.MaintenanceWindows {
+		window := declared
+		c := Change{Kind: KindMaintenanceWindow, Service: s.Name, Name: window.Name, window: &window}
+		cur := findWindow(windows, window.Name)
+		if cur == nil {
+			c.Action = ActionCreate
+			window.ServiceID = serviceID
+			p.add(c)
+			continue
+		}
+		window.ID, window.ServiceID = cur.ID, cur.ServiceID
+		window.CreatedAt, window.UpdatedAt, window.DeletedAt = cur.CreatedAt, cur.UpdatedAt, cur.DeletedAt
+		// Compare instants, not how the zone is written
+		stored := *cur
+		window.StartsAt, window.EndsAt = window.StartsAt.UTC(), window.EndsAt.UTC()
+		stored.StartsAt, stored.EndsAt = stored.StartsAt.UTC(), stored.EndsAt.UTC()
+		fields, err := changedFields(window, stored)
+		if err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			c.Action, c.Fields = ActionUpdate, fields
+			p.add(c)
+		}
+	}
+	return nil
+}
+
+// normalizeEndpoint fills in the defaults the checks assume, so leaving
+// them out of a manifest doesn't count as a change, and drops warnings,
+// which are only output.
+func normalizeEndpoint(e models.ServiceEndpoint) models.ServiceEndpoint {
+	e.Warnings = nil
+	if e.CheckType == "" {
+		e.CheckType = models.CheckHTTP
+	}
+	if e.Method == "" {
+		e.Method = "GET"
+	}
+	if e.Quorum == 0 {
+		e.Quorum = 1
+	}
+	return e
+}
+
+// changesSchedule reports whether any of fields are ones a running monitor
+// was started with.
+func changesSchedule(fields []string) bool {
+	for _, field := range fields {
+		switch field {
+		case "interval_sec", "regions", "quorum", "check_type":
+			return true
+		}
+	}
+	return false
+}
+
+// changedFields returns the JSON fields, other than the assigned ones,
+// that differ between desired and current, treating empty values as equal.
+func changedFields(desired, current interface{}) ([]string, error) {
+	a, err := fields(desired)
+	if err != nil {
+		return nil, err
+	}
+	b, err := fields(current)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for key := range a {
+		if exportOmitted[key] {
+			continue
+		}
+		x, y := prune(a[key]), prune(b[key])
+		if empty(x) && empty(y) {
+			continue
+		}
+		if !reflect.DeepEqual(x, y) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func fields(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	return m, json.Unmarshal(data, &m)
+}
+
+func findEndpoint(endpoints []models.ServiceEndpoint, name string) *models.ServiceEndpoint {
+	for i := range endpoints {
+		if endpoints[i].Name == name {
+			return &endpoints[i]
+		}
+	}
+	return nil
+}
+
+func findWebhook(webhooks []models.Webhook, name string) *models.Webhook {
+	for i := range webhooks {
+		if webhooks[i].Name == name {
+			return &webhooks[i]
+		}
+	}
+	return nil
+}
+
+func findWindow(windows []models.MaintenanceWindow, name string) *models.MaintenanceWindow {
+	for i := range windows {
+		if windows[i].Name == name {
+			return &windows[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles, from least to most access. Viewers can read, editors can also
+// change monitoring configuration, and admins can also manage users.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Roles lists every role in increasing order of access.
+var Roles = []string{RoleViewer, RoleEditor, RoleAdmin}
+
+// RoleRank orders roles for comparison. An empty or unknown role ranks 0.
+func RoleRank(role string) int {
+	for i, r := range Roles {
+		if r == role {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Scope levels. A scope is resource:level, like endpoints:write. * matches
+// every resource, and write includes read.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// ScopeResources lists the resources scopes can name.
+var ScopeResources = []string{"organizations", "projects", "services", "endpoints", "webhooks", "maintenance", "incidents", "pings", "monitors", "users", "tokens", "audit"}
+
+// ValidateScope checks that a scope names a known resource and level.
+func ValidateScope(scope string) error {
+	resource, level, ok := strings.Cut(scope, ":")
+	if !ok || (resource != "*" && !contains(ScopeResources, resource)) || (level != ScopeRead && level != ScopeWrite) {
+		return fmt.Errorf("invalid scope %q (use resource:read or resource:write, where resource is * or one of %s)", scope, strings.Join(ScopeResources, ", "))
+	}
+	return nil
+}
+
+// ScopesAllow reports whether scopes permit level access to resource. No
+// scopes at all permits everything.
+func ScopesAllow(scopes []string, resource, level string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	for _, scope := range scopes {
+		r, l, _ := strings.Cut(scope, ":")
+		if (r == "*" || r == resource) && (l == level || l == ScopeWrite) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleFor returns the user's role on a service: the higher of their own
+// role and any grant for the service.
+func (u *User) RoleFor(serviceID uuid.UUID) string {
+	role := u.Role
+	for _, grant := range u.Grants {
+		if grant.ServiceID == serviceID && RoleRank(grant.Role) > RoleRank(role) {
+			role = grant.Role
+		}
+	}
+	return role
+}
+
+// MaxRole returns the highest role the user has on any service.
+func (u *User) MaxRole() string {
+	role := u.Role
+	for _, grant := range u.Grants {
+		if RoleRank(grant.Role) > RoleRank(role) {
+			role = grant.Role
+		}
+	}
+	return role
+}
+
+// Active reports whether the token can be used at the given time.
+func (t *APIToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
@@ -4,81 +4,700 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/beacon/internal/secrets"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// Organization is a tenant: a team whose projects, users and secrets are
+// kept apart from other organizations'. MaxEndpoints and MinIntervalSec
+// are its quotas; zero means unlimited.
+type Organization struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	Slug           string     `db:"slug" json:"slug"`
+	Name           string     `db:"name" json:"name"`
+	MaxEndpoints   int        `db:"max_endpoints" json:"max_endpoints"`
+	MinIntervalSec int        `db:"min_interval_sec" json:"min_interval_sec"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt      *time.Time `db:"deleted_at" json:"deleted_at"`
+}
+
+// Project groups services within an organization.
+type Project struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	Slug           string     `db:"slug" json:"slug"`
+	Name           string     `db:"name" json:"name"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt      *time.Time `db:"deleted_at" json:"deleted_at"`
+}
+
+// DefaultSlug names the project every organization starts with, and the
+// organization that holds everything created without one.
+const DefaultSlug = "default"
+
+// Service groups endpoints. Slug is unique within the project and is set
+// from the name if left empty.
 type Service struct {
-	ID          uuid.UUID  `db:"id"`
-	Name        string     `db:"name"`
-	Description string     `db:"description"`
-	CreatedAt   time.Time  `db:"created_at"`
-	UpdatedAt   time.Time  `db:"updated_at"`
-	DeletedAt   *time.Time `db:"deleted_at"`
+	ID          uuid.UUID  `db:"id" json:"id"`
+	ProjectID   uuid.UUID  `db:"project_id" json:"project_id"`
+	Slug        string     `db:"slug" json:"slug"`
+	Name        string     `db:"name" json:"name"`
+	Description string     `db:"description" json:"description"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at" json:"deleted_at"`
 }
 
 type ServiceEndpoint struct {
-	ID            uuid.UUID  `db:"id" json:"ID"`
-	ServiceID     uuid.UUID  `db:"service_id" json:"ServiceID"`
-	Name          string     `db:"name" json:"Name"`
-	URL           string     `db:"url" json:"URL"`
-	Method        string     `db:"method" json:"Method"`
-	Headers       JSONB      `db:"headers" json:"Headers"`
-	ExpectedCode  int        `db:"expected_code" json:"ExpectedCode"`
-	TimeoutMs     int        `db:"timeout_ms" json:"TimeoutMs"`
-	IntervalSec   int        `db:"interval_sec" json:"IntervalSec"`
-	Enabled       bool       `db:"enabled" json:"Enabled"`
-	CreatedAt     time.Time  `db:"created_at" json:"CreatedAt"`
-	UpdatedAt     time.Time  `db:"updated_at" json:"UpdatedAt"`
-	DeletedAt     *time.Time `db:"deleted_at" json:"DeletedAt"`
+	ID              uuid.UUID      `db:"id" json:"id"`
+	ServiceID       uuid.UUID      `db:"service_id" json:"service_id"`
+	Slug            string         `db:"slug" json:"slug"` // unique within the service; set from the name if empty
+	Name            string         `db:"name" json:"name"`
+	URL             string         `db:"url" json:"url"`
+	Method          string         `db:"method" json:"method"`
+	Headers         JSONB          `db:"headers" json:"headers"`
+	ExpectedCode    int            `db:"expected_code" json:"expected_code"`
+	TimeoutMs       int            `db:"timeout_ms" json:"timeout_ms"`
+	IntervalSec     int            `db:"interval_sec" json:"interval_sec"`
+	Enabled         bool           `db:"enabled" json:"enabled"`
+	Regions         pq.StringArray `db:"regions" json:"regions"` // empty means any worker
+	Quorum          int            `db:"quorum" json:"quorum"`   // failing regions needed to call it down
+	Tags            pq.StringArray `db:"tags" json:"tags"`
+	Body            string         `db:"body" json:"body"`
+	ContentType     string         `db:"content_type" json:"content_type"`
+	QueryParams     JSONB          `db:"query_params" json:"query_params"`
+	FollowRedirects bool           `db:"follow_redirects" json:"follow_redirects"`
+	MaxRedirects    int            `db:"max_redirects" json:"max_redirects"`
+	UserAgent       string         `db:"user_agent" json:"user_agent"`
+	HTTPVersion     string         `db:"http_version" json:"http_version"` // "", "1.1" or "2"
+	Auth            *AuthConfig    `db:"auth" json:"auth"`
+	TLS             *TLSConfig     `db:"tls_config" json:"tls"`
+	CheckType       string         `db:"check_type" json:"check_type"`
+	Check           CheckConfig    `db:"check_config" json:"check"`
+	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time      `db:"updated_at" json:"updated_at"`
+	DeletedAt       *time.Time     `db:"deleted_at" json:"deleted_at"`
+	// Warnings are set by Redacted on the endpoints the API and CLI return,
+	// and ignored on input.
+	Warnings []string `db:"-" json:"warnings,omitempty"`
+}
+
+// WarningInsecureTLS is reported on endpoints that skip TLS certificate
+// verification.
+const WarningInsecureTLS = "tls verification disabled"
+
+// Check types an endpoint can use.
+const (
+	CheckHTTP        = "http"
+	CheckTLS         = "tls"
+	CheckTCP         = "tcp"
+	CheckUDP         = "udp"
+	CheckDNS         = "dns"
+	CheckGRPC        = "grpc"
+	CheckPostgres    = "postgres"
+	CheckMySQL       = "mysql"
+	CheckRedis       = "redis"
+	CheckTransaction = "transaction"
+	CheckScript      = "script"
+	CheckHeartbeat   = "heartbeat"
+	CheckWebSocket   = "websocket"
+	CheckSSE         = "sse"
+)
+
+// CheckTypes lists every supported check type.
+var CheckTypes = []string{
+	CheckHTTP, CheckTLS, CheckTCP, CheckUDP, CheckDNS, CheckGRPC,
+	CheckPostgres, CheckMySQL, CheckRedis, CheckTransaction, CheckScript,
+	CheckHeartbeat, CheckWebSocket, CheckSSE,
+}
+
+// CheckConfig holds the settings specific to an endpoint's check type.
+type CheckConfig struct {
+	// tls: days before expiry at which cert_expiring webhooks fire
+	CertExpiryDays []int `json:"cert_expiry_days,omitempty"`
+
+	// tcp, udp, websocket: payload to send once connected, and the response
+	// expected back as a substring and/or regular expression. Database checks
+	// match the expectation against the query result, and sse checks against
+	// event data.
+	Send        string `json:"send,omitempty"`
+	Expect      string `json:"expect,omitempty"`
+	ExpectRegex string `json:"expect_regex,omitempty"`
+
+	// dns: the record to look up (the endpoint URL holds the name), an
+	// optional nameserver, and how answers are compared. DNSBaseline is
+	// captured by the worker on the first lookup when matching "baseline".
+	RecordType     string   `json:"record_type,omitempty"`
+	Nameserver     string   `json:"nameserver,omitempty"`
+	ExpectedValues []string `json:"expected_values,omitempty"`
+	DNSMatch       string   `json:"dns_match,omitempty"`
+	DNSBaseline    []string `json:"dns_baseline,omitempty"`
+
+	// grpc: the service name passed to Health/Check (empty checks the
+	// server as a whole) and whether to connect without TLS
+	GRPCService   string `json:"grpc_service,omitempty"`
+	GRPCPlaintext bool   `json:"grpc_plaintext,omitempty"`
+
+	// postgres, mysql, redis: the query or command to run (default SELECT 1
+	// or PING) and the replication lag above which a replica fails. The
+	// connection string is the endpoint URL, which must be a secret reference.
+	Query                string `json:"query,omitempty"`
+	MaxReplicationLagSec int    `json:"max_replication_lag_sec,omitempty"`
+
+	// transaction: HTTP requests run in order, sharing cookies and
+	// variables extracted from earlier responses
+	Steps []TransactionStep `json:"steps,omitempty"`
+
+	// script: Starlark source defining check(), which returns a bool or a
+	// dict with success, message and metrics
+	Script string `json:"script,omitempty"`
+
+	// heartbeat: when check-ins are expected, as a cron expression (if unset
+	// the endpoint interval is used), and how late one may be
+	Schedule string `json:"schedule,omitempty"`
+	GraceSec int    `json:"grace_sec,omitempty"`
+
+	// sse: the event type to wait for (any type if unset)
+	SSEEvent string `json:"sse_event,omitempty"`
+}
+
+// TransactionStep is one request in a transaction check. URL, header values
+// and body may use {{var "name"}} to insert variables extracted by earlier
+// steps, as well as {{secret "name"}} references.
+type TransactionStep struct {
+	Name         string            `json:"name"`
+	Method       string            `json:"method,omitempty"` // default GET
+	URL          string            `json:"url"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	ExpectedCode int               `json:"expected_code,omitempty"` // default any 2xx
+	Extract      []Extraction      `json:"extract,omitempty"`
+	Assert       []Assertion       `json:"assert,omitempty"`
+}
+
+// Sources steps can extract variables from and assert on.
+const (
+	SourceStatus = "status" // the status code
+	SourceJSON   = "json"   // a JSONPath into the response body
+	SourceHeader = "header" // a response header
+	SourceRegex  = "regex"  // the first capture group (or whole match) in the body
+	SourceBody   = "body"   // the whole response body
+)
+
+// Extraction saves part of a step's response as a variable.
+type Extraction struct {
+	Var        string `json:"var"`
+	Source     string `json:"source"`               // json, header or regex
+	Expression string `json:"expression,omitempty"` // JSONPath, header name or regex
+}
+
+// Assertion checks part of a step's response.
+type Assertion struct {
+	Source     string `json:"source"`               // status, json, header, regex or body
+	Expression string `json:"expression,omitempty"` // JSONPath, header name or regex
+	Operator   string `json:"operator"`             // see AssertOperators
+	Value      string `json:"value,omitempty"`
+}
+
+// Assertion operators. less_than and greater_than compare numerically.
+var AssertOperators = []string{"equals", "not_equals", "contains", "matches", "exists", "less_than", "greater_than"}
+
+// DatabaseCheckTypes are the check types whose URL is a connection string.
+var DatabaseCheckTypes = []string{CheckPostgres, CheckMySQL, CheckRedis}
+
+// DNS record types a dns check can query.
+var DNSRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS", "SRV"}
+
+// How dns check answers are compared.
+const (
+	DNSMatchExact    = "exact"    // answers equal the expected values
+	DNSMatchContains = "contains" // answers include every expected value
+	DNSMatchBaseline = "baseline" // answers equal those first seen
+)
+
+// DefaultCertExpiryDays are the cert_expiring thresholds used when an
+// endpoint doesn't set its own.
+var DefaultCertExpiryDays = []int{30, 14, 7, 1}
+
+func (c CheckConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *CheckConfig) Scan(value interface{}) error {
+	*c = CheckConfig{}
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan type %T into CheckConfig", value)
+	}
+	return json.Unmarshal(data, c)
+}
+
+// HTTP versions an endpoint can prefer. An empty HTTPVersion lets the client
+// negotiate.
+const (
+	HTTPVersion11 = "1.1"
+	HTTPVersion2  = "2"
+)
+
+// Auth types supported by AuthConfig.
+const (
+	AuthBasic    = "basic"
+	AuthBearer   = "bearer"
+	AuthAPIKey   = "api_key"
+	AuthOAuth2   = "oauth2"
+	AuthAWSSigV4 = "aws_sigv4"
+)
+
+// AuthConfig describes how requests to an endpoint are authenticated. Only
+// the fields for the chosen Type are used.
+type AuthConfig struct {
+	Type string `json:"type"`
+
+	// basic
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// bearer
+	Token string `json:"token,omitempty"`
+
+	// api_key, sent as a header or query parameter
+	KeyName  string `json:"key_name,omitempty"`
+	KeyValue string `json:"key_value,omitempty"`
+	KeyIn    string `json:"key_in,omitempty"` // header, query
+
+	// oauth2 client credentials
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+
+	// aws_sigv4
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty"`
+	AWSRegion       string `json:"aws_region,omitempty"`
+	AWSService      string `json:"aws_service,omitempty"`
+}
+
+// Mask is what credentials are replaced with for display.
+const Mask = "********"
+
+// redactValue masks a credential for display. Secret references are left
+// as-is since they don't contain the value.
+func redactValue(value string) string {
+	if value == "" || secrets.IsReference(value) {
+		return value
+	}
+	return Mask
+}
+
+// sensitiveHeader reports whether a header is likely to carry credentials.
+func sensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	if name == "cookie" {
+		return true
+	}
+	for _, word := range []string{"auth", "token", "secret", "key", "password"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeaders returns a copy of the headers with credential values masked.
+func redactHeaders(headers JSONB) JSONB {
+	if headers == nil {
+		return nil
+	}
+	c := make(JSONB, len(headers))
+	for name, value := range headers {
+		if str, ok := value.(string); ok && sensitiveHeader(name) {
+			value = redactValue(str)
+		}
+		c[name] = value
+	}
+	return c
+}
+
+// Redacted returns a copy of the config with credentials masked, for display.
+func (a *AuthConfig) Redacted() *AuthConfig {
+	if a == nil {
+		return nil
+	}
+	c := *a
+	for _, field := range []*string{&c.Password, &c.Token, &c.KeyValue, &c.ClientSecret, &c.SecretAccessKey, &c.SessionToken} {
+		*field = redactValue(*field)
+	}
+	return &c
+}
+
+func (a AuthConfig) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *AuthConfig) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan type %T into AuthConfig", value)
+	}
+	return json.Unmarshal(data, a)
+}
+
+// TLS versions accepted as TLSConfig.MinVersion.
+var TLSVersions = []string{"1.0", "1.1", "1.2", "1.3"}
+
+// TLSConfig customizes the TLS connection made to an endpoint. Certificates,
+// keys and CA bundles are PEM values kept in the secrets store and referred
+// to by secret name.
+type TLSConfig struct {
+	ClientCertSecret   string `json:"client_cert_secret,omitempty"`
+	ClientKeySecret    string `json:"client_key_secret,omitempty"`
+	CASecret           string `json:"ca_secret,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	MinVersion         string `json:"min_version,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+func (t TLSConfig) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+func (t *TLSConfig) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan type %T into TLSConfig", value)
+	}
+	return json.Unmarshal(data, t)
+}
+
+// HasTag reports whether the endpoint is tagged tag.
+func (e ServiceEndpoint) HasTag(tag string) bool {
+	return contains(e.Tags, tag)
+}
+
+// Redacted returns a copy of the endpoint that is safe to print, with
+// warnings about settings that weaken its check.
+func (e ServiceEndpoint) Redacted() ServiceEndpoint {
+	e.Headers = redactHeaders(e.Headers)
+	e.Auth = e.Auth.Redacted()
+	e.Warnings = nil
+	if e.TLS != nil && e.TLS.InsecureSkipVerify {
+		e.Warnings = append(e.Warnings, WarningInsecureTLS)
+	}
+	return e
+}
+
+// NewEndpoint returns an endpoint with the defaults the CLI and API give
+// new endpoints.
+func NewEndpoint() ServiceEndpoint {
+	return ServiceEndpoint{
+		Method:          "GET",
+		Headers:         JSONB{},
+		ExpectedCode:    200,
+		TimeoutMs:       30000,
+		IntervalSec:     60,
+		Enabled:         true,
+		Quorum:          1,
+		FollowRedirects: true,
+		MaxRedirects:    10,
+	}
+}
+
+// KeepRedacted replaces the credentials in e that are still masked, as they
+// come back from Redacted, with the ones in current. It lets redacted
+// output be edited and saved without losing the credentials.
+func (e *ServiceEndpoint) KeepRedacted(current ServiceEndpoint) {
+	keepRedactedHeaders(e.Headers, current.Headers)
+	if e.Auth == nil || current.Auth == nil {
+		return
+	}
+	c := current.Auth
+	for _, pair := range [][2]*string{
+		{&e.Auth.Password, &c.Password}, {&e.Auth.Token, &c.Token}, {&e.Auth.KeyValue, &c.KeyValue},
+		{&e.Auth.ClientSecret, &c.ClientSecret}, {&e.Auth.SecretAccessKey, &c.SecretAccessKey},
+		{&e.Auth.SessionToken, &c.SessionToken},
+	} {
+		if *pair[0] == Mask {
+			*pair[0] = *pair[1]
+		}
+	}
+}
+
+func keepRedactedHeaders(headers, current JSONB) {
+	for name, value := range headers {
+		if value == Mask {
+			if old, ok := current[name]; ok {
+				headers[name] = old
+			}
+		}
+	}
 }
 
 type Ping struct {
-	ID         uuid.UUID  `db:"id"`
-	EndpointID uuid.UUID  `db:"endpoint_id"`
-	StatusCode int        `db:"status_code"`
-	ResponseMs int        `db:"response_ms"`
-	Success    bool       `db:"success"`
-	Error      *string    `db:"error"`
-	CreatedAt  time.Time  `db:"created_at"`
+	ID         uuid.UUID `db:"id" json:"id"`
+	EndpointID uuid.UUID `db:"endpoint_id" json:"endpoint_id"`
+	StatusCode int       `db:"status_code" json:"status_code"`
+	ResponseMs int       `db:"response_ms" json:"response_ms"`
+	Success    bool      `db:"success" json:"success"`
+	Error      *string   `db:"error" json:"error"`
+	Region     string    `db:"region" json:"region"`
+	PingTiming
+	Details   JSONB     `db:"details" json:"details"` // check-specific results, e.g. DNS answers
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// PingTiming breaks a request down into phases, in milliseconds. Phases that
+// didn't happen, such as DNS and connect on a reused connection, are nil.
+// TTFBMs is measured from the start of the request, so server processing
+// time is roughly TTFBMs minus the earlier phases.
+type PingTiming struct {
+	DNSMs      *int   `db:"dns_ms" json:"dns_ms"`
+	ConnectMs  *int   `db:"connect_ms" json:"connect_ms"`
+	TLSMs      *int   `db:"tls_ms" json:"tls_ms"`
+	TTFBMs     *int   `db:"ttfb_ms" json:"ttfb_ms"`
+	TransferMs *int   `db:"transfer_ms" json:"transfer_ms"`
+	QueryMs    *int   `db:"query_ms" json:"query_ms"` // database checks
+	RemoteIP   string `db:"remote_ip" json:"remote_ip"`
+	ConnReused bool   `db:"conn_reused" json:"conn_reused"`
+
+	// websocket and sse checks: until the connection is established, and
+	// from then until the first message or event arrives
+	HandshakeMs    *int `db:"handshake_ms" json:"handshake_ms"`
+	FirstMessageMs *int `db:"first_message_ms" json:"first_message_ms"`
 }
 
 type PingWindow struct {
-	ID           uuid.UUID  `db:"id"`
-	EndpointID   uuid.UUID  `db:"endpoint_id"`
-	WindowStart  time.Time  `db:"window_start"`
-	WindowEnd    time.Time  `db:"window_end"`
-	TotalPings   int        `db:"total_pings"`
-	SuccessPings int        `db:"success_pings"`
-	AvgResponseMs int       `db:"avg_response_ms"`
-	MinResponseMs int       `db:"min_response_ms"`
-	MaxResponseMs int       `db:"max_response_ms"`
-	CreatedAt    time.Time  `db:"created_at"`
+	ID            uuid.UUID `db:"id" json:"id"`
+	EndpointID    uuid.UUID `db:"endpoint_id" json:"endpoint_id"`
+	WindowStart   time.Time `db:"window_start" json:"window_start"`
+	WindowEnd     time.Time `db:"window_end" json:"window_end"`
+	TotalPings    int       `db:"total_pings" json:"total_pings"`
+	SuccessPings  int       `db:"success_pings" json:"success_pings"`
+	AvgResponseMs int       `db:"avg_response_ms" json:"avg_response_ms"`
+	MinResponseMs int       `db:"min_response_ms" json:"min_response_ms"`
+	MaxResponseMs int       `db:"max_response_ms" json:"max_response_ms"`
+	Region        string    `db:"region" json:"region"`
+	// Per-phase averages over the pings that recorded each phase
+	AvgDNSMs          *int      `db:"avg_dns_ms" json:"avg_dns_ms"`
+	AvgConnectMs      *int      `db:"avg_connect_ms" json:"avg_connect_ms"`
+	AvgTLSMs          *int      `db:"avg_tls_ms" json:"avg_tls_ms"`
+	AvgTTFBMs         *int      `db:"avg_ttfb_ms" json:"avg_ttfb_ms"`
+	AvgTransferMs     *int      `db:"avg_transfer_ms" json:"avg_transfer_ms"`
+	AvgQueryMs        *int      `db:"avg_query_ms" json:"avg_query_ms"`
+	AvgHandshakeMs    *int      `db:"avg_handshake_ms" json:"avg_handshake_ms"`
+	AvgFirstMessageMs *int      `db:"avg_first_message_ms" json:"avg_first_message_ms"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
 }
 
 type Incident struct {
-	ID         uuid.UUID  `db:"id"`
-	EndpointID uuid.UUID  `db:"endpoint_id"`
-	StartedAt  time.Time  `db:"started_at"`
-	ResolvedAt *time.Time `db:"resolved_at"`
-	Status     string     `db:"status"` // open, resolved
-	Message    string     `db:"message"`
-	CreatedAt  time.Time  `db:"created_at"`
-	UpdatedAt  time.Time  `db:"updated_at"`
+	ID         uuid.UUID  `db:"id" json:"id"`
+	EndpointID uuid.UUID  `db:"endpoint_id" json:"endpoint_id"`
+	StartedAt  time.Time  `db:"started_at" json:"started_at"`
+	ResolvedAt *time.Time `db:"resolved_at" json:"resolved_at"`
+	Status     string     `db:"status" json:"status"` // open, resolved
+	Message    string     `db:"message" json:"message"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// Certificate is the latest TLS certificate seen by an endpoint's tls check.
+type Certificate struct {
+	ID           uuid.UUID      `db:"id" json:"id"`
+	EndpointID   uuid.UUID      `db:"endpoint_id" json:"endpoint_id"`
+	Host         string         `db:"host" json:"host"`
+	Subject      string         `db:"subject" json:"subject"`
+	Issuer       string         `db:"issuer" json:"issuer"`
+	SANs         pq.StringArray `db:"sans" json:"sans"`
+	SerialNumber string         `db:"serial_number" json:"serial_number"`
+	NotBefore    time.Time      `db:"not_before" json:"not_before"`
+	NotAfter     time.Time      `db:"not_after" json:"not_after"`
+	ChainValid   bool           `db:"chain_valid" json:"chain_valid"`
+	ChainError   string         `db:"chain_error" json:"chain_error"`
+	OCSPStatus   string         `db:"ocsp_status" json:"ocsp_status"`     // good, revoked, unknown, or empty if not stapled
+	NotifiedDays *int           `db:"notified_days" json:"notified_days"` // smallest cert_expiring threshold already sent
+	CheckedAt    time.Time      `db:"checked_at" json:"checked_at"`
+	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// Heartbeat check-in statuses.
+const (
+	HeartbeatStart   = "start"
+	HeartbeatSuccess = "success"
+	HeartbeatFail    = "fail"
+)
+
+// Heartbeat tracks check-ins for a heartbeat endpoint. Jobs check in at a
+// URL containing the token.
+type Heartbeat struct {
+	EndpointID    uuid.UUID  `db:"endpoint_id" json:"endpoint_id"`
+	Token         string     `db:"token" json:"token"`
+	LastStatus    string     `db:"last_status" json:"last_status"`
+	LastStartAt   *time.Time `db:"last_start_at" json:"last_start_at"`
+	LastSuccessAt *time.Time `db:"last_success_at" json:"last_success_at"`
+	LastCheckinAt *time.Time `db:"last_checkin_at" json:"last_checkin_at"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 type Webhook struct {
-	ID        uuid.UUID  `db:"id"`
-	ServiceID uuid.UUID  `db:"service_id"`
-	Name      string     `db:"name"`
-	URL       string     `db:"url"`
-	Events    []string   `db:"events"` // incident_start, incident_resolved
-	Headers   JSONB      `db:"headers"`
-	Enabled   bool       `db:"enabled"`
-	CreatedAt time.Time  `db:"created_at"`
-	UpdatedAt time.Time  `db:"updated_at"`
-	DeletedAt *time.Time `db:"deleted_at"`
+	ID        uuid.UUID      `db:"id" json:"id"`
+	ServiceID uuid.UUID      `db:"service_id" json:"service_id"`
+	Name      string         `db:"name" json:"name"`
+	URL       string         `db:"url" json:"url"`
+	Events    pq.StringArray `db:"events" json:"events"` // incident_start, incident_resolved, cert_expiring
+	Headers   JSONB          `db:"headers" json:"headers"`
+	Enabled   bool           `db:"enabled" json:"enabled"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time     `db:"deleted_at" json:"deleted_at"`
+}
+
+// MaintenanceWindow is a planned period during which a service's endpoints
+// may fail without opening incidents, so no alerts go out. Pings are still
+// recorded.
+type MaintenanceWindow struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	ServiceID uuid.UUID  `db:"service_id" json:"service_id"`
+	Name      string     `db:"name" json:"name"`
+	StartsAt  time.Time  `db:"starts_at" json:"starts_at"`
+	EndsAt    time.Time  `db:"ends_at" json:"ends_at"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at"`
+}
+
+// Active reports whether the window covers t.
+func (m *MaintenanceWindow) Active(t time.Time) bool {
+	return !t.Before(m.StartsAt) && t.Before(m.EndsAt)
+}
+
+// Secret is an encrypted value referenced from endpoint and webhook settings
+// as {{secret "name"}}. The encrypted fields are never serialized.
+type Secret struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Name           string    `db:"name" json:"name"`
+	KeyID          string    `db:"key_id" json:"key_id"`
+	WrappedKey     []byte    `db:"wrapped_key" json:"-"`
+	Ciphertext     []byte    `db:"ciphertext" json:"-"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// User is a person or system that can call the API. Role applies to every
+// service in the user's organization; Grants give roles on individual
+// services on top of it. Users without an organization are operators, who
+// see every organization.
+type User struct {
+	ID             uuid.UUID      `db:"id" json:"id"`
+	OrganizationID *uuid.UUID     `db:"organization_id" json:"organization_id"`
+	Email          string         `db:"email" json:"email"`
+	Name           string         `db:"name" json:"name"`
+	Role           string         `db:"role" json:"role"` // viewer, editor, admin, or empty for grants only
+	Grants         []ServiceGrant `db:"-" json:"grants"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
+	DeletedAt      *time.Time     `db:"deleted_at" json:"deleted_at"`
+}
+
+// ServiceGrant gives a user a role on one service.
+type ServiceGrant struct {
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	ServiceID uuid.UUID `db:"service_id" json:"service_id"`
+	Role      string    `db:"role" json:"role"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// APIToken authenticates API requests as a user. Only a hash of the token
+// is stored; Prefix identifies it in listings. Scopes, if set, narrow what
+// the token can do below the user's roles.
+type APIToken struct {
+	ID         uuid.UUID      `db:"id" json:"id"`
+	UserID     uuid.UUID      `db:"user_id" json:"user_id"`
+	Name       string         `db:"name" json:"name"`
+	Prefix     string         `db:"prefix" json:"prefix"`
+	Hash       []byte         `db:"token_hash" json:"-"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes"` // e.g. endpoints:write, *:read
+	ExpiresAt  *time.Time     `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"last_used_at"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	RevokedAt  *time.Time     `db:"revoked_at" json:"revoked_at"`
+}
+
+// Audit log actions. Updates that only turn an endpoint or webhook on or
+// off are recorded as enable or disable.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditEnable  = "enable"
+	AuditDisable = "disable"
+	AuditResolve = "resolve"
+	AuditStart   = "start"
+	AuditStop    = "stop"
+)
+
+// AuditEntry records a configuration change: who made it, to which entity,
+// and the fields it changed. Before and After hold just the changed fields,
+// or the whole entity when it was created or deleted. Credentials are
+// redacted as they are in API responses.
+type AuditEntry struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	OrganizationID *uuid.UUID `db:"organization_id" json:"organization_id"`
+	Actor          string     `db:"actor" json:"actor"`
+	Action         string     `db:"action" json:"action"`
+	EntityType     string     `db:"entity_type" json:"entity_type"` // service, endpoint, webhook, incident, monitor, user, ...
+	EntityID       uuid.UUID  `db:"entity_id" json:"entity_id"`
+	Before         JSONB      `db:"before" json:"before"`
+	After          JSONB      `db:"after" json:"after"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Redacted returns a copy of the webhook that is safe to print.
+func (w Webhook) Redacted() Webhook {
+	w.Headers = redactHeaders(w.Headers)
+	return w
+}
+
+// NewWebhook returns a webhook with the defaults the CLI and API give new
+// webhooks.
+func NewWebhook() Webhook {
+	return Webhook{
+		Events:  []string{"incident_start", "incident_resolved"},
+		Headers: JSONB{},
+		Enabled: true,
+	}
+}
+
+// KeepRedacted replaces header values in w that are still masked with the
+// ones in current, like ServiceEndpoint.KeepRedacted.
+func (w *Webhook) KeepRedacted(current Webhook) {
+	keepRedactedHeaders(w.Headers, current.Headers)
 }
 
 type JSONB map[string]interface{}
@@ -93,11 +712,11 @@ func (j JSONB) Value() (driver.Value, error) {
 func (j *JSONB) Scan(value interface{}) error {
 	// Initialize to empty map by default
 	*j = make(map[string]interface{})
-	
+
 	if value == nil {
 		return nil
 	}
-	
+
 	var data []byte
 	switch v := value.(type) {
 	case []byte:
@@ -112,18 +731,18 @@ func (j *JSONB) Scan(value interface{}) error {
 		}
 		return fmt.Errorf("cannot scan type %T into JSONB", value)
 	}
-	
+
 	// If empty data, keep the initialized empty map
 	if len(data) == 0 || string(data) == "{}" || string(data) == "null" {
 		return nil
 	}
-	
+
 	// Only unmarshal if we have actual data
 	if err := json.Unmarshal(data, j); err != nil {
 		// If unmarshal fails, keep the empty map
 		return nil
 	}
-	
+
 	return nil
 }
 
@@ -140,11 +759,11 @@ func (j *JSONB) UnmarshalJSON(data []byte) error {
 	if j == nil {
 		return fmt.Errorf("JSONB: UnmarshalJSON on nil pointer")
 	}
-	
+
 	var m map[string]interface{}
 	if err := json.Unmarshal(data, &m); err != nil {
 		return err
 	}
 	*j = JSONB(m)
 	return nil
-}
\ No newline at end of file
+}
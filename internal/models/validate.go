@@ -0,0 +1,365 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/secrets"
+	"github.com/robfig/cron"
+)
+
+// Webhook events that can be subscribed to.
+var WebhookEvents = []string{"incident_start", "incident_resolved", "cert_expiring"}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+func validateSlug(slug string) error {
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("invalid slug %q (use lowercase letters, digits and dashes)", slug)
+	}
+	return nil
+}
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify derives a slug from a name: lowercase letters and digits, with
+// everything else collapsed to dashes. It returns "" if nothing is left.
+func Slugify(name string) string {
+	slug := strings.Trim(slugSeparators.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > 63 {
+		slug = strings.TrimRight(slug[:63], "-")
+	}
+	return slug
+}
+
+// Validate checks that an organization has a name, a valid slug and
+// non-negative quotas.
+func (o *Organization) Validate() error {
+	if strings.TrimSpace(o.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if err := validateSlug(o.Slug); err != nil {
+		return err
+	}
+	if o.MaxEndpoints < 0 || o.MinIntervalSec < 0 {
+		return fmt.Errorf("quotas can't be negative")
+	}
+	return nil
+}
+
+// Validate checks that a project has a name and a valid slug.
+func (p *Project) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	return validateSlug(p.Slug)
+}
+
+// Validate checks that a service is complete.
+func (s *Service) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if s.Slug != "" {
+		return validateSlug(s.Slug)
+	}
+	return nil
+}
+
+// Validate checks that a webhook is complete and only subscribes to known
+// events.
+func (w *Webhook) Validate() error {
+	if strings.TrimSpace(w.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if w.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if len(w.Events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, event := range w.Events {
+		if !contains(WebhookEvents, event) {
+			return fmt.Errorf("invalid event %q (use %s)", event, strings.Join(WebhookEvents, ", "))
+		}
+	}
+	return nil
+}
+
+// Validate checks that a maintenance window is named and ends after it
+// starts.
+func (m *MaintenanceWindow) Validate() error {
+	if strings.TrimSpace(m.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if m.StartsAt.IsZero() || m.EndsAt.IsZero() {
+		return fmt.Errorf("start and end times are required")
+	}
+	if !m.EndsAt.After(m.StartsAt) {
+		return fmt.Errorf("the window must end after it starts")
+	}
+	return nil
+}
+
+// Validate checks that a user has an email address and a known role, if any.
+func (u *User) Validate() error {
+	if !strings.Contains(u.Email, "@") {
+		return fmt.Errorf("a valid email is required")
+	}
+	if u.Role != "" && RoleRank(u.Role) == 0 {
+		return fmt.Errorf("invalid role %q (use %s)", u.Role, strings.Join(Roles, ", "))
+	}
+	return nil
+}
+
+// Validate checks that a grant names a known role.
+func (g *ServiceGrant) Validate() error {
+	if RoleRank(g.Role) == 0 {
+		return fmt.Errorf("invalid role %q (use %s)", g.Role, strings.Join(Roles, ", "))
+	}
+	return nil
+}
+
+// Validate checks that a token has a name and valid scopes, and doesn't
+// expire in the past.
+func (t *APIToken) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, scope := range t.Scopes {
+		if err := ValidateScope(scope); err != nil {
+			return err
+		}
+	}
+	if t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("expiry is in the past")
+	}
+	return nil
+}
+
+// Validate checks that an endpoint's settings are consistent: the fields
+// its check type needs are set, quorum can be met, and auth and TLS
+// settings are complete. It is used by every path that writes endpoints.
+func (e *ServiceEndpoint) Validate() error {
+	if strings.TrimSpace(e.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if e.Slug != "" {
+		if err := validateSlug(e.Slug); err != nil {
+			return err
+		}
+	}
+	for _, tag := range e.Tags {
+		if tag == "" || strings.ContainsAny(tag, ", \t\n") {
+			return fmt.Errorf("invalid tag %q: tags can't be empty or contain commas or spaces", tag)
+		}
+	}
+	checkType := e.CheckType
+	if checkType == "" {
+		checkType = CheckHTTP
+	}
+	if !contains(CheckTypes, checkType) {
+		return fmt.Errorf("invalid check type %q (use %s)", e.CheckType, strings.Join(CheckTypes, ", "))
+	}
+	if e.URL == "" && checkType != CheckHeartbeat {
+		return fmt.Errorf("url is required")
+	}
+	if e.TimeoutMs < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if e.IntervalSec < 1 {
+		return fmt.Errorf("interval must be at least 1 second")
+	}
+
+	quorum := e.Quorum
+	if quorum == 0 {
+		quorum = 1
+	}
+	if quorum < 1 {
+		return fmt.Errorf("quorum must be at least 1")
+	}
+	if n := len(e.Regions); n > 0 && quorum > n {
+		return fmt.Errorf("quorum %d exceeds the number of regions (%d)", quorum, n)
+	}
+	if len(e.Regions) == 0 && quorum > 1 {
+		return fmt.Errorf("quorum %d requires regions", quorum)
+	}
+
+	switch e.HTTPVersion {
+	case "", HTTPVersion11, HTTPVersion2:
+	default:
+		return fmt.Errorf("invalid HTTP version %q (use 1.1 or 2)", e.HTTPVersion)
+	}
+	if e.MaxRedirects < 0 {
+		return fmt.Errorf("max redirects must not be negative")
+	}
+	if e.FollowRedirects && e.MaxRedirects == 0 {
+		return fmt.Errorf("max redirects must be at least 1 when following redirects; turn off follow redirects instead")
+	}
+	if e.Auth != nil {
+		if err := e.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+	if e.TLS != nil {
+		if e.TLS.MinVersion != "" && !contains(TLSVersions, e.TLS.MinVersion) {
+			return fmt.Errorf("invalid TLS version %q (use %s)", e.TLS.MinVersion, strings.Join(TLSVersions, ", "))
+		}
+		if (e.TLS.ClientCertSecret == "") != (e.TLS.ClientKeySecret == "") {
+			return fmt.Errorf("client certificate and key secrets must be set together")
+		}
+	}
+
+	return e.Check.validate(checkType, e.URL)
+}
+
+// validate checks the settings specific to the check type.
+func (c *CheckConfig) validate(checkType, url string) error {
+	for _, days := range c.CertExpiryDays {
+		if days < 0 {
+			return fmt.Errorf("certificate expiry thresholds must not be negative")
+		}
+	}
+	if c.ExpectRegex != "" {
+		if _, err := regexp.Compile(c.ExpectRegex); err != nil {
+			return fmt.Errorf("invalid expect regex: %w", err)
+		}
+	}
+	if c.MaxReplicationLagSec < 0 {
+		return fmt.Errorf("max replication lag must not be negative")
+	}
+	if c.GraceSec < 0 {
+		return fmt.Errorf("grace must not be negative")
+	}
+	if c.Schedule != "" {
+		if _, err := cron.ParseStandard(c.Schedule); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+	}
+
+	switch checkType {
+	case CheckDNS:
+		if c.RecordType != "" && !contains(DNSRecordTypes, c.RecordType) {
+			return fmt.Errorf("invalid record type %q (use %s)", c.RecordType, strings.Join(DNSRecordTypes, ", "))
+		}
+		switch c.DNSMatch {
+		case "", DNSMatchExact, DNSMatchBaseline:
+		case DNSMatchContains:
+			if len(c.ExpectedValues) == 0 {
+				return fmt.Errorf("dns match contains requires expected values")
+			}
+		default:
+			return fmt.Errorf("invalid DNS match %q (use exact, contains or baseline)", c.DNSMatch)
+		}
+	case CheckPostgres, CheckMySQL, CheckRedis:
+		if !secrets.IsReference(url) {
+			return fmt.Errorf(`%s checks read their connection string from the secrets store: use a url of {{secret "name"}}`, checkType)
+		}
+	case CheckTransaction:
+		if len(c.Steps) == 0 {
+			return fmt.Errorf("transaction checks require steps")
+		}
+		if err := ValidateSteps(c.Steps); err != nil {
+			return err
+		}
+	case CheckScript:
+		if c.Script == "" {
+			return fmt.Errorf("script checks require a script")
+		}
+	case CheckWebSocket:
+		if !strings.HasPrefix(url, "ws://") && !strings.HasPrefix(url, "wss://") {
+			return fmt.Errorf("websocket checks need a ws:// or wss:// URL")
+		}
+	}
+	return nil
+}
+
+// Validate checks that the fields required by the auth type are set.
+func (a *AuthConfig) Validate() error {
+	var missing []string
+	require := func(field, value string) {
+		if value == "" {
+			missing = append(missing, field)
+		}
+	}
+
+	switch a.Type {
+	case AuthBasic:
+		require("username", a.Username)
+	case AuthBearer:
+		require("token", a.Token)
+	case AuthAPIKey:
+		require("key_name", a.KeyName)
+		require("key_value", a.KeyValue)
+		if a.KeyIn != "header" && a.KeyIn != "query" {
+			return fmt.Errorf("api_key auth key_in must be header or query")
+		}
+	case AuthOAuth2:
+		require("token_url", a.TokenURL)
+		require("client_id", a.ClientID)
+		require("client_secret", a.ClientSecret)
+	case AuthAWSSigV4:
+		require("access_key_id", a.AccessKeyID)
+		require("secret_access_key", a.SecretAccessKey)
+		require("aws_region", a.AWSRegion)
+		require("aws_service", a.AWSService)
+	default:
+		return fmt.Errorf("invalid auth type %q", a.Type)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%s auth requires %s", a.Type, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ValidateSteps checks that transaction steps are complete and only use
+// known sources and operators.
+func ValidateSteps(steps []TransactionStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("transaction has no steps")
+	}
+
+	for i, step := range steps {
+		where := fmt.Sprintf("step %d", i+1)
+		if step.Name != "" {
+			where += fmt.Sprintf(" (%s)", step.Name)
+		}
+		if step.URL == "" {
+			return fmt.Errorf("%s: url is required", where)
+		}
+		for _, e := range step.Extract {
+			if e.Var == "" {
+				return fmt.Errorf("%s: extract needs a var name", where)
+			}
+			if !contains([]string{SourceJSON, SourceHeader, SourceRegex}, e.Source) {
+				return fmt.Errorf("%s: invalid extract source %q (use json, header or regex)", where, e.Source)
+			}
+			if e.Source == SourceRegex {
+				if _, err := regexp.Compile(e.Expression); err != nil {
+					return fmt.Errorf("%s: invalid regex for %s: %w", where, e.Var, err)
+				}
+			}
+		}
+		for _, a := range step.Assert {
+			if !contains([]string{SourceStatus, SourceJSON, SourceHeader, SourceRegex, SourceBody}, a.Source) {
+				return fmt.Errorf("%s: invalid assert source %q (use status, json, header, regex or body)", where, a.Source)
+			}
+			if !contains(AssertOperators, a.Operator) {
+				return fmt.Errorf("%s: invalid assert operator %q (use %s)", where, a.Operator, strings.Join(AssertOperators, ", "))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Package receiver accepts check-ins from jobs monitored by heartbeat
+// endpoints. Jobs call /ping/{token} when they succeed, and optionally
+// /ping/{token}/start when they begin and /ping/{token}/fail when they fail.
+package receiver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
+	"github.com/beacon/internal/temporal"
+	"go.temporal.io/sdk/client"
+)
+
+// maxFailureBody caps how much of a failure check-in's body is kept as the
+// ping's error.
+const maxFailureBody = 10 << 10
+
+// Server records check-ins and signals the endpoint's heartbeat workflow.
+type Server struct {
+	DB       *db.DB
+	Temporal client.Client
+	// Region is recorded on the pings check-ins create.
+	Region string
+}
+
+// Handler returns the HTTP handler for check-ins.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping/", s.checkin)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK\n"))
+	})
+	return mux
+}
+
+func (s *Server) checkin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, status, ok := parsePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	heartbeat, err := s.DB.GetHeartbeatByToken(token)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to look up heartbeat: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	lastStatus, lastStart := heartbeat.LastStatus, heartbeat.LastStartAt
+	if err := s.DB.RecordCheckin(heartbeat, status, now); err != nil {
+		log.Printf("Failed to record check-in for %s: %v", heartbeat.EndpointID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if status != models.HeartbeatStart {
+		ping := &models.Ping{
+			EndpointID: heartbeat.EndpointID,
+			Success:    status == models.HeartbeatSuccess,
+			Region:     s.Region,
+		}
+		// A job that reported its start is timed from then
+		if lastStatus == models.HeartbeatStart && lastStart != nil {
+			ping.ResponseMs = int(now.Sub(*lastStart).Milliseconds())
+		}
+		if status == models.HeartbeatFail {
+			message := "job reported failure"
+			body, _ := io.ReadAll(io.LimitReader(r.Body, maxFailureBody))
+			if text := strings.TrimSpace(string(body)); text != "" {
+				message = text
+			}
+			ping.Error = &message
+		}
+		if err := s.DB.CreatePing(ping); err != nil {
+			log.Printf("Failed to save ping for %s: %v", heartbeat.EndpointID, err)
+		}
+	}
+
+	// The check-in is stored, so a workflow that isn't running yet will
+	// still see it when it starts
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	orgID, err := s.DB.EndpointOrganization(heartbeat.EndpointID)
+	if err == nil {
+		err = temporal.SignalHeartbeat(ctx, s.Temporal, orgID, heartbeat.EndpointID, status)
+	}
+	if err != nil {
+		log.Printf("Failed to signal heartbeat workflow for %s: %v", heartbeat.EndpointID, err)
+	}
+
+	w.Write([]byte("OK\n"))
+}
+
+// parsePath splits /ping/{token}[/start|/fail] into the token and the
+// check-in status.
+func parsePath(path string) (token, status string, ok bool) {
+	rest := strings.Trim(strings.TrimPrefix(path, "/ping/"), "/")
+	token, action, _ := strings.Cut(rest, "/")
+	if token == "" {
+		return "", "", false
+	}
+	switch action {
+	case "":
+		return token, models.HeartbeatSuccess, true
+	case models.HeartbeatStart, models.HeartbeatFail:
+		return token, action, true
+	default:
+		return "", "", false
+	}
+}
@@ -0,0 +1,199 @@
+// Package secrets encrypts secret values with envelope encryption and
+// resolves {{secret "name"}} references in endpoint and webhook settings.
+//
+// Each value is encrypted with its own random data key, and the data key is
+// in turn encrypted ("wrapped") with a master key supplied through the
+// environment. The master key never touches the database.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables holding the master key, either base64-encoded
+// directly or in a file. The previous key is only needed while rotating.
+const (
+	KeyEnv             = "BEACON_SECRETS_KEY"
+	KeyFileEnv         = "BEACON_SECRETS_KEY_FILE"
+	PreviousKeyEnv     = "BEACON_SECRETS_PREVIOUS_KEY"
+	PreviousKeyFileEnv = "BEACON_SECRETS_PREVIOUS_KEY_FILE"
+)
+
+const keySize = 32
+
+// ErrNoKey is returned by LoadKeyring when no master key is configured.
+var ErrNoKey = errors.New("secrets key not configured: set " + KeyEnv + " or " + KeyFileEnv)
+
+// Sealed is an encrypted secret value as stored in the database.
+type Sealed struct {
+	KeyID      string // identifies the master key that wrapped the data key
+	WrappedKey []byte // nonce || encrypted data key
+	Ciphertext []byte // nonce || encrypted value
+}
+
+// Keyring holds the current master key and, during rotation, the previous
+// one so values wrapped with either can be opened.
+type Keyring struct {
+	primary masterKey
+	keys    map[string]masterKey
+}
+
+type masterKey struct {
+	id  string
+	key []byte
+}
+
+// LoadKeyring reads the master key (and optional previous key) from the
+// environment.
+func LoadKeyring() (*Keyring, error) {
+	primary, err := loadKey(KeyEnv, KeyFileEnv)
+	if err != nil {
+		return nil, err
+	}
+	if primary == nil {
+		return nil, ErrNoKey
+	}
+
+	keys := [][]byte{primary}
+	previous, err := loadKey(PreviousKeyEnv, PreviousKeyFileEnv)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		keys = append(keys, previous)
+	}
+	return NewKeyring(keys...)
+}
+
+// NewKeyring builds a keyring from raw 32-byte keys. The first key is used
+// for sealing; all of them can open.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKey
+	}
+	k := &Keyring{keys: make(map[string]masterKey)}
+	for i, key := range keys {
+		if len(key) != keySize {
+			return nil, fmt.Errorf("secrets key must be %d bytes, got %d", keySize, len(key))
+		}
+		mk := masterKey{id: keyID(key), key: key}
+		if i == 0 {
+			k.primary = mk
+		}
+		k.keys[mk.id] = mk
+	}
+	return k, nil
+}
+
+// KeyID returns the ID of the key new values are sealed with.
+func (k *Keyring) KeyID() string {
+	return k.primary.id
+}
+
+// Seal encrypts a value under a fresh data key. The name is bound to the
+// ciphertext so a value can't be swapped onto another secret.
+func (k *Keyring) Seal(name string, plaintext []byte) (*Sealed, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	ciphertext, err := encrypt(dataKey, plaintext, []byte(name))
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := encrypt(k.primary.key, dataKey, []byte(name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sealed{KeyID: k.primary.id, WrappedKey: wrapped, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts a sealed value.
+func (k *Keyring) Open(name string, sealed *Sealed) ([]byte, error) {
+	mk, ok := k.keys[sealed.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("secret %q is encrypted with unknown key %s", name, sealed.KeyID)
+	}
+
+	dataKey, err := decrypt(mk.key, sealed.WrappedKey, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key for secret %q: %w", name, err)
+	}
+	plaintext, err := decrypt(dataKey, sealed.Ciphertext, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret %q: %w", name, err)
+	}
+	return plaintext, nil
+}
+
+func loadKey(env, fileEnv string) ([]byte, error) {
+	encoded := os.Getenv(env)
+	if path := os.Getenv(fileEnv); encoded == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", fileEnv, err)
+		}
+		// Accept a raw 32-byte key file as well as a base64 one
+		if len(data) == keySize {
+			return data, nil
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("secrets key is not valid base64: %w", err)
+	}
+	return key, nil
+}
+
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func decrypt(key, data, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,39 @@
+package secrets
+
+import (
+	"regexp"
+	"strings"
+)
+
+// refPattern matches {{secret "name"}}, allowing whitespace inside the braces.
+var refPattern = regexp.MustCompile(`\{\{\s*secret\s+"([^"]+)"\s*\}\}`)
+
+// Lookup returns the plaintext value of a named secret.
+type Lookup func(name string) (string, error)
+
+// Resolve replaces every secret reference in s with the secret's value.
+func Resolve(s string, lookup Lookup) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+
+	var firstErr error
+	resolved := refPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := refPattern.FindStringSubmatch(ref)[1]
+		value, err := lookup(name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return resolved, nil
+}
+
+// IsReference reports whether s consists of nothing but a secret reference.
+func IsReference(s string) bool {
+	loc := refPattern.FindStringIndex(strings.TrimSpace(s))
+	return loc != nil && loc[0] == 0 && loc[1] == len(strings.TrimSpace(s))
+}
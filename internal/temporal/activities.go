@@ -10,19 +10,29 @@ import (
 
 	"github.com/beacon/internal/db"
 	"github.com/beacon/internal/models"
+	"github.com/beacon/internal/secrets"
 	"github.com/google/uuid"
+	"go.temporal.io/sdk/activity"
 )
 
 type Activities struct {
 	DB *db.DB
+	// Region is recorded on every ping this worker makes.
+	Region string
+	// Secrets decrypts secrets referenced by endpoints and webhooks. May be
+	// nil if no key is configured, in which case references fail to resolve.
+	Secrets *secrets.Keyring
 }
 
 type PingResult struct {
 	EndpointID uuid.UUID
+	Region     string
 	StatusCode int
 	ResponseMs int
 	Success    bool
 	Error      string
+	Timing     models.PingTiming
+	Details    models.JSONB
 }
 
 func (a *Activities) PingEndpoint(ctx context.Context, endpointID uuid.UUID) (*PingResult, error) {
@@ -31,51 +41,74 @@ func (a *Activities) PingEndpoint(ctx context.Context, endpointID uuid.UUID) (*P
 		return nil, fmt.Errorf("failed to get endpoint: %w", err)
 	}
 
-	client := &http.Client{
-		Timeout: time.Duration(endpoint.TimeoutMs) * time.Millisecond,
+	result := &PingResult{
+		EndpointID: endpointID,
+		Region:     a.region(),
 	}
 
-	req, err := http.NewRequestWithContext(ctx, endpoint.Method, endpoint.URL, nil)
+	// Secrets are only ever resolved here, inside the worker
+	resolver := a.newSecretResolver(func() (uuid.UUID, error) {
+		return a.DB.EndpointOrganization(endpointID)
+	})
+	resolved, err := resolver.resolveEndpoint(endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		result.Error = fmt.Sprintf("failed to resolve secrets: %v", err)
+		return a.recordPing(result)
 	}
 
-	// Safely handle headers - they might be nil or empty
-	if endpoint.Headers != nil && len(endpoint.Headers) > 0 {
-		for key, value := range endpoint.Headers {
-			if strValue, ok := value.(string); ok {
-				req.Header.Set(key, strValue)
-			}
-		}
+	tlsConfig, err := resolver.tlsConfig(resolved.TLS)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid TLS config: %v", err)
+		return a.recordPing(result)
 	}
-
-	start := time.Now()
-	resp, err := client.Do(req)
-	responseMs := int(time.Since(start).Milliseconds())
-
-	result := &PingResult{
-		EndpointID: endpointID,
-		ResponseMs: responseMs,
+	if tlsConfig != nil && tlsConfig.InsecureSkipVerify {
+		activity.GetLogger(ctx).Warn("TLS certificate verification is disabled", "endpoint", endpointID)
 	}
 
+	switch endpoint.CheckType {
+	case models.CheckTLS:
+		err = a.checkCertificate(ctx, resolved, tlsConfig, result)
+	case models.CheckTCP:
+		err = checkTCP(ctx, resolved, result)
+	case models.CheckUDP:
+		err = checkUDP(ctx, resolved, result)
+	case models.CheckDNS:
+		err = a.checkDNS(ctx, resolved, result)
+	case models.CheckGRPC:
+		err = checkGRPC(ctx, resolved, tlsConfig, result)
+	case models.CheckPostgres, models.CheckMySQL, models.CheckRedis:
+		err = checkDatabase(ctx, resolved, result)
+	case models.CheckTransaction:
+		err = checkTransaction(ctx, endpoint, resolved, tlsConfig, result)
+	case models.CheckScript:
+		err = checkScript(ctx, resolved, tlsConfig, resolver.lookup, result)
+	case models.CheckWebSocket:
+		err = checkWebSocket(ctx, endpoint, resolved, tlsConfig, result)
+	case models.CheckSSE:
+		err = checkSSE(ctx, endpoint, resolved, tlsConfig, result)
+	case models.CheckHeartbeat:
+		// Heartbeats are pushed to the receiver, there is nothing to ping
+		return nil, fmt.Errorf("endpoint %s is a heartbeat and cannot be pinged", endpoint.Name)
+	default:
+		err = checkHTTP(ctx, endpoint, resolved, tlsConfig, result)
+	}
 	if err != nil {
-		result.Success = false
-		result.Error = err.Error()
-		result.StatusCode = 0
-	} else {
-		defer resp.Body.Close()
-		result.StatusCode = resp.StatusCode
-		result.Success = resp.StatusCode == endpoint.ExpectedCode
-		if !result.Success {
-			result.Error = fmt.Sprintf("Expected status %d but got %d", endpoint.ExpectedCode, resp.StatusCode)
-		}
+		return nil, err
 	}
 
+	return a.recordPing(result)
+}
+
+// recordPing stores the outcome of a check as a ping.
+func (a *Activities) recordPing(result *PingResult) (*PingResult, error) {
 	ping := &models.Ping{
-		EndpointID: endpointID,
+		EndpointID: result.EndpointID,
 		StatusCode: result.StatusCode,
 		ResponseMs: result.ResponseMs,
 		Success:    result.Success,
+		Region:     result.Region,
+		PingTiming: result.Timing,
+		Details:    result.Details,
 	}
 	if result.Error != "" {
 		ping.Error = &result.Error
@@ -106,6 +139,16 @@ func (a *Activities) CheckIncidentStatus(ctx context.Context, endpointID uuid.UU
 		}
 	} else {
 		if err != nil {
+			// Failures during a maintenance window are expected, so they
+			// don't open incidents or alert anyone
+			maintenance, err := a.DB.InMaintenance(endpointID, time.Now())
+			if err != nil {
+				return err
+			}
+			if maintenance {
+				return nil
+			}
+
 			endpoint, _ := a.DB.GetEndpoint(endpointID)
 			incident = &models.Incident{
 				EndpointID: endpointID,
@@ -129,6 +172,21 @@ func (a *Activities) CheckIncidentStatus(ctx context.Context, endpointID uuid.UU
 }
 
 func (a *Activities) TriggerWebhooks(ctx context.Context, serviceID uuid.UUID, event string, incident *models.Incident) error {
+	payload := map[string]interface{}{
+		"incident_id": incident.ID,
+		"endpoint_id": incident.EndpointID,
+		"started_at":  incident.StartedAt,
+		"message":     incident.Message,
+	}
+	if incident.ResolvedAt != nil {
+		payload["resolved_at"] = incident.ResolvedAt
+	}
+	return a.sendWebhooks(ctx, serviceID, event, payload)
+}
+
+// sendWebhooks posts an event to every enabled webhook of a service that
+// subscribes to it. Delivery is best effort.
+func (a *Activities) sendWebhooks(ctx context.Context, serviceID uuid.UUID, event string, payload map[string]interface{}) error {
 	webhooks, err := a.DB.ListEnabledWebhooks(serviceID, event)
 	if err != nil {
 		return fmt.Errorf("failed to list webhooks: %w", err)
@@ -138,59 +196,100 @@ func (a *Activities) TriggerWebhooks(ctx context.Context, serviceID uuid.UUID, e
 		Timeout: 10 * time.Second,
 	}
 
+	resolver := a.newSecretResolver(func() (uuid.UUID, error) {
+		return a.DB.ServiceOrganization(serviceID)
+	})
+
+	payload["event"] = event
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	logger := activity.GetLogger(ctx)
 	for _, webhook := range webhooks {
-		payload := map[string]interface{}{
-			"event":       event,
-			"incident_id": incident.ID,
-			"endpoint_id": incident.EndpointID,
-			"started_at":  incident.StartedAt,
-			"message":     incident.Message,
-		}
-		if incident.ResolvedAt != nil {
-			payload["resolved_at"] = incident.ResolvedAt
+		webhookURL, err := resolver.resolve(webhook.URL)
+		if err != nil {
+			logger.Warn("Failed to resolve webhook URL", "webhook", webhook.ID, "event", event, "error", err)
+			continue
 		}
-
-		payloadBytes, err := json.Marshal(payload)
+		headers, err := resolver.resolveJSONB(webhook.Headers)
 		if err != nil {
+			logger.Warn("Failed to resolve webhook headers", "webhook", webhook.ID, "event", event, "error", err)
 			continue
 		}
 
-		req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewReader(payloadBytes))
+		req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(payloadBytes))
 		if err != nil {
+			// The error can include the resolved URL
+			logger.Warn("Failed to build webhook request", "webhook", webhook.ID, "event", event, "error", redactURLError(err, webhook.URL))
 			continue
 		}
 
 		req.Header.Set("Content-Type", "application/json")
-		for key, value := range webhook.Headers {
+		for key, value := range headers {
 			if strValue, ok := value.(string); ok {
 				req.Header.Set(key, strValue)
 			}
 		}
 
-		client.Do(req)
+		resp, err := client.Do(req)
+		if err != nil {
+			logger.Warn("Failed to deliver webhook", "webhook", webhook.ID, "event", event, "error", redactURLError(err, webhook.URL))
+			continue
+		}
+		resp.Body.Close()
 	}
 
 	return nil
 }
 
+func (a *Activities) region() string {
+	if a.Region == "" {
+		return DefaultRegion
+	}
+	return a.Region
+}
+
 func (a *Activities) AggregateMetrics(ctx context.Context, endpointID uuid.UUID, windowStart, windowEnd time.Time) error {
-	pings, err := a.DB.ListPingsByTimeRange(endpointID, windowStart, windowEnd)
+	pings, err := a.DB.ListPingsByTimeRange(endpointID, "", windowStart, windowEnd)
 	if err != nil {
 		return fmt.Errorf("failed to list pings: %w", err)
 	}
 
-	if len(pings) == 0 {
-		return nil
+	// One window per region the endpoint was probed from
+	byRegion := make(map[string][]models.Ping)
+	var regions []string
+	for _, ping := range pings {
+		if _, ok := byRegion[ping.Region]; !ok {
+			regions = append(regions, ping.Region)
+		}
+		byRegion[ping.Region] = append(byRegion[ping.Region], ping)
+	}
+
+	for _, region := range regions {
+		window := aggregatePings(byRegion[region])
+		window.EndpointID = endpointID
+		window.WindowStart = windowStart
+		window.WindowEnd = windowEnd
+		window.Region = region
+
+		if err := a.DB.CreatePingWindow(window); err != nil {
+			return fmt.Errorf("failed to create ping window: %w", err)
+		}
 	}
 
+	return nil
+}
+
+// aggregatePings summarizes a non-empty set of pings into a window.
+func aggregatePings(pings []models.Ping) *models.PingWindow {
 	window := &models.PingWindow{
-		EndpointID:  endpointID,
-		WindowStart: windowStart,
-		WindowEnd:   windowEnd,
-		TotalPings:  len(pings),
+		TotalPings: len(pings),
 	}
 
 	var totalResponseMs int
+	var dns, connect, tlsHandshake, ttfb, transfer, query, handshake, firstMessage phaseAverage
 	minResponseMs := pings[0].ResponseMs
 	maxResponseMs := pings[0].ResponseMs
 
@@ -205,17 +304,49 @@ func (a *Activities) AggregateMetrics(ctx context.Context, endpointID uuid.UUID,
 		if ping.ResponseMs > maxResponseMs {
 			maxResponseMs = ping.ResponseMs
 		}
+		dns.add(ping.DNSMs)
+		connect.add(ping.ConnectMs)
+		tlsHandshake.add(ping.TLSMs)
+		ttfb.add(ping.TTFBMs)
+		transfer.add(ping.TransferMs)
+		query.add(ping.QueryMs)
+		handshake.add(ping.HandshakeMs)
+		firstMessage.add(ping.FirstMessageMs)
 	}
 
 	window.AvgResponseMs = totalResponseMs / len(pings)
 	window.MinResponseMs = minResponseMs
 	window.MaxResponseMs = maxResponseMs
+	window.AvgDNSMs = dns.average()
+	window.AvgConnectMs = connect.average()
+	window.AvgTLSMs = tlsHandshake.average()
+	window.AvgTTFBMs = ttfb.average()
+	window.AvgTransferMs = transfer.average()
+	window.AvgQueryMs = query.average()
+	window.AvgHandshakeMs = handshake.average()
+	window.AvgFirstMessageMs = firstMessage.average()
+
+	return window
+}
+
+// phaseAverage averages a timing phase over the pings that recorded it.
+type phaseAverage struct {
+	total, count int
+}
 
-	if err := a.DB.CreatePingWindow(window); err != nil {
-		return fmt.Errorf("failed to create ping window: %w", err)
+func (p *phaseAverage) add(ms *int) {
+	if ms != nil {
+		p.total += *ms
+		p.count++
 	}
+}
 
-	return nil
+func (p *phaseAverage) average() *int {
+	if p.count == 0 {
+		return nil
+	}
+	avg := p.total / p.count
+	return &avg
 }
 
 func (a *Activities) CleanupOldData(ctx context.Context, retentionDays int) error {
@@ -232,6 +363,27 @@ func (a *Activities) CleanupOldData(ctx context.Context, retentionDays int) erro
 	return nil
 }
 
+// MonitorConfig is how an endpoint's monitor pings it.
+type MonitorConfig struct {
+	IntervalSec int
+	Regions     []string
+	Quorum      int
+}
+
+// GetMonitorConfig reads the interval, regions and quorum an endpoint is
+// monitored with.
+func (a *Activities) GetMonitorConfig(ctx context.Context, endpointID uuid.UUID) (*MonitorConfig, error) {
+	endpoint, err := a.DB.GetEndpoint(endpointID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoint: %w", err)
+	}
+	return &MonitorConfig{
+		IntervalSec: endpoint.IntervalSec,
+		Regions:     endpoint.Regions,
+		Quorum:      endpoint.Quorum,
+	}, nil
+}
+
 func (a *Activities) GetEnabledEndpoints(ctx context.Context) ([]uuid.UUID, error) {
 	endpoints, err := a.DB.ListEnabledEndpoints()
 	if err != nil {
@@ -0,0 +1,253 @@
+package temporal
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beacon/internal/models"
+)
+
+// applyAuth adds the endpoint's credentials to the request. It must run
+// after every other header has been set, since SigV4 signs them.
+func applyAuth(ctx context.Context, req *http.Request, auth *models.AuthConfig, body string) error {
+	if auth == nil {
+		return nil
+	}
+
+	switch auth.Type {
+	case models.AuthBasic:
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case models.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case models.AuthAPIKey:
+		if auth.KeyIn == "query" {
+			query := req.URL.Query()
+			query.Set(auth.KeyName, auth.KeyValue)
+			req.URL.RawQuery = query.Encode()
+		} else {
+			req.Header.Set(auth.KeyName, auth.KeyValue)
+		}
+	case models.AuthOAuth2:
+		token, err := oauth2Tokens.get(ctx, auth)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case models.AuthAWSSigV4:
+		signSigV4(req, auth, body, time.Now())
+	default:
+		return fmt.Errorf("unsupported auth type %q", auth.Type)
+	}
+	return nil
+}
+
+// oauth2Tokens caches client-credentials tokens across pings so the token
+// endpoint is only hit when a token is missing or about to expire.
+var oauth2Tokens = &tokenCache{tokens: make(map[string]cachedToken)}
+
+// tokenRefreshMargin is how long before expiry a cached token is replaced.
+const tokenRefreshMargin = 30 * time.Second
+
+type cachedToken struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+}
+
+// tokenCacheKey identifies a token by everything that's sent to get it. The
+// client secret is included as a hash, so a rotated secret fetches a new
+// token without the secret itself being kept in memory as a map key.
+func tokenCacheKey(auth *models.AuthConfig) string {
+	secret := sha256.Sum256([]byte(auth.ClientSecret))
+	return strings.Join([]string{auth.TokenURL, auth.ClientID, strings.Join(auth.Scopes, " "), hex.EncodeToString(secret[:])}, "|")
+}
+
+func (c *tokenCache) get(ctx context.Context, auth *models.AuthConfig) (string, error) {
+	key := tokenCacheKey(auth)
+
+	c.mu.Lock()
+	token, ok := c.tokens[key]
+	c.mu.Unlock()
+	if ok && time.Now().Add(tokenRefreshMargin).Before(token.expiresAt) {
+		return token.accessToken, nil
+	}
+
+	token, err := fetchClientCredentialsToken(ctx, auth)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.tokens[key] = token
+	c.mu.Unlock()
+	return token.accessToken, nil
+}
+
+// invalidate drops a cached token, e.g. after the endpoint rejected it.
+func (c *tokenCache) invalidate(auth *models.AuthConfig) {
+	c.mu.Lock()
+	delete(c.tokens, tokenCacheKey(auth))
+	c.mu.Unlock()
+}
+
+func fetchClientCredentialsToken(ctx context.Context, auth *models.AuthConfig) (cachedToken, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return cachedToken{}, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&payload); err != nil {
+		return cachedToken{}, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if payload.AccessToken == "" {
+		return cachedToken{}, fmt.Errorf("token response has no access_token")
+	}
+
+	// Tokens without an expiry are refreshed every few minutes to be safe
+	expiresIn := time.Duration(payload.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 5 * time.Minute
+	}
+	return cachedToken{accessToken: payload.AccessToken, expiresAt: time.Now().Add(expiresIn)}, nil
+}
+
+// signSigV4 signs the request with AWS Signature Version 4.
+func signSigV4(req *http.Request, auth *models.AuthConfig, body string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := sha256Hex([]byte(body))
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if auth.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", auth.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{dateStamp, auth.AWSRegion, auth.AWSService, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+auth.SecretAccessKey), dateStamp)
+	key = hmacSHA256(key, auth.AWSRegion)
+	key = hmacSHA256(key, auth.AWSService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		auth.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQueryString sorts parameters by key and value and encodes spaces
+// as %20, as SigV4 requires.
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, sigV4Escape(key)+"="+sigV4Escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func sigV4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,250 @@
+package temporal
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/ocsp"
+)
+
+// OCSP stapling states recorded on certificates.
+const (
+	OCSPGood    = "good"
+	OCSPRevoked = "revoked"
+	OCSPUnknown = "unknown"
+)
+
+// checkCertificate connects to the endpoint's host, records its leaf
+// certificate and chain validity, and sends cert_expiring webhooks as the
+// expiry date crosses the configured thresholds. The check fails if the
+// handshake fails, the certificate has expired or been revoked, or the
+// chain doesn't verify (unless verification is explicitly skipped).
+func (a *Activities) checkCertificate(ctx context.Context, endpoint *models.ServiceEndpoint, tlsConfig *tls.Config, result *PingResult) error {
+	host, port, err := tlsTarget(endpoint.URL)
+	if err != nil {
+		result.Error = err.Error()
+		return nil
+	}
+
+	config := &tls.Config{}
+	if tlsConfig != nil {
+		config = tlsConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	// Verification is done below so the certificate is recorded even when
+	// the chain is broken.
+	skipVerify := config.InsecureSkipVerify
+	config.InsecureSkipVerify = true
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: time.Duration(endpoint.TimeoutMs) * time.Millisecond},
+		Config:    config,
+	}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	result.ResponseMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		result.Error = fmt.Sprintf("TLS handshake failed: %v", err)
+		return nil
+	}
+	state := conn.(*tls.Conn).ConnectionState()
+	conn.Close()
+
+	if len(state.PeerCertificates) == 0 {
+		result.Error = "server presented no certificates"
+		return nil
+	}
+	leaf := state.PeerCertificates[0]
+
+	cert := &models.Certificate{
+		EndpointID:   endpoint.ID,
+		Host:         host,
+		Subject:      leaf.Subject.String(),
+		Issuer:       leaf.Issuer.String(),
+		SANs:         certificateSANs(leaf),
+		SerialNumber: leaf.SerialNumber.Text(16),
+		NotBefore:    leaf.NotBefore,
+		NotAfter:     leaf.NotAfter,
+		ChainValid:   true,
+		OCSPStatus:   stapledOCSPStatus(state),
+		CheckedAt:    time.Now(),
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range state.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+	_, verifyErr := leaf.Verify(x509.VerifyOptions{
+		DNSName:       config.ServerName,
+		Roots:         config.RootCAs,
+		Intermediates: intermediates,
+	})
+	if verifyErr != nil {
+		cert.ChainValid = false
+		cert.ChainError = verifyErr.Error()
+	}
+
+	if err := a.recordCertificate(ctx, endpoint, cert); err != nil {
+		return err
+	}
+
+	daysLeft := certDaysLeft(cert.NotAfter, time.Now())
+	switch {
+	case time.Now().After(cert.NotAfter):
+		result.Error = fmt.Sprintf("certificate expired on %s", cert.NotAfter.Format(time.RFC3339))
+	case cert.OCSPStatus == OCSPRevoked:
+		result.Error = "certificate has been revoked (stapled OCSP response)"
+	case !cert.ChainValid && !skipVerify:
+		result.Error = fmt.Sprintf("certificate chain is invalid: %s", cert.ChainError)
+	default:
+		result.Success = true
+	}
+	// A certificate close to expiry still passes; Error is only for failures
+	if result.Success && daysLeft <= smallestThreshold(endpoint.Check.CertExpiryDays) {
+		if result.Details == nil {
+			result.Details = models.JSONB{}
+		}
+		result.Details["warning"] = fmt.Sprintf("certificate expires in %d days", daysLeft)
+	}
+
+	return nil
+}
+
+// recordCertificate stores the certificate and fires a cert_expiring
+// webhook the first time it crosses each threshold. A renewed certificate
+// starts over.
+func (a *Activities) recordCertificate(ctx context.Context, endpoint *models.ServiceEndpoint, cert *models.Certificate) error {
+	previous, err := a.DB.GetCertificate(endpoint.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get certificate: %w", err)
+	}
+	if previous != nil && previous.NotAfter.Equal(cert.NotAfter) {
+		cert.NotifiedDays = previous.NotifiedDays
+	}
+
+	daysLeft := certDaysLeft(cert.NotAfter, time.Now())
+	threshold, crossed := crossedThreshold(endpoint.Check.CertExpiryDays, daysLeft)
+	notify := crossed && (cert.NotifiedDays == nil || threshold < *cert.NotifiedDays)
+	if notify {
+		cert.NotifiedDays = &threshold
+	}
+
+	if err := a.DB.UpsertCertificate(cert); err != nil {
+		return fmt.Errorf("failed to save certificate: %w", err)
+	}
+
+	if notify {
+		payload := map[string]interface{}{
+			"endpoint_id":   endpoint.ID,
+			"endpoint_name": endpoint.Name,
+			"host":          cert.Host,
+			"subject":       cert.Subject,
+			"issuer":        cert.Issuer,
+			"not_after":     cert.NotAfter,
+			"days_left":     daysLeft,
+			"threshold":     threshold,
+			"message":       fmt.Sprintf("Certificate for %s expires in %d days", cert.Host, daysLeft),
+		}
+		if err := a.sendWebhooks(ctx, endpoint.ServiceID, "cert_expiring", payload); err != nil {
+			return fmt.Errorf("failed to trigger webhooks: %w", err)
+		}
+	}
+	return nil
+}
+
+// tlsTarget extracts host and port from an https URL or a host:port pair.
+func tlsTarget(target string) (host, port string, err error) {
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid URL: %w", err)
+		}
+		host, port = u.Hostname(), u.Port()
+	} else if host, port, err = net.SplitHostPort(target); err != nil {
+		host = target
+	}
+	if port == "" {
+		port = "443"
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("no host in %q", target)
+	}
+	return host, port, nil
+}
+
+func certificateSANs(cert *x509.Certificate) pq.StringArray {
+	sans := pq.StringArray{}
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, email := range cert.EmailAddresses {
+		sans = append(sans, email)
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
+
+// stapledOCSPStatus reports the status in a stapled OCSP response, or an
+// empty string if the server didn't staple one.
+func stapledOCSPStatus(state tls.ConnectionState) string {
+	if len(state.OCSPResponse) == 0 || len(state.PeerCertificates) < 2 {
+		return ""
+	}
+	resp, err := ocsp.ParseResponseForCert(state.OCSPResponse, state.PeerCertificates[0], state.PeerCertificates[1])
+	if err != nil {
+		return OCSPUnknown
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		return OCSPGood
+	case ocsp.Revoked:
+		return OCSPRevoked
+	default:
+		return OCSPUnknown
+	}
+}
+
+func certDaysLeft(notAfter, now time.Time) int {
+	return int(math.Floor(notAfter.Sub(now).Hours() / 24))
+}
+
+func expiryThresholds(days []int) []int {
+	if len(days) == 0 {
+		days = models.DefaultCertExpiryDays
+	}
+	sorted := append([]int(nil), days...)
+	sort.Ints(sorted)
+	return sorted
+}
+
+func smallestThreshold(days []int) int {
+	return expiryThresholds(days)[0]
+}
+
+// crossedThreshold returns the smallest threshold that daysLeft has reached.
+func crossedThreshold(days []int, daysLeft int) (int, bool) {
+	for _, threshold := range expiryThresholds(days) {
+		if daysLeft <= threshold {
+			return threshold, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,90 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+const (
+	// TaskQueue is polled by every worker for workflows and shared activities.
+	TaskQueue = "beacon-monitoring"
+
+	// DefaultRegion is recorded on pings made by workers that have no
+	// BEACON_REGION configured.
+	DefaultRegion = "default"
+)
+
+// RegionTaskQueue returns the task queue that workers in a region poll for
+// PingEndpoint activities.
+func RegionTaskQueue(region string) string {
+	return fmt.Sprintf("%s-%s", TaskQueue, region)
+}
+
+// MonitorWorkflowID returns the ID of the monitoring workflow for an
+// endpoint, namespaced by the organization it belongs to.
+func MonitorWorkflowID(organizationID, endpointID uuid.UUID) string {
+	return fmt.Sprintf("org-%s/monitor-endpoint-%s", organizationID, endpointID)
+}
+
+// LegacyMonitorWorkflowID returns the ID monitoring workflows had before
+// organizations. Monitors started then keep it until they're restarted.
+func LegacyMonitorWorkflowID(endpointID uuid.UUID) string {
+	return fmt.Sprintf("monitor-endpoint-%s", endpointID)
+}
+
+// StartMonitor starts the monitoring workflow for an endpoint. Heartbeat
+// endpoints are watched by HeartbeatWorkflow instead of being pinged. A
+// monitor still running under its legacy ID is stopped first, so the
+// endpoint isn't monitored twice.
+func StartMonitor(ctx context.Context, c client.Client, organizationID uuid.UUID, endpoint *models.ServiceEndpoint) (client.WorkflowRun, error) {
+	err := c.CancelWorkflow(ctx, LegacyMonitorWorkflowID(endpoint.ID), "")
+	if err != nil && !isNotFound(err) {
+		return nil, fmt.Errorf("failed to stop legacy monitor: %w", err)
+	}
+	options := client.StartWorkflowOptions{
+		ID:        MonitorWorkflowID(organizationID, endpoint.ID),
+		TaskQueue: TaskQueue,
+	}
+	if endpoint.CheckType == models.CheckHeartbeat {
+		return c.ExecuteWorkflow(ctx, options, HeartbeatWorkflow, endpoint.ID)
+	}
+	return c.ExecuteWorkflow(ctx, options, MonitorEndpointWorkflow, endpoint.ID, endpoint.IntervalSec)
+}
+
+// StopMonitor cancels the monitoring workflow for an endpoint under both
+// its current and its legacy ID. It returns a *serviceerror.NotFound if
+// neither was running.
+func StopMonitor(ctx context.Context, c client.Client, organizationID, endpointID uuid.UUID) error {
+	err := c.CancelWorkflow(ctx, MonitorWorkflowID(organizationID, endpointID), "")
+	legacyErr := c.CancelWorkflow(ctx, LegacyMonitorWorkflowID(endpointID), "")
+	switch {
+	case isNotFound(legacyErr):
+		return err
+	case legacyErr != nil:
+		return legacyErr
+	case isNotFound(err):
+		// Only the legacy workflow was running
+		return nil
+	}
+	return err
+}
+
+// SignalHeartbeat tells a heartbeat endpoint's workflow about a check-in.
+func SignalHeartbeat(ctx context.Context, c client.Client, organizationID, endpointID uuid.UUID, status string) error {
+	err := c.SignalWorkflow(ctx, MonitorWorkflowID(organizationID, endpointID), "", HeartbeatSignal, status)
+	if isNotFound(err) {
+		err = c.SignalWorkflow(ctx, LegacyMonitorWorkflowID(endpointID), "", HeartbeatSignal, status)
+	}
+	return err
+}
+
+func isNotFound(err error) bool {
+	var notFound *serviceerror.NotFound
+	return errors.As(err, &notFound)
+}
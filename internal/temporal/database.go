@@ -0,0 +1,280 @@
+package temporal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/redis/go-redis/v9"
+)
+
+var defaultQueries = map[string]string{
+	models.CheckPostgres: "SELECT 1",
+	models.CheckMySQL:    "SELECT 1",
+	models.CheckRedis:    "PING",
+}
+
+// sqlDrivers maps check types to database/sql driver names.
+var sqlDrivers = map[string]string{
+	models.CheckPostgres: "postgres",
+	models.CheckMySQL:    "mysql",
+}
+
+// checkDatabase connects to the database or cache at the endpoint's
+// connection string, runs the configured query or command, and checks the
+// result and replication lag. Connect and query times are recorded
+// separately.
+func checkDatabase(ctx context.Context, endpoint *models.ServiceEndpoint, result *PingResult) error {
+	check := endpoint.Check
+	query := check.Query
+	if query == "" {
+		query = defaultQueries[endpoint.CheckType]
+	}
+
+	var expectRegex *regexp.Regexp
+	if check.ExpectRegex != "" {
+		var err error
+		if expectRegex, err = regexp.Compile(check.ExpectRegex); err != nil {
+			result.Error = fmt.Sprintf("invalid expect regex: %v", err)
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(endpoint.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	var probe databaseProbe
+	var err error
+	if endpoint.CheckType == models.CheckRedis {
+		probe, err = newRedisProbe(endpoint.URL)
+	} else {
+		probe, err = newSQLProbe(sqlDrivers[endpoint.CheckType], endpoint.URL)
+	}
+	if err != nil {
+		// Driver errors can echo the connection string, so don't include them
+		result.Error = "invalid connection string"
+		return nil
+	}
+	defer probe.Close()
+
+	start := time.Now()
+	err = probe.Connect(ctx)
+	result.Timing.ConnectMs = sinceMs(start)
+	if err != nil {
+		result.ResponseMs = int(time.Since(start).Milliseconds())
+		result.Error = fmt.Sprintf("connect failed: %v", err)
+		return nil
+	}
+
+	queryStart := time.Now()
+	output, err := probe.Query(ctx, query)
+	result.Timing.QueryMs = sinceMs(queryStart)
+	result.ResponseMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		result.Error = fmt.Sprintf("query failed: %v", err)
+		return nil
+	}
+	result.Details = models.JSONB{"result": truncate([]byte(output), 1024)}
+
+	if (check.Expect != "" || expectRegex != nil) && !matchesExpectation(check, expectRegex, []byte(output)) {
+		result.Error = fmt.Sprintf("unexpected result %q", truncate([]byte(output), 200))
+		return nil
+	}
+
+	if check.MaxReplicationLagSec > 0 {
+		lag, err := probe.ReplicationLag(ctx)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to check replication lag: %v", err)
+			return nil
+		}
+		result.Details["replication_lag_sec"] = lag
+		if lag > float64(check.MaxReplicationLagSec) {
+			result.Error = fmt.Sprintf("replication lag %.0fs exceeds %ds", lag, check.MaxReplicationLagSec)
+			return nil
+		}
+	}
+
+	result.Success = true
+	return nil
+}
+
+// databaseProbe is the connection a database check runs through.
+type databaseProbe interface {
+	Connect(ctx context.Context) error
+	// Query runs a query or command and returns its result as text.
+	Query(ctx context.Context, query string) (string, error)
+	// ReplicationLag returns how far a replica is behind, or 0 on a primary.
+	ReplicationLag(ctx context.Context) (float64, error)
+	Close() error
+}
+
+type sqlProbe struct {
+	driver string
+	db     *sql.DB
+}
+
+func newSQLProbe(driver, dsn string) (*sqlProbe, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return &sqlProbe{driver: driver, db: db}, nil
+}
+
+func (p *sqlProbe) Connect(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+// Query returns the first row of the result, with columns separated by
+// tabs, or an empty string if there are no rows.
+func (p *sqlProbe) Query(ctx context.Context, query string) (string, error) {
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if !rows.Next() {
+		return "", rows.Err()
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", err
+	}
+
+	fields := make([]string, len(values))
+	for i, value := range values {
+		fields[i] = value.String
+	}
+	return strings.Join(fields, "\t"), nil
+}
+
+func (p *sqlProbe) ReplicationLag(ctx context.Context) (float64, error) {
+	if p.driver == "postgres" {
+		var lag float64
+		err := p.db.QueryRowContext(ctx, `
+			SELECT CASE WHEN pg_is_in_recovery()
+			       THEN COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+			       ELSE 0 END
+		`).Scan(&lag)
+		return lag, err
+	}
+	return p.mysqlReplicationLag(ctx)
+}
+
+// mysqlReplicationLag reads Seconds_Behind_Source (Seconds_Behind_Master
+// before MySQL 8.0.22) from the replica status.
+func (p *sqlProbe) mysqlReplicationLag(ctx context.Context) (float64, error) {
+	rows, err := p.db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = p.db.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, err
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		// Not a replica
+		return 0, rows.Err()
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if !values[i].Valid {
+			return 0, fmt.Errorf("replication is not running")
+		}
+		return strconv.ParseFloat(values[i].String, 64)
+	}
+	return 0, fmt.Errorf("replica status has no lag column")
+}
+
+func (p *sqlProbe) Close() error {
+	return p.db.Close()
+}
+
+type redisProbe struct {
+	client *redis.Client
+}
+
+func newRedisProbe(url string) (*redisProbe, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	opts.PoolSize = 1
+	opts.MaxRetries = -1
+	return &redisProbe{client: redis.NewClient(opts)}, nil
+}
+
+func (p *redisProbe) Connect(ctx context.Context) error {
+	return p.client.Ping(ctx).Err()
+}
+
+func (p *redisProbe) Query(ctx context.Context, command string) (string, error) {
+	fields := strings.Fields(command)
+	args := make([]interface{}, len(fields))
+	for i, field := range fields {
+		args[i] = field
+	}
+	value, err := p.client.Do(ctx, args...).Result()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(value), nil
+}
+
+// ReplicationLag reports seconds since a replica last heard from its
+// primary, failing if the link is down.
+func (p *redisProbe) ReplicationLag(ctx context.Context) (float64, error) {
+	info, err := p.client.Info(ctx, "replication").Result()
+	if err != nil {
+		return 0, err
+	}
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), ":"); ok {
+			fields[key] = value
+		}
+	}
+	if fields["role"] != "slave" {
+		return 0, nil
+	}
+	if fields["master_link_status"] != "up" {
+		return 0, fmt.Errorf("replication link is %s", fields["master_link_status"])
+	}
+	return strconv.ParseFloat(fields["master_last_io_seconds_ago"], 64)
+}
+
+func (p *redisProbe) Close() error {
+	return p.client.Close()
+}
@@ -0,0 +1,189 @@
+package temporal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+)
+
+// checkDNS resolves the endpoint's record and compares the answers with the
+// expected values or the stored baseline. The answers are recorded on the
+// ping either way.
+func (a *Activities) checkDNS(ctx context.Context, endpoint *models.ServiceEndpoint, result *PingResult) error {
+	check := endpoint.Check
+	name := strings.TrimPrefix(endpoint.URL, "dns://")
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(endpoint.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	answers, err := lookupRecord(ctx, newDNSResolver(check.Nameserver), name, check.RecordType)
+	result.ResponseMs = int(time.Since(start).Milliseconds())
+	dnsMs := result.ResponseMs
+	result.Timing.DNSMs = &dnsMs
+	result.Details = models.JSONB{
+		"record_type": check.RecordType,
+		"answers":     answers,
+	}
+	if check.Nameserver != "" {
+		result.Details["nameserver"] = check.Nameserver
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("lookup failed: %v", err)
+		return nil
+	}
+	if len(answers) == 0 {
+		result.Error = "no answers"
+		return nil
+	}
+
+	switch check.DNSMatch {
+	case models.DNSMatchBaseline:
+		if len(check.DNSBaseline) == 0 {
+			if err := a.DB.SetDNSBaseline(endpoint.ID, answers); err != nil {
+				return fmt.Errorf("failed to save DNS baseline: %w", err)
+			}
+		} else if !sameAnswers(answers, normalizeAnswers(check.RecordType, check.DNSBaseline)) {
+			result.Error = fmt.Sprintf("answers changed from baseline %v to %v", check.DNSBaseline, answers)
+			return nil
+		}
+	case models.DNSMatchContains:
+		expected := normalizeAnswers(check.RecordType, check.ExpectedValues)
+		for _, value := range expected {
+			if !containsAnswer(answers, value) {
+				result.Error = fmt.Sprintf("expected %q in answers %v", value, answers)
+				return nil
+			}
+		}
+	default:
+		if len(check.ExpectedValues) > 0 && !sameAnswers(answers, normalizeAnswers(check.RecordType, check.ExpectedValues)) {
+			result.Error = fmt.Sprintf("expected answers %v but got %v", check.ExpectedValues, answers)
+			return nil
+		}
+	}
+
+	result.Success = true
+	return nil
+}
+
+// newDNSResolver returns a resolver that queries the given nameserver, or
+// the system resolver if none is set.
+func newDNSResolver(nameserver string) *net.Resolver {
+	if nameserver == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(nameserver, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, nameserver)
+		},
+	}
+}
+
+// lookupRecord returns the record's answers in a normalized, sorted form:
+// names are lowercased without the trailing dot, MX answers are
+// "preference host" and SRV answers are "priority weight port target".
+func lookupRecord(ctx context.Context, resolver *net.Resolver, name, recordType string) ([]string, error) {
+	var answers []string
+	switch strings.ToUpper(recordType) {
+	case "A", "AAAA":
+		network := "ip4"
+		if strings.ToUpper(recordType) == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, cname)
+	case "MX":
+		records, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range records {
+			answers = append(answers, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+	case "TXT":
+		records, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, records...)
+	case "NS":
+		records, err := resolver.LookupNS(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range records {
+			answers = append(answers, ns.Host)
+		}
+	case "SRV":
+		_, records, err := resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, err
+		}
+		for _, srv := range records {
+			answers = append(answers, fmt.Sprintf("%d %d %d %s", srv.Priority, srv.Weight, srv.Port, srv.Target))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+	return normalizeAnswers(recordType, answers), nil
+}
+
+// normalizeAnswers puts answers, or expected values, into the form produced
+// by lookupRecord so they can be compared directly.
+func normalizeAnswers(recordType string, answers []string) []string {
+	normalized := make([]string, 0, len(answers))
+	for _, answer := range answers {
+		answer = strings.TrimSpace(answer)
+		if strings.ToUpper(recordType) != "TXT" {
+			answer = strings.TrimSuffix(strings.ToLower(answer), ".")
+			if ip := net.ParseIP(answer); ip != nil {
+				answer = ip.String()
+			}
+		}
+		normalized = append(normalized, answer)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func sameAnswers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAnswer(answers []string, value string) bool {
+	for _, answer := range answers {
+		if answer == value {
+			return true
+		}
+	}
+	return false
+}
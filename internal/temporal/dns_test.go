@@ -0,0 +1,158 @@
+package temporal
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/beacon/internal/models"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestCheckDNS(t *testing.T) {
+	nameserver := startDNSServer(t, map[string][]string{
+		"app.beacon.test": {"10.0.0.2", "10.0.0.1"},
+	})
+
+	tests := []struct {
+		name    string
+		host    string
+		check   models.CheckConfig
+		wantErr string // empty if the check should pass
+	}{
+		{
+			name:  "exact match in any order",
+			host:  "app.beacon.test",
+			check: models.CheckConfig{ExpectedValues: []string{"10.0.0.1", "10.0.0.2"}},
+		},
+		{
+			name:    "exact mismatch",
+			host:    "app.beacon.test",
+			check:   models.CheckConfig{ExpectedValues: []string{"10.0.0.1"}},
+			wantErr: "expected answers [10.0.0.1] but got [10.0.0.1 10.0.0.2]",
+		},
+		{
+			name:  "contains",
+			host:  "app.beacon.test",
+			check: models.CheckConfig{DNSMatch: models.DNSMatchContains, ExpectedValues: []string{"10.0.0.2"}},
+		},
+		{
+			name:    "contains missing value",
+			host:    "app.beacon.test",
+			check:   models.CheckConfig{DNSMatch: models.DNSMatchContains, ExpectedValues: []string{"10.0.0.9"}},
+			wantErr: `expected "10.0.0.9" in answers`,
+		},
+		{
+			name:  "baseline unchanged",
+			host:  "app.beacon.test",
+			check: models.CheckConfig{DNSMatch: models.DNSMatchBaseline, DNSBaseline: []string{"10.0.0.2", "10.0.0.1"}},
+		},
+		{
+			name:    "baseline changed",
+			host:    "app.beacon.test",
+			check:   models.CheckConfig{DNSMatch: models.DNSMatchBaseline, DNSBaseline: []string{"10.0.0.1", "10.0.0.3"}},
+			wantErr: "answers changed from baseline",
+		},
+		{
+			name:    "nxdomain",
+			host:    "missing.beacon.test",
+			wantErr: "no such host",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check.RecordType = "A"
+			tt.check.Nameserver = nameserver
+			endpoint := &models.ServiceEndpoint{
+				URL:       "dns://" + tt.host,
+				CheckType: models.CheckDNS,
+				TimeoutMs: 2000,
+				Check:     tt.check,
+			}
+			var result PingResult
+			if err := (&Activities{}).checkDNS(context.Background(), endpoint, &result); err != nil {
+				t.Fatalf("checkDNS: %v", err)
+			}
+			if tt.wantErr == "" {
+				if !result.Success {
+					t.Fatalf("check failed: %s", result.Error)
+				}
+				return
+			}
+			if result.Success {
+				t.Fatalf("check passed, want error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(result.Error, tt.wantErr) {
+				t.Fatalf("error = %q, want it to contain %q", result.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+// startDNSServer answers A queries for records on 127.0.0.1 and returns its
+// address. Other names get NXDOMAIN.
+func startDNSServer(t *testing.T, records map[string][]string) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if reply, err := dnsReply(buf[:n], records); err == nil {
+				conn.WriteTo(reply, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func dnsReply(query []byte, records map[string][]string) ([]byte, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(question.Name.String()), ".")
+	answers, found := records[name]
+	header.Response, header.Authoritative, header.RecursionAvailable = true, true, true
+	if !found {
+		header.RCode = dnsmessage.RCodeNameError
+	}
+
+	builder := dnsmessage.NewBuilder(nil, header)
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := builder.Question(question); err != nil {
+		return nil, err
+	}
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+	if question.Type == dnsmessage.TypeA {
+		for _, answer := range answers {
+			var a dnsmessage.AResource
+			copy(a.A[:], net.ParseIP(answer).To4())
+			rh := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}
+			if err := builder.AResource(rh, a); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return builder.Finish()
+}
@@ -0,0 +1,78 @@
+package temporal
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// checkGRPC calls the standard grpc.health.v1 Health/Check method on the
+// endpoint's host:port. Only SERVING counts as a success.
+func checkGRPC(ctx context.Context, endpoint *models.ServiceEndpoint, tlsConfig *tls.Config, result *PingResult) error {
+	target := strings.TrimPrefix(endpoint.URL, "grpc://")
+
+	var creds credentials.TransportCredentials
+	if endpoint.Check.GRPCPlaintext {
+		creds = insecure.NewCredentials()
+	} else {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid gRPC target: %v", err)
+		return nil
+	}
+	defer conn.Close()
+
+	timeout := time.Duration(endpoint.TimeoutMs) * time.Millisecond
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	md := metadata.MD{}
+	for key, value := range endpoint.Headers {
+		if strValue, ok := value.(string); ok {
+			md.Append(strings.ToLower(key), strValue)
+		}
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	start := time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: endpoint.Check.GRPCService,
+	})
+	result.ResponseMs = int(time.Since(start).Milliseconds())
+
+	if err != nil {
+		st := status.Convert(err)
+		result.Details = models.JSONB{"grpc_code": st.Code().String()}
+		if st.Code() == codes.DeadlineExceeded {
+			result.Error = fmt.Sprintf("health check timed out after %s", timeout)
+		} else {
+			result.Error = fmt.Sprintf("health check failed: %s: %s", st.Code(), st.Message())
+		}
+		return nil
+	}
+
+	serving := resp.GetStatus()
+	result.Details = models.JSONB{"grpc_code": codes.OK.String(), "health_status": serving.String()}
+	result.Success = serving == healthpb.HealthCheckResponse_SERVING
+	if !result.Success {
+		result.Error = fmt.Sprintf("health status %s", serving)
+	}
+	return nil
+}
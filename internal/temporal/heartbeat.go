@@ -0,0 +1,162 @@
+package temporal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/google/uuid"
+	"github.com/robfig/cron"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// HeartbeatSignal is sent to a heartbeat endpoint's workflow with the
+// status of each check-in.
+const HeartbeatSignal = "heartbeat"
+
+// heartbeatIterations bounds a heartbeat workflow's history before it
+// continues as new.
+const heartbeatIterations = 500
+
+// HeartbeatState is when a heartbeat endpoint's next check-in is due.
+type HeartbeatState struct {
+	// Overdue is set when the deadline has passed without a successful
+	// check-in. A failed ping has been recorded.
+	Overdue  bool
+	Deadline time.Time
+	// NextCheck is when the workflow should look again if nothing arrives.
+	NextCheck time.Time
+}
+
+// HeartbeatWorkflow watches a heartbeat endpoint. Jobs check in through the
+// receiver, which signals this workflow; an incident is opened when a job
+// reports failure or no successful check-in arrives before the deadline, and
+// resolved by the next success.
+func HeartbeatWorkflow(ctx workflow.Context, endpointID uuid.UUID) error {
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout: 60 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 3,
+		},
+	}
+	ctx = workflow.WithActivityOptions(ctx, ao)
+	logger := workflow.GetLogger(ctx)
+	checkins := workflow.GetSignalChannel(ctx, HeartbeatSignal)
+
+	checkIncident := func(success bool) {
+		err := workflow.ExecuteActivity(ctx, "CheckIncidentStatus", endpointID, success).Get(ctx, nil)
+		if err != nil {
+			logger.Error("Failed to check incident status", "error", err)
+		}
+	}
+	handle := func(status string) {
+		switch status {
+		case models.HeartbeatSuccess:
+			checkIncident(true)
+		case models.HeartbeatFail:
+			checkIncident(false)
+		}
+	}
+
+	for i := 0; i < heartbeatIterations; i++ {
+		var state HeartbeatState
+		err := workflow.ExecuteActivity(ctx, "CheckHeartbeat", endpointID).Get(ctx, &state)
+		if err != nil {
+			logger.Error("Failed to check heartbeat", "error", err)
+			state.NextCheck = workflow.Now(ctx).Add(time.Minute)
+		} else if state.Overdue {
+			checkIncident(false)
+		}
+
+		wait := state.NextCheck.Sub(workflow.Now(ctx))
+		if wait < time.Second {
+			wait = time.Second
+		}
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(workflow.NewTimer(timerCtx, wait), func(f workflow.Future) {})
+		selector.AddReceive(checkins, func(c workflow.ReceiveChannel, more bool) {
+			var status string
+			c.Receive(ctx, &status)
+			handle(status)
+		})
+		selector.Select(ctx)
+		cancelTimer()
+
+		if err := ctx.Err(); err != nil {
+			logger.Info("Heartbeat workflow cancelled", "endpoint", endpointID)
+			return err
+		}
+	}
+
+	// Don't lose check-ins that arrived while the last iteration ran
+	var status string
+	for checkins.ReceiveAsync(&status) {
+		handle(status)
+	}
+	return workflow.NewContinueAsNewError(ctx, HeartbeatWorkflow, endpointID)
+}
+
+// CheckHeartbeat works out when the endpoint's next successful check-in is
+// due and records a failed ping if it is overdue.
+func (a *Activities) CheckHeartbeat(ctx context.Context, endpointID uuid.UUID) (*HeartbeatState, error) {
+	endpoint, err := a.DB.GetEndpoint(endpointID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get endpoint: %w", err)
+	}
+	heartbeat, err := a.DB.GetHeartbeat(endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	last := heartbeat.CreatedAt
+	if heartbeat.LastSuccessAt != nil {
+		last = *heartbeat.LastSuccessAt
+	}
+	grace := time.Duration(endpoint.Check.GraceSec) * time.Second
+	expected, err := nextCheckin(endpoint, last)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	state := &HeartbeatState{Deadline: expected.Add(grace), NextCheck: expected.Add(grace)}
+	if now.Before(state.Deadline) {
+		return state, nil
+	}
+
+	// Look again once the next check-in is due as well
+	state.Overdue = true
+	next, err := nextCheckin(endpoint, now)
+	if err != nil {
+		return nil, err
+	}
+	state.NextCheck = next.Add(grace)
+
+	message := "no check-in received"
+	if heartbeat.LastSuccessAt != nil {
+		message = fmt.Sprintf("no successful check-in since %s", heartbeat.LastSuccessAt.UTC().Format(time.RFC3339))
+	}
+	_, err = a.recordPing(&PingResult{
+		EndpointID: endpointID,
+		Region:     a.region(),
+		Error:      message,
+		Details:    models.JSONB{"deadline": state.Deadline.UTC().Format(time.RFC3339)},
+	})
+	return state, err
+}
+
+// nextCheckin returns when the check-in after the one at last is expected:
+// the next time matching the endpoint's cron schedule, or one interval later.
+func nextCheckin(endpoint *models.ServiceEndpoint, last time.Time) (time.Time, error) {
+	if endpoint.Check.Schedule == "" {
+		return last.Add(time.Duration(endpoint.IntervalSec) * time.Second), nil
+	}
+	schedule, err := cron.ParseStandard(endpoint.Check.Schedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule %q: %w", endpoint.Check.Schedule, err)
+	}
+	return schedule.Next(last.UTC()), nil
+}
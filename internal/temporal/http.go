@@ -0,0 +1,186 @@
+package temporal
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"golang.org/x/net/http2"
+)
+
+// newHTTPClient builds a client honouring the endpoint's timeout, redirect
+// policy, TLS settings and HTTP version. By default HTTP/2 is used when the
+// server offers it over TLS; "1.1" rules it out and "2" requires it. Each
+// ping gets its own transport so connections aren't shared between
+// endpoints.
+func newHTTPClient(endpoint *models.ServiceEndpoint, tlsConfig *tls.Config) *http.Client {
+	defaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		defaultTransport.TLSClientConfig = tlsConfig
+	}
+	var transport http.RoundTripper = defaultTransport
+	switch endpoint.HTTPVersion {
+	case models.HTTPVersion11:
+		// A non-nil, empty TLSNextProto disables HTTP/2
+		defaultTransport.ForceAttemptHTTP2 = false
+		defaultTransport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	case models.HTTPVersion2:
+		transport = newHTTP2Transport(tlsConfig)
+	}
+
+	return &http.Client{
+		Timeout:   time.Duration(endpoint.TimeoutMs) * time.Millisecond,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !endpoint.FollowRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= endpoint.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", endpoint.MaxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// http2Transport speaks only HTTP/2: negotiated over TLS for https URLs,
+// and h2c with prior knowledge for http ones. A server that doesn't speak
+// it fails the request rather than being answered over HTTP/1.1. Proxy
+// settings from the environment aren't used.
+type http2Transport struct {
+	tls, h2c *http2.Transport
+}
+
+func newHTTP2Transport(tlsConfig *tls.Config) *http2Transport {
+	return &http2Transport{
+		tls: &http2.Transport{TLSClientConfig: tlsConfig},
+		h2c: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+func (t *http2Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		resp, err := t.h2c.RoundTrip(req)
+		if err != nil {
+			return nil, fmt.Errorf("HTTP/2 without TLS (h2c) failed, the server may not support it: %w", err)
+		}
+		return resp, nil
+	}
+	return t.tls.RoundTrip(req)
+}
+
+func (t *http2Transport) CloseIdleConnections() {
+	t.tls.CloseIdleConnections()
+	t.h2c.CloseIdleConnections()
+}
+
+// newHTTPRequest builds the request described by the endpoint: URL with
+// query parameters, body, content type, user agent and custom headers.
+func newHTTPRequest(ctx context.Context, endpoint *models.ServiceEndpoint) (*http.Request, error) {
+	target, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if len(endpoint.QueryParams) > 0 {
+		query := target.Query()
+		for key, value := range endpoint.QueryParams {
+			query.Set(key, fmt.Sprint(value))
+		}
+		target.RawQuery = query.Encode()
+	}
+
+	var body io.Reader
+	if endpoint.Body != "" {
+		body = strings.NewReader(endpoint.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, endpoint.Method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.ContentType != "" {
+		req.Header.Set("Content-Type", endpoint.ContentType)
+	}
+	if endpoint.UserAgent != "" {
+		req.Header.Set("User-Agent", endpoint.UserAgent)
+	}
+
+	// Safely handle headers - they might be nil or empty
+	for key, value := range endpoint.Headers {
+		if strValue, ok := value.(string); ok {
+			req.Header.Set(key, strValue)
+		}
+	}
+
+	return req, nil
+}
+
+// checkHTTP sends the endpoint's request, compares the response status with
+// the expected code and records the timing of each phase.
+func checkHTTP(ctx context.Context, configured, endpoint *models.ServiceEndpoint, tlsConfig *tls.Config, result *PingResult) error {
+	client := newHTTPClient(endpoint, tlsConfig)
+	defer client.CloseIdleConnections()
+
+	req, err := newHTTPRequest(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := applyAuth(ctx, req, endpoint.Auth, endpoint.Body); err != nil {
+		result.Error = fmt.Sprintf("auth failed: %v", err)
+		return nil
+	}
+
+	trace := newRequestTrace()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+	defer func() { result.Timing = trace.result() }()
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.ResponseMs = int(time.Since(start).Milliseconds())
+
+	if err != nil {
+		// Don't leak resolved secrets from the URL into the stored error
+		if urlErr, ok := err.(*url.Error); ok {
+			urlErr.URL = configured.URL
+		}
+		result.Success = false
+		result.Error = err.Error()
+		result.StatusCode = 0
+		return nil
+	}
+	defer resp.Body.Close()
+
+	result.StatusCode = resp.StatusCode
+	result.Success = resp.StatusCode == endpoint.ExpectedCode
+	if !result.Success {
+		result.Error = fmt.Sprintf("Expected status %d but got %d", endpoint.ExpectedCode, resp.StatusCode)
+	}
+
+	// Read the body so the transfer phase can be timed
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil && result.Success {
+		result.Success = false
+		result.Error = fmt.Sprintf("failed to read response body: %v", err)
+	}
+	trace.bodyRead()
+	// A rejected token may have been revoked early; fetch a new one next time
+	if resp.StatusCode == http.StatusUnauthorized && endpoint.Auth != nil && endpoint.Auth.Type == models.AuthOAuth2 {
+		oauth2Tokens.invalidate(endpoint.Auth)
+	}
+	return nil
+}
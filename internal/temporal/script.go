@@ -0,0 +1,313 @@
+package temporal
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/beacon/internal/secrets"
+	starjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// Limits on script checks. Wall-clock time is bounded by the endpoint's
+// timeout.
+const (
+	maxScriptSteps    = 10_000_000 // Starlark execution steps, a proxy for CPU
+	maxScriptRequests = 50
+	maxScriptOutput   = 100 // lines of print() output kept
+)
+
+var scriptFileOptions = &syntax.FileOptions{
+	Set:             true,
+	While:           true,
+	TopLevelControl: true,
+	GlobalReassign:  true,
+}
+
+// scriptRunner holds the state shared by the helpers a script can call.
+type scriptRunner struct {
+	ctx      context.Context
+	client   *http.Client
+	lookup   secrets.Lookup
+	requests int
+	output   []string
+	secrets  []string // values secret() returned, masked in what's saved
+}
+
+// checkScript runs the endpoint's Starlark script and uses its check()
+// result in place of a fixed comparison. Scripts get an http module, json,
+// secret() and the endpoint's URL and name, and nothing else: load() and
+// filesystem access are unavailable.
+func checkScript(ctx context.Context, endpoint *models.ServiceEndpoint, tlsConfig *tls.Config, lookup secrets.Lookup, result *PingResult) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(endpoint.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	client := newHTTPClient(endpoint, tlsConfig)
+	defer client.CloseIdleConnections()
+	runner := &scriptRunner{ctx: ctx, client: client, lookup: lookup}
+
+	thread := &starlark.Thread{
+		Name: "check",
+		Print: func(_ *starlark.Thread, msg string) {
+			if len(runner.output) < maxScriptOutput {
+				runner.output = append(runner.output, msg)
+			}
+		},
+	}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	stop := context.AfterFunc(ctx, func() { thread.Cancel("time limit exceeded") })
+	defer stop()
+
+	start := time.Now()
+	defer func() {
+		result.ResponseMs = int(time.Since(start).Milliseconds())
+		if len(runner.output) > 0 {
+			if result.Details == nil {
+				result.Details = models.JSONB{}
+			}
+			result.Details["output"] = runner.output
+		}
+		runner.redactResult(result)
+	}()
+
+	globals, err := starlark.ExecFileOptions(scriptFileOptions, thread, endpoint.Name+".star", endpoint.Check.Script, runner.predeclared(endpoint))
+	if err != nil {
+		result.Error = fmt.Sprintf("script error: %s", scriptError(err))
+		return nil
+	}
+	check, ok := globals["check"].(starlark.Callable)
+	if !ok {
+		result.Error = "script must define a check() function"
+		return nil
+	}
+	value, err := starlark.Call(thread, check, nil, nil)
+	if err != nil {
+		result.Error = fmt.Sprintf("script error: %s", scriptError(err))
+		return nil
+	}
+
+	return applyScriptResult(value, result)
+}
+
+// applyScriptResult copies check()'s return value onto the result. It may
+// return a bool, or a dict with success, message and metrics keys. The
+// message becomes the ping's error on failure.
+func applyScriptResult(value starlark.Value, result *PingResult) error {
+	switch v := value.(type) {
+	case starlark.Bool:
+		result.Success = bool(v)
+		if !result.Success {
+			result.Error = "check() returned False"
+		}
+		return nil
+	case *starlark.Dict:
+		success, found, _ := v.Get(starlark.String("success"))
+		if !found {
+			result.Error = `check() result has no "success" key`
+			return nil
+		}
+		result.Success = bool(success.Truth())
+
+		result.Details = models.JSONB{}
+		if message, found, _ := v.Get(starlark.String("message")); found {
+			text, ok := starlark.AsString(message)
+			if !ok {
+				text = message.String()
+			}
+			if result.Success {
+				result.Details["message"] = text
+			} else {
+				result.Error = text
+			}
+		}
+		if !result.Success && result.Error == "" {
+			result.Error = "check() reported failure"
+		}
+
+		if raw, found, _ := v.Get(starlark.String("metrics")); found {
+			metricsDict, ok := raw.(*starlark.Dict)
+			if !ok {
+				result.Success = false
+				result.Error = "check() metrics must be a dict"
+				return nil
+			}
+			metrics := make(map[string]float64, metricsDict.Len())
+			for _, item := range metricsDict.Items() {
+				name, ok := starlark.AsString(item[0])
+				number, isNumber := starlark.AsFloat(item[1])
+				if !ok || !isNumber {
+					result.Success = false
+					result.Error = fmt.Sprintf("check() metric %s must map a string to a number", item[0])
+					return nil
+				}
+				metrics[name] = number
+			}
+			result.Details["metrics"] = metrics
+		}
+		return nil
+	default:
+		result.Error = fmt.Sprintf("check() must return a bool or dict, not %s", value.Type())
+		return nil
+	}
+}
+
+func (r *scriptRunner) predeclared(endpoint *models.ServiceEndpoint) starlark.StringDict {
+	return starlark.StringDict{
+		"http": &starlarkstruct.Module{
+			Name: "http",
+			Members: starlark.StringDict{
+				"get":     starlark.NewBuiltin("http.get", r.request(http.MethodGet)),
+				"post":    starlark.NewBuiltin("http.post", r.request(http.MethodPost)),
+				"put":     starlark.NewBuiltin("http.put", r.request(http.MethodPut)),
+				"delete":  starlark.NewBuiltin("http.delete", r.request(http.MethodDelete)),
+				"request": starlark.NewBuiltin("http.request", r.request("")),
+			},
+		},
+		"json":   starjson.Module,
+		"secret": starlark.NewBuiltin("secret", r.secret),
+		"endpoint": starlarkstruct.FromStringDict(starlark.String("endpoint"), starlark.StringDict{
+			"url":  starlark.String(endpoint.URL),
+			"name": starlark.String(endpoint.Name),
+		}),
+	}
+}
+
+type builtinFunc func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error)
+
+// request returns the implementation of an http helper. An empty method
+// means the method is the first argument, as in http.request("PATCH", url).
+func (r *scriptRunner) request(method string) builtinFunc {
+	return func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		method := method
+		var target, body string
+		var headers *starlark.Dict
+		if method == "" {
+			if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "method", &method, "url", &target, "body?", &body, "headers?", &headers); err != nil {
+				return nil, err
+			}
+		} else if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "url", &target, "body?", &body, "headers?", &headers); err != nil {
+			return nil, err
+		}
+
+		r.requests++
+		if r.requests > maxScriptRequests {
+			return nil, fmt.Errorf("%s: more than %d requests", fn.Name(), maxScriptRequests)
+		}
+
+		var reqBody io.Reader
+		if body != "" {
+			reqBody = strings.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(r.ctx, strings.ToUpper(method), target, reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+		if headers != nil {
+			for _, item := range headers.Items() {
+				name, ok1 := starlark.AsString(item[0])
+				value, ok2 := starlark.AsString(item[1])
+				if !ok1 || !ok2 {
+					return nil, fmt.Errorf("%s: headers must map strings to strings", fn.Name())
+				}
+				req.Header.Set(name, value)
+			}
+		}
+
+		start := time.Now()
+		resp, err := r.client.Do(req)
+		if err != nil {
+			// The URL may have been built with a secret
+			if urlErr, ok := err.(*url.Error); ok {
+				err = urlErr.Err
+			}
+			return nil, fmt.Errorf("%s: %w", fn.Name(), err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxStepBody))
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to read response body: %w", fn.Name(), err)
+		}
+		elapsed := time.Since(start).Milliseconds()
+
+		respHeaders := starlark.NewDict(len(resp.Header))
+		for name := range resp.Header {
+			respHeaders.SetKey(starlark.String(strings.ToLower(name)), starlark.String(resp.Header.Get(name)))
+		}
+		bodyValue := starlark.String(data)
+		decode := starjson.Module.Members["decode"]
+
+		return starlarkstruct.FromStringDict(starlark.String("response"), starlark.StringDict{
+			"status":     starlark.MakeInt(resp.StatusCode),
+			"headers":    respHeaders,
+			"body":       bodyValue,
+			"elapsed_ms": starlark.MakeInt64(elapsed),
+			"json": starlark.NewBuiltin("response.json", func(thread *starlark.Thread, _ *starlark.Builtin, _ starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+				return starlark.Call(thread, decode, starlark.Tuple{bodyValue}, nil)
+			}),
+		}), nil
+	}
+}
+
+func (r *scriptRunner) secret(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &name); err != nil {
+		return nil, err
+	}
+	value, err := r.lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("secret: %w", err)
+	}
+	if value != "" {
+		r.secrets = append(r.secrets, value)
+	}
+	return starlark.String(value), nil
+}
+
+// redactResult masks the secrets the script read wherever it could have
+// put them in the ping: its print output, message, metric names and error.
+// Secrets are resolved only inside the worker and mustn't be saved.
+func (r *scriptRunner) redactResult(result *PingResult) {
+	if len(r.secrets) == 0 {
+		return
+	}
+	result.Error = r.redact(result.Error)
+	if output, ok := result.Details["output"].([]string); ok {
+		for i, line := range output {
+			output[i] = r.redact(line)
+		}
+	}
+	if message, ok := result.Details["message"].(string); ok {
+		result.Details["message"] = r.redact(message)
+	}
+	if metrics, ok := result.Details["metrics"].(map[string]float64); ok {
+		redacted := make(map[string]float64, len(metrics))
+		for name, value := range metrics {
+			redacted[r.redact(name)] = value
+		}
+		result.Details["metrics"] = redacted
+	}
+}
+
+func (r *scriptRunner) redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, models.Mask)
+	}
+	return s
+}
+
+// scriptError includes the Starlark backtrace for runtime errors.
+func scriptError(err error) string {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return evalErr.Backtrace()
+	}
+	return err.Error()
+}
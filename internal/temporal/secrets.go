@@ -0,0 +1,138 @@
+package temporal
+
+import (
+	"fmt"
+
+	"github.com/beacon/internal/db"
+	"github.com/beacon/internal/models"
+	"github.com/beacon/internal/secrets"
+	"github.com/google/uuid"
+)
+
+// secretResolver looks up and decrypts secrets for a single activity run,
+// caching values so each secret is read at most once. Secrets are read from
+// the organization that organization returns, which is only called once a
+// secret is referenced.
+type secretResolver struct {
+	activities   *Activities
+	organization func() (uuid.UUID, error)
+	db           *db.DB
+	cache        map[string]string
+}
+
+func (a *Activities) newSecretResolver(organization func() (uuid.UUID, error)) *secretResolver {
+	return &secretResolver{activities: a, organization: organization, cache: make(map[string]string)}
+}
+
+func (r *secretResolver) lookup(name string) (string, error) {
+	if value, ok := r.cache[name]; ok {
+		return value, nil
+	}
+	if r.activities.Secrets == nil {
+		return "", fmt.Errorf("secret %q referenced but %s is not set on the worker", name, secrets.KeyEnv)
+	}
+
+	if r.db == nil {
+		orgID, err := r.organization()
+		if err != nil {
+			return "", err
+		}
+		r.db = r.activities.DB.ForTenant(db.Tenant{OrganizationID: orgID})
+	}
+	secret, err := r.db.GetSecret(name)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := r.activities.Secrets.Open(name, &secrets.Sealed{
+		KeyID:      secret.KeyID,
+		WrappedKey: secret.WrappedKey,
+		Ciphertext: secret.Ciphertext,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	r.cache[name] = string(plaintext)
+	return r.cache[name], nil
+}
+
+func (r *secretResolver) resolve(s string) (string, error) {
+	return secrets.Resolve(s, r.lookup)
+}
+
+// resolveJSONB resolves references in the string values of a map, returning
+// a new map.
+func (r *secretResolver) resolveJSONB(m models.JSONB) (models.JSONB, error) {
+	if m == nil {
+		return nil, nil
+	}
+	resolved := make(models.JSONB, len(m))
+	for key, value := range m {
+		if str, ok := value.(string); ok {
+			var err error
+			if value, err = r.resolve(str); err != nil {
+				return nil, err
+			}
+		}
+		resolved[key] = value
+	}
+	return resolved, nil
+}
+
+// resolveEndpoint returns a copy of the endpoint with secret references in
+// its URL, headers, query parameters, body, check payload, transaction steps
+// and auth config replaced.
+func (r *secretResolver) resolveEndpoint(endpoint *models.ServiceEndpoint) (*models.ServiceEndpoint, error) {
+	resolved := *endpoint
+
+	var err error
+	if resolved.URL, err = r.resolve(endpoint.URL); err != nil {
+		return nil, err
+	}
+	if resolved.Body, err = r.resolve(endpoint.Body); err != nil {
+		return nil, err
+	}
+	if resolved.Headers, err = r.resolveJSONB(endpoint.Headers); err != nil {
+		return nil, err
+	}
+	if resolved.QueryParams, err = r.resolveJSONB(endpoint.QueryParams); err != nil {
+		return nil, err
+	}
+	if resolved.Check.Send, err = r.resolve(endpoint.Check.Send); err != nil {
+		return nil, err
+	}
+	if len(endpoint.Check.Steps) > 0 {
+		resolved.Check.Steps = make([]models.TransactionStep, len(endpoint.Check.Steps))
+		for i, step := range endpoint.Check.Steps {
+			if step.URL, err = r.resolve(step.URL); err != nil {
+				return nil, err
+			}
+			if step.Body, err = r.resolve(step.Body); err != nil {
+				return nil, err
+			}
+			headers := make(map[string]string, len(step.Headers))
+			for key, value := range step.Headers {
+				if headers[key], err = r.resolve(value); err != nil {
+					return nil, err
+				}
+			}
+			step.Headers = headers
+			resolved.Check.Steps[i] = step
+		}
+	}
+
+	if endpoint.Auth != nil {
+		auth := *endpoint.Auth
+		for _, field := range []*string{
+			&auth.Username, &auth.Password, &auth.Token, &auth.KeyValue, &auth.TokenURL,
+			&auth.ClientID, &auth.ClientSecret, &auth.AccessKeyID, &auth.SecretAccessKey, &auth.SessionToken,
+		} {
+			if *field, err = r.resolve(*field); err != nil {
+				return nil, err
+			}
+		}
+		resolved.Auth = &auth
+	}
+
+	return &resolved, nil
+}
@@ -0,0 +1,150 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+)
+
+// maxExpectRead caps how much of a response is read while looking for the
+// expected banner.
+const maxExpectRead = 64 * 1024
+
+// udpReplyWait is how long a UDP check with nothing to expect waits for an
+// ICMP port unreachable before counting the datagram as delivered.
+const udpReplyWait = time.Second
+
+// checkTCP connects to the endpoint's host:port, optionally sends a payload,
+// and reads until the expected response arrives.
+func checkTCP(ctx context.Context, endpoint *models.ServiceEndpoint, result *PingResult) error {
+	return checkSocket(ctx, "tcp", endpoint, result)
+}
+
+// checkUDP sends a datagram to the endpoint's host:port and waits for the
+// expected reply. Without an expectation the check only fails if the port
+// is reported unreachable.
+func checkUDP(ctx context.Context, endpoint *models.ServiceEndpoint, result *PingResult) error {
+	return checkSocket(ctx, "udp", endpoint, result)
+}
+
+func checkSocket(ctx context.Context, network string, endpoint *models.ServiceEndpoint, result *PingResult) error {
+	address := strings.TrimPrefix(endpoint.URL, network+"://")
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		result.Error = fmt.Sprintf("invalid %s address %q: expected host:port", network, endpoint.URL)
+		return nil
+	}
+
+	var expectRegex *regexp.Regexp
+	if endpoint.Check.ExpectRegex != "" {
+		var err error
+		if expectRegex, err = regexp.Compile(endpoint.Check.ExpectRegex); err != nil {
+			result.Error = fmt.Sprintf("invalid expect regex: %v", err)
+			return nil
+		}
+	}
+	expecting := endpoint.Check.Expect != "" || expectRegex != nil
+
+	timeout := time.Duration(endpoint.TimeoutMs) * time.Millisecond
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	start := time.Now()
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		result.ResponseMs = int(time.Since(start).Milliseconds())
+		result.Error = fmt.Sprintf("connect failed: %v", err)
+		return nil
+	}
+	defer conn.Close()
+
+	result.Timing.ConnectMs = sinceMs(start)
+	if host, _, err := net.SplitHostPort(conn.RemoteAddr().String()); err == nil {
+		result.Timing.RemoteIP = host
+	}
+	conn.SetDeadline(deadline)
+
+	if endpoint.Check.Send != "" {
+		if _, err := conn.Write([]byte(endpoint.Check.Send)); err != nil {
+			result.ResponseMs = int(time.Since(start).Milliseconds())
+			result.Error = fmt.Sprintf("send failed: %v", err)
+			return nil
+		}
+	}
+
+	if !expecting {
+		if network == "udp" {
+			// A refused datagram surfaces as an error on the next read
+			wait := time.Now().Add(udpReplyWait)
+			if wait.Before(deadline) {
+				conn.SetReadDeadline(wait)
+			}
+			buf := make([]byte, maxExpectRead)
+			if _, err := conn.Read(buf); err != nil && !isTimeout(err) {
+				result.ResponseMs = int(time.Since(start).Milliseconds())
+				result.Error = fmt.Sprintf("no reply: %v", err)
+				return nil
+			}
+		}
+		result.ResponseMs = int(time.Since(start).Milliseconds())
+		result.Success = true
+		return nil
+	}
+
+	var received []byte
+	buf := make([]byte, 4096)
+	for len(received) < maxExpectRead {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			if result.Timing.TTFBMs == nil {
+				result.Timing.TTFBMs = sinceMs(start)
+			}
+			received = append(received, buf[:n]...)
+			if matchesExpectation(endpoint.Check, expectRegex, received) {
+				result.ResponseMs = int(time.Since(start).Milliseconds())
+				result.Success = true
+				return nil
+			}
+		}
+		if err != nil {
+			result.ResponseMs = int(time.Since(start).Milliseconds())
+			result.Error = fmt.Sprintf("expected response not received: %v (got %q)", err, truncate(received, 200))
+			return nil
+		}
+	}
+
+	result.ResponseMs = int(time.Since(start).Milliseconds())
+	result.Error = fmt.Sprintf("expected response not found in first %d bytes (got %q)", maxExpectRead, truncate(received, 200))
+	return nil
+}
+
+// matchesExpectation reports whether data satisfies both the substring and
+// the regex expectation, whichever are set.
+func matchesExpectation(check models.CheckConfig, expectRegex *regexp.Regexp, data []byte) bool {
+	if check.Expect != "" && !strings.Contains(string(data), check.Expect) {
+		return false
+	}
+	if expectRegex != nil && !expectRegex.Match(data) {
+		return false
+	}
+	return true
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func truncate(data []byte, n int) string {
+	if len(data) > n {
+		return string(data[:n]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,218 @@
+package temporal
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/beacon/internal/models"
+)
+
+func TestCheckTCP(t *testing.T) {
+	banner := startTCPServer(t, func(conn net.Conn) {
+		conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+	})
+	pong := startTCPServer(t, func(conn net.Conn) {
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err == nil && line == "PING\r\n" {
+			conn.Write([]byte("+PONG\r\n"))
+		}
+	})
+
+	tests := []struct {
+		name    string
+		address string
+		check   models.CheckConfig
+		wantErr string // empty if the check should pass
+	}{
+		{
+			name:    "banner",
+			address: banner,
+			check:   models.CheckConfig{Expect: "SSH-2.0"},
+		},
+		{
+			name:    "banner mismatch",
+			address: banner,
+			check:   models.CheckConfig{Expect: "220 "},
+			wantErr: "expected response not received: EOF",
+		},
+		{
+			name:    "send and regex",
+			address: pong,
+			check:   models.CheckConfig{Send: "PING\r\n", ExpectRegex: `^\+PONG\r\n$`},
+		},
+		{
+			name:    "regex mismatch",
+			address: pong,
+			check:   models.CheckConfig{Send: "PING\r\n", ExpectRegex: `^-ERR`},
+			wantErr: `expected response not received: EOF (got "+PONG\r\n")`,
+		},
+		{
+			name:    "connection refused",
+			address: closedTCPAddress(t),
+			wantErr: "connection refused",
+		},
+		{
+			name:    "no port",
+			address: "127.0.0.1",
+			wantErr: "expected host:port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runSocketCheck(t, checkTCP, "tcp://"+tt.address, tt.check)
+			assertSocketResult(t, result, tt.wantErr)
+		})
+	}
+}
+
+func TestCheckUDP(t *testing.T) {
+	echo := startUDPServer(t, true)
+	silent := startUDPServer(t, false)
+
+	tests := []struct {
+		name    string
+		address string
+		check   models.CheckConfig
+		wantErr string // empty if the check should pass
+	}{
+		{
+			name:    "reply",
+			address: echo,
+			check:   models.CheckConfig{Send: "ping", Expect: "ping"},
+		},
+		{
+			name:    "reply mismatch",
+			address: echo,
+			check:   models.CheckConfig{Send: "ping", Expect: "pong"},
+			wantErr: "i/o timeout",
+		},
+		{
+			name:    "no reply",
+			address: silent,
+			check:   models.CheckConfig{Send: "ping", Expect: "pong"},
+			wantErr: "expected response not received",
+		},
+		{
+			name:    "delivered without expectation",
+			address: silent,
+			check:   models.CheckConfig{Send: "ping"},
+		},
+		{
+			name:    "port unreachable",
+			address: closedUDPAddress(t),
+			check:   models.CheckConfig{Send: "ping"},
+			wantErr: "connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runSocketCheck(t, checkUDP, "udp://"+tt.address, tt.check)
+			assertSocketResult(t, result, tt.wantErr)
+		})
+	}
+}
+
+func runSocketCheck(t *testing.T, check func(context.Context, *models.ServiceEndpoint, *PingResult) error, url string, config models.CheckConfig) PingResult {
+	t.Helper()
+	endpoint := &models.ServiceEndpoint{URL: url, TimeoutMs: 300, Check: config}
+	var result PingResult
+	if err := check(context.Background(), endpoint, &result); err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	return result
+}
+
+func assertSocketResult(t *testing.T, result PingResult, wantErr string) {
+	t.Helper()
+	if wantErr == "" {
+		if !result.Success {
+			t.Fatalf("check failed: %s", result.Error)
+		}
+		return
+	}
+	if result.Success {
+		t.Fatalf("check passed, want error containing %q", wantErr)
+	}
+	if !strings.Contains(result.Error, wantErr) {
+		t.Fatalf("error = %q, want it to contain %q", result.Error, wantErr)
+	}
+}
+
+// startTCPServer listens on 127.0.0.1 and runs handle for each connection,
+// closing it afterwards. It returns the listener's address.
+func startTCPServer(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// startUDPServer listens on 127.0.0.1, echoing each datagram back if echo
+// is set and ignoring it otherwise. It returns the listener's address.
+func startUDPServer(t *testing.T, echo bool) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if echo {
+				conn.WriteTo(buf[:n], addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// closedTCPAddress returns an address nothing is listening on.
+func closedTCPAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+// closedUDPAddress returns a UDP address nothing is listening on.
+func closedUDPAddress(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	address := conn.LocalAddr().String()
+	conn.Close()
+	return address
+}
@@ -0,0 +1,270 @@
+package temporal
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/models"
+	"github.com/gorilla/websocket"
+)
+
+// checkWebSocket opens a WebSocket connection, sends the configured message
+// if there is one, and waits for a message matching the expectation (any
+// message if none is set). A failed upgrade, a close before a match, or the
+// timeout passing fails the ping.
+func checkWebSocket(ctx context.Context, configured, endpoint *models.ServiceEndpoint, tlsConfig *tls.Config, result *PingResult) error {
+	check := endpoint.Check
+	expectRegex, err := compileExpectRegex(check)
+	if err != nil {
+		result.Error = err.Error()
+		return nil
+	}
+
+	timeout := time.Duration(endpoint.TimeoutMs) * time.Millisecond
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Build the handshake like an HTTP request so headers, query
+	// parameters and auth apply as usual
+	req, err := newHTTPRequest(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := applyAuth(ctx, req, endpoint.Auth, ""); err != nil {
+		result.Error = fmt.Sprintf("auth failed: %v", err)
+		return nil
+	}
+
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		TLSClientConfig:  tlsConfig,
+		HandshakeTimeout: timeout,
+	}
+
+	trace := newRequestTrace()
+	var handshakeMs, firstMessageMs *int
+	defer func() {
+		result.Timing = trace.result()
+		result.Timing.HandshakeMs = handshakeMs
+		result.Timing.FirstMessageMs = firstMessageMs
+	}()
+
+	start := time.Now()
+	conn, resp, err := dialer.DialContext(httptrace.WithClientTrace(ctx, trace.clientTrace()), req.URL.String(), req.Header)
+	if resp != nil {
+		result.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		result.ResponseMs = int(time.Since(start).Milliseconds())
+		if resp != nil {
+			result.Error = fmt.Sprintf("upgrade failed: expected status 101 but got %d", resp.StatusCode)
+		} else {
+			result.Error = fmt.Sprintf("connect failed: %v", redactURLError(err, configured.URL))
+		}
+		return nil
+	}
+	defer conn.Close()
+	handshakeMs = sinceMs(start)
+	connected := time.Now()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+		conn.SetWriteDeadline(deadline)
+	}
+	conn.SetReadLimit(maxStepBody)
+
+	if check.Send != "" {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(check.Send)); err != nil {
+			result.ResponseMs = int(time.Since(start).Milliseconds())
+			result.Error = fmt.Sprintf("failed to send message: %v", err)
+			return nil
+		}
+	}
+
+	received := 0
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			result.ResponseMs = int(time.Since(start).Milliseconds())
+			result.Details = models.JSONB{"messages": received}
+			var closeErr *websocket.CloseError
+			switch {
+			case isTimeout(err):
+				result.Error = fmt.Sprintf("no matching message within %s", timeout)
+			case errors.As(err, &closeErr):
+				result.Error = fmt.Sprintf("connection closed before a matching message: %v", closeErr)
+			default:
+				result.Error = fmt.Sprintf("connection lost before a matching message: %v", err)
+			}
+			return nil
+		}
+
+		received++
+		if firstMessageMs == nil {
+			firstMessageMs = sinceMs(connected)
+		}
+		if matchesExpectation(check, expectRegex, data) {
+			result.ResponseMs = int(time.Since(start).Milliseconds())
+			result.Details = models.JSONB{"messages": received, "message": truncate(data, 1024)}
+			result.Success = true
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			return nil
+		}
+	}
+}
+
+// checkSSE sends the endpoint's request, expecting a text/event-stream
+// response, and waits for an event of the configured type whose data
+// matches the expectation (any event if neither is set). The stream ending
+// before a match, or the timeout passing, fails the ping.
+func checkSSE(ctx context.Context, configured, endpoint *models.ServiceEndpoint, tlsConfig *tls.Config, result *PingResult) error {
+	check := endpoint.Check
+	expectRegex, err := compileExpectRegex(check)
+	if err != nil {
+		result.Error = err.Error()
+		return nil
+	}
+
+	client := newHTTPClient(endpoint, tlsConfig)
+	defer client.CloseIdleConnections()
+
+	req, err := newHTTPRequest(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	req.Header.Set("Cache-Control", "no-cache")
+	if err := applyAuth(ctx, req, endpoint.Auth, endpoint.Body); err != nil {
+		result.Error = fmt.Sprintf("auth failed: %v", err)
+		return nil
+	}
+
+	trace := newRequestTrace()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+	var handshakeMs, firstMessageMs *int
+	defer func() {
+		result.Timing = trace.result()
+		result.Timing.HandshakeMs = handshakeMs
+		result.Timing.FirstMessageMs = firstMessageMs
+	}()
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.ResponseMs = int(time.Since(start).Milliseconds())
+		result.Error = redactURLError(err, configured.URL).Error()
+		return nil
+	}
+	defer resp.Body.Close()
+	handshakeMs = sinceMs(start)
+	connected := time.Now()
+
+	result.StatusCode = resp.StatusCode
+	if resp.StatusCode != endpoint.ExpectedCode {
+		result.ResponseMs = int(time.Since(start).Milliseconds())
+		result.Error = fmt.Sprintf("Expected status %d but got %d", endpoint.ExpectedCode, resp.StatusCode)
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		result.ResponseMs = int(time.Since(start).Milliseconds())
+		result.Error = fmt.Sprintf("expected an event stream but got content type %q", resp.Header.Get("Content-Type"))
+		return nil
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxStepBody)
+	received := 0
+	var eventType string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			if strings.HasPrefix(line, ":") {
+				continue // comment or keep-alive
+			}
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				eventType = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+
+		// A blank line dispatches the event, if it carried any data
+		if data == nil {
+			eventType = ""
+			continue
+		}
+		event := sseEvent{Type: eventType, Data: strings.Join(data, "\n")}
+		if event.Type == "" {
+			event.Type = "message"
+		}
+		eventType, data = "", nil
+
+		received++
+		if firstMessageMs == nil {
+			firstMessageMs = sinceMs(connected)
+		}
+		if (check.SSEEvent == "" || event.Type == check.SSEEvent) && matchesExpectation(check, expectRegex, []byte(event.Data)) {
+			result.ResponseMs = int(time.Since(start).Milliseconds())
+			result.Details = models.JSONB{"events": received, "event": event.Type, "data": truncate([]byte(event.Data), 1024)}
+			result.Success = true
+			return nil
+		}
+	}
+
+	result.ResponseMs = int(time.Since(start).Milliseconds())
+	result.Details = models.JSONB{"events": received}
+	switch err := scanner.Err(); {
+	case err == nil:
+		result.Error = "stream closed before a matching event"
+	case isTimeout(err):
+		result.Error = fmt.Sprintf("no matching event within %s", time.Duration(endpoint.TimeoutMs)*time.Millisecond)
+	default:
+		result.Error = fmt.Sprintf("stream lost before a matching event: %v", err)
+	}
+	return nil
+}
+
+// sseEvent is one dispatched server-sent event.
+type sseEvent struct {
+	Type string
+	Data string
+}
+
+func compileExpectRegex(check models.CheckConfig) (*regexp.Regexp, error) {
+	if check.ExpectRegex == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(check.ExpectRegex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expect regex: %v", err)
+	}
+	return re, nil
+}
+
+// redactURLError replaces the URL in a *url.Error with the configured one,
+// which may hold secret references rather than their values. Checks that
+// send requests are given the endpoint as stored (configured) alongside the
+// resolved one for this, so resolved secrets stay out of ping errors.
+func redactURLError(err error, configuredURL string) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		urlErr.URL = configuredURL
+	}
+	return err
+}
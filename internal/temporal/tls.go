@@ -0,0 +1,71 @@
+package temporal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/beacon/internal/models"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsConfig builds the client TLS configuration for an endpoint, loading
+// client certificates and CA bundles from the secrets store. It returns nil
+// when the endpoint has no TLS settings.
+func (r *secretResolver) tlsConfig(cfg *models.TLSConfig) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.MinVersion != "" {
+		version, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %q", cfg.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if cfg.ClientCertSecret != "" || cfg.ClientKeySecret != "" {
+		if cfg.ClientCertSecret == "" || cfg.ClientKeySecret == "" {
+			return nil, fmt.Errorf("client certificate and key must both be set")
+		}
+		certPEM, err := r.lookup(cfg.ClientCertSecret)
+		if err != nil {
+			return nil, err
+		}
+		keyPEM, err := r.lookup(cfg.ClientKeySecret)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CASecret != "" {
+		caPEM, err := r.lookup(cfg.CASecret)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+			return nil, fmt.Errorf("CA bundle in secret %q contains no certificates", cfg.CASecret)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
@@ -0,0 +1,110 @@
+package temporal
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/beacon/internal/models"
+)
+
+// requestTrace records how long each phase of a request took. When redirects
+// are followed each hop overwrites the last, so the timings describe the
+// final request.
+type requestTrace struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	firstByte    time.Time
+
+	timing models.PingTiming
+}
+
+func newRequestTrace() *requestTrace {
+	return &requestTrace{start: time.Now()}
+}
+
+func (t *requestTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.start = time.Now()
+			t.timing = models.PingTiming{}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.DNSMs = sinceMs(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// Dual-stack dialing may start several connects; time the first
+			if t.timing.ConnectMs == nil && t.connectStart.Before(t.start) {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil && t.timing.ConnectMs == nil {
+				t.timing.ConnectMs = sinceMs(t.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.TLSMs = sinceMs(t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timing.ConnReused = info.Reused
+			if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				t.timing.RemoteIP = host
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.firstByte = time.Now()
+			t.timing.TTFBMs = sinceMs(t.start)
+		},
+	}
+}
+
+// bodyRead records the end of the transfer phase.
+func (t *requestTrace) bodyRead() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.firstByte.IsZero() {
+		t.timing.TransferMs = sinceMs(t.firstByte)
+	}
+}
+
+func (t *requestTrace) result() models.PingTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timing
+}
+
+func sinceMs(start time.Time) *int {
+	ms := int(time.Since(start).Milliseconds())
+	return &ms
+}
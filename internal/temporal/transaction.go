@@ -0,0 +1,289 @@
+package temporal
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beacon/internal/jsonpath"
+	"github.com/beacon/internal/models"
+)
+
+// maxStepBody caps how much of each step's response is read for
+// extractions and assertions.
+const maxStepBody = 10 << 20
+
+// varPattern matches {{var "name"}}, allowing whitespace inside the braces.
+var varPattern = regexp.MustCompile(`\{\{\s*var\s+"([^"]+)"\s*\}\}`)
+
+// stepResult is the outcome of one transaction step, stored in the ping's
+// details.
+type stepResult struct {
+	Name       string `json:"name"`
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMs int    `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// checkTransaction runs the endpoint's steps in order, stopping at the
+// first failure.
+func checkTransaction(ctx context.Context, configured, endpoint *models.ServiceEndpoint, tlsConfig *tls.Config, result *PingResult) error {
+	steps := endpoint.Check.Steps
+	if len(steps) == 0 {
+		result.Error = "transaction has no steps"
+		return nil
+	}
+
+	client := newHTTPClient(endpoint, tlsConfig)
+	defer client.CloseIdleConnections()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return fmt.Errorf("failed to create cookie jar: %w", err)
+	}
+	client.Jar = jar
+
+	vars := make(map[string]string)
+	results := make([]stepResult, 0, len(steps))
+	start := time.Now()
+
+	for i, step := range steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+		stepStart := time.Now()
+		status, err := runStep(ctx, client, endpoint, step, vars)
+		results = append(results, stepResult{
+			Name:       name,
+			StatusCode: status,
+			DurationMs: int(time.Since(stepStart).Milliseconds()),
+		})
+		result.StatusCode = status
+
+		if err != nil {
+			if urlErr, ok := err.(*url.Error); ok {
+				urlErr.URL = configured.Check.Steps[i].URL
+			}
+			results[i].Error = err.Error()
+			result.Error = fmt.Sprintf("step %d (%s) failed: %v", i+1, name, err)
+			result.Details = models.JSONB{"steps": results, "failed_step": name}
+			result.ResponseMs = int(time.Since(start).Milliseconds())
+			return nil
+		}
+	}
+
+	result.ResponseMs = int(time.Since(start).Milliseconds())
+	result.Details = models.JSONB{"steps": results}
+	result.Success = true
+	return nil
+}
+
+// runStep sends one step's request, checks its status and assertions, and
+// saves its extractions into vars. It returns the response status code.
+func runStep(ctx context.Context, client *http.Client, endpoint *models.ServiceEndpoint, step models.TransactionStep, vars map[string]string) (int, error) {
+	target, err := substituteVars(step.URL, vars)
+	if err != nil {
+		return 0, err
+	}
+	body, err := substituteVars(step.Body, vars)
+	if err != nil {
+		return 0, err
+	}
+
+	method := step.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+	if endpoint.UserAgent != "" {
+		req.Header.Set("User-Agent", endpoint.UserAgent)
+	}
+	for key, value := range step.Headers {
+		if value, err = substituteVars(value, vars); err != nil {
+			return 0, err
+		}
+		req.Header.Set(key, value)
+	}
+	if err := applyAuth(ctx, req, endpoint.Auth, body); err != nil {
+		return 0, fmt.Errorf("auth failed: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxStepBody))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
+	}
+	response := &stepResponse{status: resp.StatusCode, header: resp.Header, body: data}
+
+	if step.ExpectedCode != 0 && resp.StatusCode != step.ExpectedCode {
+		return resp.StatusCode, fmt.Errorf("expected status %d but got %d", step.ExpectedCode, resp.StatusCode)
+	}
+	if step.ExpectedCode == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return resp.StatusCode, fmt.Errorf("expected a 2xx status but got %d", resp.StatusCode)
+	}
+
+	for _, assertion := range step.Assert {
+		if err := response.assert(assertion); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+
+	for _, extraction := range step.Extract {
+		value, ok, err := response.value(extraction.Source, extraction.Expression)
+		if err != nil {
+			return resp.StatusCode, fmt.Errorf("extracting %s: %w", extraction.Var, err)
+		}
+		if !ok {
+			return resp.StatusCode, fmt.Errorf("extracting %s: %s %q not found in response", extraction.Var, extraction.Source, extraction.Expression)
+		}
+		vars[extraction.Var] = value
+	}
+
+	return resp.StatusCode, nil
+}
+
+// substituteVars replaces {{var "name"}} references with extracted values.
+func substituteVars(s string, vars map[string]string) (string, error) {
+	var missing string
+	substituted := varPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := varPattern.FindStringSubmatch(ref)[1]
+		value, ok := vars[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("variable %q is not set by an earlier step", missing)
+	}
+	return substituted, nil
+}
+
+// stepResponse is a step's response, with the body decoded as JSON on
+// first use.
+type stepResponse struct {
+	status int
+	header http.Header
+	body   []byte
+
+	decoded bool
+	json    interface{}
+	jsonErr error
+}
+
+// value returns the part of the response named by source and expression.
+// ok is false if it isn't present.
+func (r *stepResponse) value(source, expression string) (value string, ok bool, err error) {
+	switch source {
+	case models.SourceStatus:
+		return strconv.Itoa(r.status), true, nil
+	case models.SourceBody:
+		return string(r.body), true, nil
+	case models.SourceHeader:
+		values := r.header.Values(expression)
+		if len(values) == 0 {
+			return "", false, nil
+		}
+		return values[0], true, nil
+	case models.SourceRegex:
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid regex: %w", err)
+		}
+		match := re.FindSubmatch(r.body)
+		if match == nil {
+			return "", false, nil
+		}
+		if len(match) > 1 {
+			return string(match[1]), true, nil
+		}
+		return string(match[0]), true, nil
+	case models.SourceJSON:
+		if !r.decoded {
+			r.decoded = true
+			r.jsonErr = json.Unmarshal(r.body, &r.json)
+		}
+		if r.jsonErr != nil {
+			return "", false, fmt.Errorf("response is not JSON: %w", r.jsonErr)
+		}
+		found, ok, err := jsonpath.Lookup(r.json, expression)
+		if err != nil || !ok {
+			return "", false, err
+		}
+		return jsonpath.Format(found), true, nil
+	default:
+		return "", false, fmt.Errorf("unknown source %q", source)
+	}
+}
+
+// assert checks one assertion against the response.
+func (r *stepResponse) assert(a models.Assertion) error {
+	actual, ok, err := r.value(a.Source, a.Expression)
+	if err != nil {
+		return fmt.Errorf("assertion on %s: %w", a.Source, err)
+	}
+
+	subject := a.Source
+	if a.Expression != "" {
+		subject = fmt.Sprintf("%s %q", a.Source, a.Expression)
+	}
+	if a.Operator == "exists" {
+		if !ok {
+			return fmt.Errorf("expected %s to exist", subject)
+		}
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("%s not found in response", subject)
+	}
+
+	var pass bool
+	switch a.Operator {
+	case "equals":
+		pass = actual == a.Value
+	case "not_equals":
+		pass = actual != a.Value
+	case "contains":
+		pass = strings.Contains(actual, a.Value)
+	case "matches":
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", a.Value, err)
+		}
+		pass = re.MatchString(actual)
+	case "less_than", "greater_than":
+		got, err1 := strconv.ParseFloat(actual, 64)
+		want, err2 := strconv.ParseFloat(a.Value, 64)
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("%s: cannot compare %q and %q as numbers", subject, actual, a.Value)
+		}
+		pass = (a.Operator == "less_than" && got < want) || (a.Operator == "greater_than" && got > want)
+	default:
+		return fmt.Errorf("unknown assertion operator %q", a.Operator)
+	}
+	if !pass {
+		return fmt.Errorf("expected %s %s %q but got %q", subject, strings.ReplaceAll(a.Operator, "_", " "), a.Value, truncate([]byte(actual), 200))
+	}
+	return nil
+}
@@ -8,6 +8,16 @@ import (
 	"go.temporal.io/sdk/workflow"
 )
 
+// monitorConfigVersion gates loading an endpoint's interval, regions and
+// quorum on every iteration. Workflows started before it ping from the
+// worker's own region at the interval they were started with.
+const monitorConfigVersion = "load-monitor-config"
+
+// MonitorEndpointWorkflow pings an endpoint every interval. The interval,
+// regions and quorum are read from the endpoint before each ping, so changes
+// apply without restarting the monitor; intervalSec is only used until they
+// have been read. When regions are set the ping runs once per region and the
+// endpoint is considered down once at least quorum regions report a failure.
 func MonitorEndpointWorkflow(ctx workflow.Context, endpointID uuid.UUID, intervalSec int) error {
 	ao := workflow.ActivityOptions{
 		StartToCloseTimeout: 60 * time.Second,
@@ -17,20 +27,30 @@ func MonitorEndpointWorkflow(ctx workflow.Context, endpointID uuid.UUID, interva
 	}
 	ctx = workflow.WithActivityOptions(ctx, ao)
 
+	config := MonitorConfig{IntervalSec: intervalSec}
+	version := workflow.GetVersion(ctx, monitorConfigVersion, workflow.DefaultVersion, 1)
 	for {
-		var result PingResult
-		err := workflow.ExecuteActivity(ctx, "PingEndpoint", endpointID).Get(ctx, &result)
-		if err != nil {
-			workflow.GetLogger(ctx).Error("Failed to ping endpoint", "error", err)
-		} else {
-			err = workflow.ExecuteActivity(ctx, "CheckIncidentStatus", endpointID, result.Success).Get(ctx, nil)
+		if version >= 1 {
+			// Keep the last config if the endpoint can't be read
+			var latest MonitorConfig
+			err := workflow.ExecuteActivity(ctx, "GetMonitorConfig", endpointID).Get(ctx, &latest)
+			if err != nil {
+				workflow.GetLogger(ctx).Error("Failed to get monitor config", "error", err)
+			} else {
+				config = latest
+			}
+		}
+
+		success, ok := pingRegions(ctx, endpointID, config.Regions, config.Quorum)
+		if ok {
+			err := workflow.ExecuteActivity(ctx, "CheckIncidentStatus", endpointID, success).Get(ctx, nil)
 			if err != nil {
 				workflow.GetLogger(ctx).Error("Failed to check incident status", "error", err)
 			}
 		}
 		
 		// Use workflow.Sleep instead of time.Sleep to properly handle cancellation
-		err = workflow.Sleep(ctx, time.Duration(intervalSec)*time.Second)
+		err := workflow.Sleep(ctx, time.Duration(config.IntervalSec)*time.Second)
 		if err != nil {
 			// Workflow was cancelled
 			workflow.GetLogger(ctx).Info("Monitoring workflow cancelled", "endpoint", endpointID)
@@ -39,6 +59,48 @@ func MonitorEndpointWorkflow(ctx workflow.Context, endpointID uuid.UUID, interva
 	}
 }
 
+// pingRegions runs PingEndpoint in every region in parallel and applies the
+// quorum rule to the results. ok is false when no region reported back.
+func pingRegions(ctx workflow.Context, endpointID uuid.UUID, regions []string, quorum int) (success bool, ok bool) {
+	if len(regions) == 0 {
+		var result PingResult
+		err := workflow.ExecuteActivity(ctx, "PingEndpoint", endpointID).Get(ctx, &result)
+		if err != nil {
+			workflow.GetLogger(ctx).Error("Failed to ping endpoint", "error", err)
+			return false, false
+		}
+		return result.Success, true
+	}
+
+	futures := make([]workflow.Future, len(regions))
+	for i, region := range regions {
+		// Don't wait forever on a region that has no workers polling it.
+		rctx := workflow.WithScheduleToStartTimeout(workflow.WithTaskQueue(ctx, RegionTaskQueue(region)), 60*time.Second)
+		futures[i] = workflow.ExecuteActivity(rctx, "PingEndpoint", endpointID)
+	}
+
+	var reported, failed int
+	for i, future := range futures {
+		var result PingResult
+		if err := future.Get(ctx, &result); err != nil {
+			workflow.GetLogger(ctx).Error("Failed to ping endpoint", "region", regions[i], "error", err)
+			continue
+		}
+		reported++
+		if !result.Success {
+			failed++
+		}
+	}
+	if reported == 0 {
+		return false, false
+	}
+
+	if quorum < 1 {
+		quorum = 1
+	}
+	return failed < quorum, true
+}
+
 func AggregateMetricsWorkflow(ctx workflow.Context) error {
 	ao := workflow.ActivityOptions{
 		StartToCloseTimeout: 5 * time.Minute,
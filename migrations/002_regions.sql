@@ -0,0 +1,9 @@
+-- Multi-region probing
+ALTER TABLE service_endpoints ADD COLUMN regions TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE service_endpoints ADD COLUMN quorum INT NOT NULL DEFAULT 1;
+
+ALTER TABLE pings ADD COLUMN region VARCHAR(64) NOT NULL DEFAULT 'default';
+CREATE INDEX idx_pings_region ON pings(region);
+
+ALTER TABLE ping_windows ADD COLUMN region VARCHAR(64) NOT NULL DEFAULT 'default';
+CREATE INDEX idx_ping_windows_region ON ping_windows(region);
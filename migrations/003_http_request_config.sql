@@ -0,0 +1,8 @@
+-- Request bodies and richer HTTP request configuration
+ALTER TABLE service_endpoints ADD COLUMN body TEXT NOT NULL DEFAULT '';
+ALTER TABLE service_endpoints ADD COLUMN content_type VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE service_endpoints ADD COLUMN query_params JSONB;
+ALTER TABLE service_endpoints ADD COLUMN follow_redirects BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE service_endpoints ADD COLUMN max_redirects INT NOT NULL DEFAULT 10;
+ALTER TABLE service_endpoints ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE service_endpoints ADD COLUMN http_version VARCHAR(8) NOT NULL DEFAULT '';
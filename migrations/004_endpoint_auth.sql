@@ -0,0 +1,2 @@
+-- Authentication modes for monitored endpoints
+ALTER TABLE service_endpoints ADD COLUMN auth JSONB;
@@ -0,0 +1,2 @@
+-- Mutual TLS and custom CA support for HTTP checks
+ALTER TABLE service_endpoints ADD COLUMN tls_config JSONB;
@@ -0,0 +1,24 @@
+-- Check types and TLS certificate monitoring
+ALTER TABLE service_endpoints ADD COLUMN check_type VARCHAR(20) NOT NULL DEFAULT 'http';
+ALTER TABLE service_endpoints ADD COLUMN check_config JSONB;
+
+CREATE TABLE certificates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    endpoint_id UUID NOT NULL UNIQUE REFERENCES service_endpoints(id) ON DELETE CASCADE,
+    host VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    issuer TEXT NOT NULL,
+    sans TEXT[] NOT NULL DEFAULT '{}',
+    serial_number VARCHAR(128) NOT NULL,
+    not_before TIMESTAMP NOT NULL,
+    not_after TIMESTAMP NOT NULL,
+    chain_valid BOOLEAN NOT NULL,
+    chain_error TEXT NOT NULL DEFAULT '',
+    ocsp_status VARCHAR(20) NOT NULL DEFAULT '',
+    notified_days INT,
+    checked_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_certificates_not_after ON certificates(not_after);
@@ -0,0 +1,15 @@
+-- Request timing breakdown. Phases that didn't happen (e.g. DNS on a reused
+-- connection, TLS on plain HTTP) are NULL.
+ALTER TABLE pings ADD COLUMN dns_ms INT;
+ALTER TABLE pings ADD COLUMN connect_ms INT;
+ALTER TABLE pings ADD COLUMN tls_ms INT;
+ALTER TABLE pings ADD COLUMN ttfb_ms INT;
+ALTER TABLE pings ADD COLUMN transfer_ms INT;
+ALTER TABLE pings ADD COLUMN remote_ip VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE pings ADD COLUMN conn_reused BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE ping_windows ADD COLUMN avg_dns_ms INT;
+ALTER TABLE ping_windows ADD COLUMN avg_connect_ms INT;
+ALTER TABLE ping_windows ADD COLUMN avg_tls_ms INT;
+ALTER TABLE ping_windows ADD COLUMN avg_ttfb_ms INT;
+ALTER TABLE ping_windows ADD COLUMN avg_transfer_ms INT;
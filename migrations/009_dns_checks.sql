@@ -0,0 +1,2 @@
+-- Check-specific ping results, such as the answers returned by DNS checks
+ALTER TABLE pings ADD COLUMN details JSONB;
@@ -0,0 +1,3 @@
+-- Query time for database and cache checks
+ALTER TABLE pings ADD COLUMN query_ms INT;
+ALTER TABLE ping_windows ADD COLUMN avg_query_ms INT;
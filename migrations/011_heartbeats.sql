@@ -0,0 +1,11 @@
+-- Heartbeat (push) monitors: jobs check in at a unique URL
+CREATE TABLE heartbeats (
+    endpoint_id UUID PRIMARY KEY REFERENCES service_endpoints(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    last_status VARCHAR(20) NOT NULL DEFAULT '',
+    last_start_at TIMESTAMP,
+    last_success_at TIMESTAMP,
+    last_checkin_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
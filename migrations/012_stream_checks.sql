@@ -0,0 +1,5 @@
+-- Handshake and first-message times for websocket and sse checks
+ALTER TABLE pings ADD COLUMN handshake_ms INT;
+ALTER TABLE pings ADD COLUMN first_message_ms INT;
+ALTER TABLE ping_windows ADD COLUMN avg_handshake_ms INT;
+ALTER TABLE ping_windows ADD COLUMN avg_first_message_ms INT;
@@ -0,0 +1,44 @@
+-- Organizations are tenants; projects group their services
+CREATE TABLE organizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    slug VARCHAR(63) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    max_endpoints INTEGER NOT NULL DEFAULT 0,
+    min_interval_sec INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_organizations_slug ON organizations(slug) WHERE deleted_at IS NULL;
+
+CREATE TABLE projects (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    slug VARCHAR(63) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_projects_slug ON projects(organization_id, slug) WHERE deleted_at IS NULL;
+
+-- Everything that exists already moves to default/default
+INSERT INTO organizations (slug, name) VALUES ('default', 'Default');
+INSERT INTO projects (organization_id, slug, name)
+SELECT id, 'default', 'Default' FROM organizations WHERE slug = 'default';
+
+ALTER TABLE services ADD COLUMN project_id UUID REFERENCES projects(id);
+UPDATE services SET project_id = (SELECT id FROM projects WHERE slug = 'default');
+ALTER TABLE services ALTER COLUMN project_id SET NOT NULL;
+CREATE INDEX idx_services_project_id ON services(project_id);
+
+-- Users without an organization are operators across all of them
+ALTER TABLE users ADD COLUMN organization_id UUID REFERENCES organizations(id);
+
+ALTER TABLE secrets ADD COLUMN organization_id UUID REFERENCES organizations(id);
+UPDATE secrets SET organization_id = (SELECT id FROM organizations WHERE slug = 'default');
+ALTER TABLE secrets ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE secrets DROP CONSTRAINT secrets_name_key;
+ALTER TABLE secrets ADD CONSTRAINT secrets_organization_id_name_key UNIQUE (organization_id, name);
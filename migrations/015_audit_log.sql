@@ -0,0 +1,16 @@
+-- Who changed which configuration, and how
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID REFERENCES organizations(id),
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id UUID NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_organization_created ON audit_log(organization_id, created_at DESC);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_id, created_at DESC);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor, created_at DESC);
@@ -0,0 +1,34 @@
+-- Slugs name services within their project and endpoints within their
+-- service, so they can be referred to as <service>/<endpoint>
+ALTER TABLE services ADD COLUMN slug VARCHAR(63);
+ALTER TABLE service_endpoints ADD COLUMN slug VARCHAR(63);
+
+-- Existing rows take their slugs from their names. Where names collide,
+-- all but the oldest get part of their ID appended.
+UPDATE services s SET slug = CASE WHEN n = 1 THEN base ELSE base || '-' || left(s.id::text, 8) END
+FROM (
+    SELECT id, base, ROW_NUMBER() OVER (PARTITION BY project_id, base ORDER BY created_at, id) AS n
+    FROM (
+        SELECT id, project_id, created_at,
+               COALESCE(NULLIF(trim(both '-' from left(regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g'), 54)), ''), 'service') AS base
+        FROM services
+    ) named
+) numbered
+WHERE s.id = numbered.id;
+
+UPDATE service_endpoints e SET slug = CASE WHEN n = 1 THEN base ELSE base || '-' || left(e.id::text, 8) END
+FROM (
+    SELECT id, base, ROW_NUMBER() OVER (PARTITION BY service_id, base ORDER BY created_at, id) AS n
+    FROM (
+        SELECT id, service_id, created_at,
+               COALESCE(NULLIF(trim(both '-' from left(regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g'), 54)), ''), 'endpoint') AS base
+        FROM service_endpoints
+    ) named
+) numbered
+WHERE e.id = numbered.id;
+
+ALTER TABLE services ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE service_endpoints ALTER COLUMN slug SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_services_slug ON services(project_id, slug) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_service_endpoints_slug ON service_endpoints(service_id, slug) WHERE deleted_at IS NULL;
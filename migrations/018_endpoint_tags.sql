@@ -0,0 +1,3 @@
+-- Free-form labels for grouping and filtering endpoints, e.g. by team or tier
+ALTER TABLE service_endpoints ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+CREATE INDEX idx_service_endpoints_tags ON service_endpoints USING GIN (tags);